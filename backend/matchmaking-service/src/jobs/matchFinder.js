@@ -6,7 +6,6 @@
 
 const Redis = require('ioredis');
 const { v4: uuidv4 } = require('uuid');
-const https = require('http');
 
 const redis = new Redis(process.env.REDIS_URL || 'redis://localhost:6379');
 
@@ -17,6 +16,10 @@ const TICK_INTERVAL_MS = 2000;
 const MAX_RANK_SPREAD = parseInt(process.env.MAX_RANK_SPREAD || '200');
 const EXPAND_RATE = parseInt(process.env.RANK_SPREAD_EXPAND_RATE || '50');
 const EXPAND_INTERVAL_MS = parseInt(process.env.RANK_SPREAD_EXPAND_INTERVAL_MS || '10000');
+const GAME_SERVER_IP = process.env.GAME_SERVER_IP || '127.0.0.1';
+const GAME_SERVER_PORT = parseInt(process.env.GAME_SERVER_PORT || '7001'); // In production: Agones allocated port
+const GAME_SERVER_ADMIN_URL = process.env.GAME_SERVER_ADMIN_URL || 'http://127.0.0.1:7080';
+const RESERVATION_TTL_SEC = parseInt(process.env.RESERVATION_TTL_SEC || '60');
 
 function getBracketSpread(joinedAt) {
     const waitMs = Date.now() - joinedAt;
@@ -35,6 +38,30 @@ async function notifyMatchFound(userId, matchInfo) {
     }
 }
 
+// Reserve a room on the game server under our match ID so it admits only this roster.
+// Players stay queued if the game server refuses (full, draining, unreachable).
+async function allocateRoom(matchInfo) {
+    const res = await fetch(`${GAME_SERVER_ADMIN_URL}/v1/allocations`, {
+        method: 'POST',
+        headers: {
+            'Content-Type': 'application/json',
+            'X-Server-Secret': process.env.SERVER_SECRET,
+        },
+        body: JSON.stringify({
+            match_id: matchInfo.match_id,
+            game_mode: matchInfo.game_mode,
+            map_id: matchInfo.map_id,
+            players: matchInfo.players,
+            reservation_ttl_sec: RESERVATION_TTL_SEC,
+        }),
+        signal: AbortSignal.timeout(3000),
+    });
+    if (!res.ok) {
+        throw new Error(`game server allocation failed: ${res.status}`);
+    }
+    return res.json();
+}
+
 async function runMatchFinder() {
     try {
         const queueSize = await redis.zcard(QUEUE_KEY);
@@ -71,18 +98,23 @@ async function runMatchFinder() {
             if (group.length >= MIN_PLAYERS) {
                 // Found a match group!
                 const matchId = uuidv4();
-                const serverPort = 7001; // In production: Agones allocated port
-                const serverIp = process.env.GAME_SERVER_IP || '127.0.0.1';
 
                 const matchInfo = {
                     match_id: matchId,
                     game_mode: anchor.game_mode,
                     map_id: 'outpost', // Phase 1 — only Outpost map
-                    server_ip: serverIp,
-                    server_port: serverPort,
+                    server_ip: GAME_SERVER_IP,
+                    server_port: GAME_SERVER_PORT,
                     players: group.map(p => ({ user_id: p.userId, elo: p.elo })),
                 };
 
+                try {
+                    await allocateRoom(matchInfo);
+                } catch (err) {
+                    console.error(`Match ${matchId} not created, players stay queued:`, err.message);
+                    continue;
+                }
+
                 // Remove matched players from queue
                 for (const p of group) {
                     await redis.zrem(QUEUE_KEY, p.userId);
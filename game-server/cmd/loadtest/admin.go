@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// allocate reserves a match for users the way the matchmaker does
+func allocate(o options, matchID string, users []string) error {
+	type player struct {
+		UserID string `json:"user_id"`
+	}
+	req := struct {
+		MatchID           string   `json:"match_id"`
+		Players           []player `json:"players"`
+		ReservationTTLSec int      `json:"reservation_ttl_sec"`
+	}{MatchID: matchID, ReservationTTLSec: int((o.ramp + 30*time.Second).Seconds())}
+	for _, u := range users {
+		req.Players = append(req.Players, player{u})
+	}
+	body, _ := json.Marshal(req)
+
+	r, err := http.NewRequest(http.MethodPost, o.admin+"/v1/allocations", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	r.Header.Set("X-Server-Secret", o.secret)
+	resp, err := http.DefaultClient.Do(r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		var e struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&e)
+		return fmt.Errorf("%s %s", resp.Status, e.Error)
+	}
+	return nil
+}
+
+// serverStats is what the server's /metrics says about the run. Counters
+// count since the server started, and a room's series go when it closes.
+type serverStats struct {
+	tickSeconds float64 // summed over every room's ticks
+	ticks       float64
+	ticksLate   float64
+	ticksMissed float64
+	inputDrops  float64
+	drops       map[string]float64 // packets, by reason
+}
+
+func scrapeServer(o options) (serverStats, error) {
+	s := serverStats{drops: map[string]float64{}}
+	resp, err := http.Get(o.admin + "/metrics")
+	if err != nil {
+		return s, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return s, fmt.Errorf("/metrics: %s", resp.Status)
+	}
+
+	sc := bufio.NewScanner(resp.Body)
+	for sc.Scan() {
+		line := sc.Text()
+		series, value, ok := strings.Cut(line, " ")
+		if !ok || strings.HasPrefix(line, "#") {
+			continue
+		}
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			continue
+		}
+		name, labels, _ := strings.Cut(series, "{")
+		switch name {
+		case "skybattle_room_tick_duration_seconds_sum":
+			s.tickSeconds += n
+		case "skybattle_room_tick_duration_seconds_count":
+			s.ticks += n
+		case "skybattle_room_ticks_late_total":
+			s.ticksLate += n
+		case "skybattle_room_ticks_missed_total":
+			s.ticksMissed += n
+		case "skybattle_room_inputs_dropped_total":
+			s.inputDrops += n
+		case "skybattle_packets_dropped_total":
+			reason := strings.TrimSuffix(strings.TrimPrefix(labels, `reason="`), `"}`)
+			s.drops[reason] += n
+		}
+	}
+	return s, sc.Err()
+}
@@ -0,0 +1,371 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/auth"
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/network"
+)
+
+const (
+	pingInterval = 500 * time.Millisecond
+	pingTimeout  = time.Second // a pong later than this counts as lost
+)
+
+// simClient is one simulated player
+type simClient struct {
+	id      int
+	userID  string
+	matchID string
+	inputHz int
+	local   *net.UDPAddr
+}
+
+// clientStats is what one client measured
+type clientStats struct {
+	failed     string // "auth" or "join" if the client never played
+	played     time.Duration
+	tickRate   int
+	inputHz    int
+	inputs     int
+	matchEnded bool // the match ended or the client was removed before the run did
+
+	snapshots int
+	bytesIn   int64
+	ticks     tickSet
+	gaps      []time.Duration // between snapshots
+	jitter    time.Duration   // RFC 3550 interarrival jitter at the end
+
+	rtts      []time.Duration
+	pings     int
+	pingsLost int
+}
+
+// play runs the client until stop, or until its match ends
+func (c *simClient) play(o options, server *net.UDPAddr, stop time.Time, p *progress) (st clientStats) {
+	st.inputHz = c.inputHz
+	client, err := network.Dial(c.local, server)
+	if err != nil {
+		logger.Printf("Client %d: %v", c.id, err)
+		st.failed = "auth"
+		return st
+	}
+	defer client.Close()
+
+	token := auth.Issue([]byte(o.jwtSecret), auth.Claims{UserID: c.userID, DisplayName: fmt.Sprintf("Load %d", c.id)})
+	if _, err := client.Authenticate(token, 5*time.Second); err != nil {
+		logger.Printf("Client %d: %v", c.id, err)
+		st.failed = "auth"
+		return st
+	}
+
+	// A join is not resent: a second one would seat the client twice
+	init, err := c.join(client, time.Now().Add(5*time.Second))
+	if err != nil {
+		logger.Printf("Client %d: join %s: %v", c.id, c.matchID, err)
+		st.failed = "join"
+		return st
+	}
+	st.tickRate = init.TickRate
+	p.playing.Add(1)
+	joined := time.Now()
+	defer func() {
+		p.playing.Add(-1)
+		st.played = time.Since(joined)
+	}()
+
+	// Snapshots and pongs are read on their own goroutine, which ends when
+	// the socket closes or the server lets the client go
+	var ping pinger
+	readDone := make(chan struct{})
+	go func() {
+		defer close(readDone)
+		c.read(client, &st, &ping, p)
+	}()
+
+	b := newBehaviour(int64(c.id), c.inputHz)
+	ticker := time.NewTicker(time.Second / time.Duration(c.inputHz))
+	defer ticker.Stop()
+	var lastPing time.Time
+	for playing := true; playing; {
+		select {
+		case now := <-ticker.C:
+			if now.After(stop) {
+				playing = false
+				break
+			}
+			if client.Send(b.next()) == nil {
+				st.inputs++
+				p.inputsSent.Add(1)
+			}
+			if now.Sub(lastPing) >= pingInterval {
+				lastPing = now
+				ping.send(now, client)
+			}
+		case <-readDone:
+			playing = false
+		}
+	}
+	client.Close()
+	<-readDone
+
+	st.rtts, st.pings, st.pingsLost = ping.finish(time.Now())
+	return st
+}
+
+// join asks for the client's match and waits for MatchInit
+func (c *simClient) join(client *network.Client, deadline time.Time) (network.MatchInitPacket, error) {
+	var init network.MatchInitPacket
+	if err := client.Send(network.JoinPacket{MatchID: c.matchID}); err != nil {
+		return init, err
+	}
+	for {
+		data, err := client.Read(deadline)
+		if err != nil {
+			return init, err
+		}
+		switch network.ServerPacketType(data[0]) {
+		case network.PacketMatchInit:
+			return init, init.Decode(data[1:])
+		case network.PacketJoinReject:
+			var rej network.JoinRejectPacket
+			rej.Decode(data[1:])
+			return init, fmt.Errorf("rejected: %s", rej.Reason)
+		}
+	}
+}
+
+// worldState decodes only what is measured. Every snapshot carries all the
+// events of the match so far, and only the newest matters.
+type worldState struct {
+	Tick   int `msgpack:"tick"`
+	Events []struct {
+		Type string `msgpack:"type"`
+	} `msgpack:"events"`
+}
+
+// read measures snapshots and pongs until the socket closes, the match ends
+// or the server drops the client
+func (c *simClient) read(client *network.Client, st *clientStats, ping *pinger, p *progress) {
+	interval := time.Second / time.Duration(max(st.tickRate, 1))
+	var lastTick int
+	var lastAt time.Time
+	var jitter float64
+	defer func() { st.jitter = time.Duration(jitter) }()
+	for {
+		data, err := client.Read(time.Time{})
+		if err != nil {
+			return
+		}
+		now := time.Now()
+		switch network.ServerPacketType(data[0]) {
+		case network.PacketPong:
+			ping.pong(now)
+		case network.PacketKick, network.PacketShutdown, network.PacketError:
+			st.matchEnded = true
+			return
+		case network.PacketWorldState:
+			var ws worldState
+			if msgpack.Unmarshal(data[1:], &ws) != nil {
+				continue
+			}
+			st.snapshots++
+			st.bytesIn += int64(len(data))
+			p.snapshots.Add(1)
+			if !st.ticks.add(ws.Tick) {
+				continue // a duplicate
+			}
+			if !lastAt.IsZero() {
+				if ws.Tick < lastTick {
+					st.ticks.late++
+					continue
+				}
+				gap := now.Sub(lastAt)
+				st.gaps = append(st.gaps, gap)
+				// RFC 3550: how far arrivals stray from the server's spacing,
+				// smoothed over the last 16 or so
+				d := gap - time.Duration(ws.Tick-lastTick)*interval
+				jitter += (abs(float64(d)) - jitter) / 16
+			}
+			lastTick, lastAt = ws.Tick, now
+			if n := len(ws.Events); n > 0 && ws.Events[n-1].Type == "MATCH_END" {
+				st.matchEnded = true
+				return
+			}
+		}
+	}
+}
+
+func abs(f float64) float64 {
+	if f < 0 {
+		return -f
+	}
+	return f
+}
+
+// tickSet records which ticks a client got snapshots of
+type tickSet struct {
+	first, last int
+	seen        []uint64 // bit per tick from first
+	unique      int
+	dups, late  int
+}
+
+// add reports whether tick is new
+func (t *tickSet) add(tick int) bool {
+	if t.unique == 0 {
+		t.first, t.last = tick, tick
+	}
+	i := tick - t.first
+	if i < 0 {
+		t.late++ // from before the first snapshot
+		return false
+	}
+	for i/64 >= len(t.seen) {
+		t.seen = append(t.seen, 0)
+	}
+	if t.seen[i/64]&(1<<(i%64)) != 0 {
+		t.dups++
+		return false
+	}
+	t.seen[i/64] |= 1 << (i % 64)
+	t.unique++
+	t.last = max(t.last, tick)
+	return true
+}
+
+// missing counts ticks between the first and last snapshot never received
+func (t *tickSet) missing() int {
+	if t.unique == 0 {
+		return 0
+	}
+	return t.last - t.first + 1 - t.unique
+}
+
+// pinger keeps one ping in flight: a Pong has no body to say which ping it
+// answers
+type pinger struct {
+	mu     sync.Mutex
+	sentAt time.Time
+	rtts   []time.Duration
+	sent   int
+	lost   int
+}
+
+func (p *pinger) send(now time.Time, client *network.Client) {
+	p.mu.Lock()
+	if !p.sentAt.IsZero() {
+		if now.Sub(p.sentAt) < pingTimeout {
+			p.mu.Unlock()
+			return
+		}
+		p.lost++
+	}
+	p.sentAt = now
+	p.sent++
+	p.mu.Unlock()
+	client.Ping()
+}
+
+func (p *pinger) pong(now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.sentAt.IsZero() {
+		p.rtts = append(p.rtts, now.Sub(p.sentAt))
+		p.sentAt = time.Time{}
+	}
+}
+
+// finish settles the ping still in flight: lost if it timed out, otherwise
+// not counted
+func (p *pinger) finish(now time.Time) (rtts []time.Duration, sent, lost int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.sentAt.IsZero() {
+		if now.Sub(p.sentAt) >= pingTimeout {
+			p.lost++
+		} else {
+			p.sent--
+		}
+	}
+	return p.rtts, p.sent, p.lost
+}
+
+// behaviour makes up input the way a player moves: runs one way for a
+// while, jets and fires in bursts, sweeps the aim between targets, and now
+// and then melees, throws a grenade or switches weapon
+type behaviour struct {
+	rng   *rand.Rand
+	hz    int
+	input network.InputPacket
+	aimTo float32
+
+	moveLeft, flyLeft, fireLeft int // inputs until each changes
+}
+
+func newBehaviour(seed int64, hz int) *behaviour {
+	return &behaviour{rng: rand.New(rand.NewSource(seed)), hz: hz}
+}
+
+// inputs returns how many inputs last between lo and hi seconds
+func (b *behaviour) inputs(lo, hi float64) int {
+	return int((lo + b.rng.Float64()*(hi-lo)) * float64(b.hz))
+}
+
+// chance is true about once every sec seconds
+func (b *behaviour) chance(sec float64) bool {
+	return b.rng.Float64()*sec*float64(b.hz) < 1
+}
+
+func (b *behaviour) next() network.InputPacket {
+	in := &b.input
+	in.Sequence++
+
+	if b.moveLeft--; b.moveLeft <= 0 {
+		in.Horizontal = float32(b.rng.Intn(3) - 1)
+		b.moveLeft = b.inputs(0.5, 2)
+	}
+	if b.flyLeft--; b.flyLeft <= 0 {
+		in.IsFlying = !in.IsFlying
+		if in.IsFlying {
+			b.flyLeft = b.inputs(0.3, 1.5)
+		} else {
+			b.flyLeft = b.inputs(0.5, 3)
+		}
+	}
+	in.Vertical = 0
+	if in.IsFlying {
+		in.Vertical = 1
+	}
+
+	// Turn towards the target at up to 720°/s, then pick another
+	step := 720 / float32(b.hz)
+	switch d := b.aimTo - in.AimAngleDeg; {
+	case d > step:
+		in.AimAngleDeg += step
+	case d < -step:
+		in.AimAngleDeg -= step
+	default:
+		in.AimAngleDeg = b.aimTo
+		b.aimTo = float32(b.rng.Intn(360))
+	}
+
+	if b.fireLeft > 0 {
+		b.fireLeft--
+	} else if b.chance(1.5) {
+		b.fireLeft = b.inputs(0.2, 1)
+	}
+	in.Firing = b.fireLeft > 0
+
+	in.Melee = b.chance(8)
+	in.Throw = b.chance(15)
+	in.PlaceMine = b.chance(30)
+	in.Switch = b.chance(20)
+	return *in
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/admin"
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/config"
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/metrics"
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/network"
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/room"
+)
+
+// serveLocal runs a cloud-profile server in this process on free loopback
+// ports and points o at it, with made-up secrets unless they were given. Its matches outlast the run: no time or kill
+// limit ends them early.
+func serveLocal(o *options, matches int) (stop func(), err error) {
+	for _, secret := range []*string{&o.jwtSecret, &o.secret} {
+		if *secret == "" {
+			key := make([]byte, 32)
+			if _, err := rand.Read(key); err != nil {
+				return nil, err
+			}
+			*secret = hex.EncodeToString(key)
+		}
+	}
+
+	probe, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		return nil, err
+	}
+	port := probe.LocalAddr().(*net.UDPAddr).Port
+	probe.Close()
+
+	cfg := &config.Config{
+		Profile:           config.ProfileCloud,
+		Port:              port,
+		TickRate:          o.tickRate,
+		MaxRoomsPerServer: matches + 1, // and the initial room
+		JWTAccessSecret:   o.jwtSecret,
+		ServerSecret:      o.secret,
+		NetSim:            o.netsim,
+	}
+	srv := network.NewServer(cfg)
+	srv.Manager().SetRules(func(gameMode, mapID string) room.Rules {
+		rules := room.DefaultRules()
+		rules.TimeLimitSec = int((o.ramp + o.duration).Seconds()) + 60
+		rules.KillLimit = math.MaxInt32
+		return rules
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Default.Handler())
+	mux.Handle("/", admin.NewAPI(srv.Manager(), srv, cfg.ServerSecret).Handler())
+	adminSrv := &http.Server{Handler: mux}
+	go adminSrv.Serve(ln)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		if err := srv.Start(ctx); err != nil {
+			logger.Fatalf("❌ Server stopped: %v", err)
+		}
+	}()
+
+	o.server = fmt.Sprintf("127.0.0.1:%d", port)
+	o.admin = "http://" + ln.Addr().String()
+	return func() {
+		cancel()
+		adminSrv.Close()
+	}, nil
+}
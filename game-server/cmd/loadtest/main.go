@@ -0,0 +1,174 @@
+// SKYBATTLE Load Test
+// Plays hundreds of simulated clients against a server to find out how many
+// rooms it holds: each one runs the handshake and key exchange, joins a
+// match allocated for it through the admin API and streams input at 30-60
+// Hz, measuring snapshot rate, jitter, loss and round trip time. The report
+// at the end adds the server's own tick timing and drops from /metrics.
+//
+//	go run ./cmd/loadtest -serve -clients 300 -duration 30s
+//
+// -serve runs a server in this process on free ports, so a run needs
+// nothing else; it shares the machine's CPUs with the clients, and -netsim
+// puts a simulated network between them. Without it, point -server and
+// -admin at a server started separately:
+//
+//	go run ./cmd/server &
+//	go run ./cmd/loadtest -clients 300 -duration 30s
+//
+// Clients bind their own loopback addresses (127.0.0.2 and up, a few per
+// address) so the server's per-IP limits treat them as separate households.
+// Linux routes all of 127/8 to loopback; elsewhere add the aliases first or
+// pass -clients-per-ip 0 to send everyone from one address.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type options struct {
+	server       string
+	admin        string
+	secret       string
+	jwtSecret    string
+	serve        bool
+	tickRate     int
+	netsim       string
+	verbose      bool
+	clients      int
+	roomSize     int
+	clientsPerIP int
+	inputHz      int
+	inputHzMax   int
+	duration     time.Duration
+	ramp         time.Duration
+}
+
+// progress is updated by every client for the periodic log line
+type progress struct {
+	playing    atomic.Int64
+	inputsSent atomic.Int64
+	snapshots  atomic.Int64
+}
+
+// logger is the load test's own: with -serve the standard one carries the
+// server's log
+var logger = log.New(os.Stderr, "", log.LstdFlags)
+
+func main() {
+	var o options
+	flag.StringVar(&o.server, "server", "127.0.0.1:7001", "game server UDP address")
+	flag.StringVar(&o.admin, "admin", "http://127.0.0.1:7080", "admin API base URL, for allocating matches and reading /metrics")
+	flag.StringVar(&o.secret, "secret", os.Getenv("SERVER_SECRET"), "admin API secret; -serve makes one up if empty")
+	flag.StringVar(&o.jwtSecret, "jwt-secret", os.Getenv("JWT_ACCESS_SECRET"), "signs the clients' tokens, the server's JWT_ACCESS_SECRET; -serve makes one up if empty")
+	flag.BoolVar(&o.serve, "serve", false, "run a server in this process instead of using -server and -admin")
+	flag.IntVar(&o.tickRate, "tick-rate", 30, "with -serve, the server's tick rate")
+	flag.StringVar(&o.netsim, "netsim", "", `with -serve, simulated network conditions such as "jittery" (see internal/netsim)`)
+	flag.BoolVar(&o.verbose, "v", false, "with -serve, show the server's log")
+	flag.IntVar(&o.clients, "clients", 200, "simulated clients")
+	flag.IntVar(&o.roomSize, "room-size", 10, "clients per match")
+	flag.IntVar(&o.clientsPerIP, "clients-per-ip", 4, "clients sharing each loopback source address, 0 for one address")
+	flag.IntVar(&o.inputHz, "input-hz", 30, "input packets per second, the lowest rate a client picks")
+	flag.IntVar(&o.inputHzMax, "input-hz-max", 60, "the highest input rate a client picks")
+	flag.DurationVar(&o.duration, "duration", 30*time.Second, "how long clients play")
+	flag.DurationVar(&o.ramp, "ramp", 5*time.Second, "spread connections over this long")
+	flag.Parse()
+
+	if o.clients < 1 || o.roomSize < 1 || o.inputHz < 1 || o.tickRate < 1 {
+		logger.Fatalf("❌ -clients, -room-size, -input-hz and -tick-rate must be at least 1")
+	}
+	o.inputHzMax = max(o.inputHzMax, o.inputHz)
+	matches := (o.clients + o.roomSize - 1) / o.roomSize
+
+	if o.serve {
+		if !o.verbose {
+			log.SetOutput(io.Discard)
+		}
+		stop, err := serveLocal(&o, matches)
+		if err != nil {
+			logger.Fatalf("❌ Starting the server: %v", err)
+		}
+		defer stop()
+		logger.Printf("🚀 Server running in this process on UDP %s, admin %s", o.server, o.admin)
+	}
+
+	server, err := net.ResolveUDPAddr("udp", o.server)
+	if err != nil {
+		logger.Fatalf("❌ -server: %v", err)
+	}
+
+	run := strconv.FormatInt(time.Now().Unix(), 36)
+	matchIDs := make([]string, matches)
+	for m := range matchIDs {
+		matchIDs[m] = fmt.Sprintf("loadtest-%s-%d", run, m)
+		var users []string
+		for i := m * o.roomSize; i < min((m+1)*o.roomSize, o.clients); i++ {
+			users = append(users, userID(run, i))
+		}
+		if err := allocate(o, matchIDs[m], users); err != nil {
+			logger.Fatalf("❌ Allocating match %d of %d: %v", m+1, matches, err)
+		}
+	}
+	logger.Printf("🎮 %d matches allocated, starting %d clients at %d-%d Hz input", matches, o.clients, o.inputHz, o.inputHzMax)
+
+	var p progress
+	stats := make([]clientStats, o.clients)
+	var wg sync.WaitGroup
+	start := time.Now()
+	stop := start.Add(o.ramp + o.duration)
+	for i := 0; i < o.clients; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			time.Sleep(o.ramp * time.Duration(i) / time.Duration(o.clients))
+			c := &simClient{
+				id:      i,
+				userID:  userID(run, i),
+				matchID: matchIDs[i/o.roomSize],
+				inputHz: o.inputHz + i%(o.inputHzMax-o.inputHz+1),
+				local:   localAddr(o, i),
+			}
+			stats[i] = c.play(o, server, stop, &p)
+		}(i)
+	}
+
+	ticker := time.NewTicker(5 * time.Second)
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for waiting := true; waiting; {
+		select {
+		case <-ticker.C:
+			logger.Printf("⏱  %s: %d playing, %d inputs sent, %d snapshots received",
+				time.Since(start).Round(time.Second), p.playing.Load(), p.inputsSent.Load(), p.snapshots.Load())
+		case <-done:
+			waiting = false
+		}
+	}
+	ticker.Stop()
+
+	report(os.Stdout, o, matches, stats)
+}
+
+// localAddr spreads clients over loopback source addresses
+func localAddr(o options, i int) *net.UDPAddr {
+	if o.clientsPerIP <= 0 {
+		return nil
+	}
+	n := i/o.clientsPerIP + 2 // from 127.0.0.2
+	return &net.UDPAddr{IP: net.IPv4(127, byte(n>>16), byte(n>>8), byte(n))}
+}
+
+func userID(run string, i int) string {
+	return fmt.Sprintf("loadtest_%s_%04d", run, i)
+}
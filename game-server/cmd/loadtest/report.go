@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"time"
+)
+
+// report prints what the clients measured, then the server's side from
+// /metrics
+func report(w io.Writer, o options, matches int, stats []clientStats) {
+	var (
+		authFailed, joinFailed, ended int
+		inputs, snapshots             int
+		bytesIn                       int64
+		played                        time.Duration
+		expected, missing, dups, late int
+		rates, jitters                []float64
+		gaps, rtts                    []time.Duration
+		pings, pingsLost              int
+		tickRate                      int
+	)
+	for _, st := range stats {
+		switch st.failed {
+		case "auth":
+			authFailed++
+			continue
+		case "join":
+			joinFailed++
+			continue
+		}
+		if st.matchEnded {
+			ended++
+		}
+		tickRate = max(tickRate, st.tickRate)
+		inputs += st.inputs
+		snapshots += st.snapshots
+		bytesIn += st.bytesIn
+		played += st.played
+		if st.played > 0 {
+			rates = append(rates, float64(st.snapshots)/st.played.Seconds())
+		}
+		if st.ticks.unique > 0 {
+			expected += st.ticks.last - st.ticks.first + 1
+			missing += st.ticks.missing()
+		}
+		dups += st.ticks.dups
+		late += st.ticks.late
+		gaps = append(gaps, st.gaps...)
+		if len(st.gaps) > 0 {
+			jitters = append(jitters, ms(st.jitter))
+		}
+		rtts = append(rtts, st.rtts...)
+		pings += st.pings
+		pingsLost += st.pingsLost
+	}
+	playing := len(stats) - authFailed - joinFailed
+	seconds := played.Seconds()
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Clients          %d in %d matches: %d failed auth, %d failed to join, %d dropped or saw their match end early\n",
+		len(stats), matches, authFailed, joinFailed, ended)
+	if playing == 0 || seconds == 0 {
+		return
+	}
+	fmt.Fprintf(w, "Inputs           %d, %.1f/s per client (%d-%d Hz)\n", inputs, float64(inputs)/seconds, o.inputHz, o.inputHzMax)
+
+	sort.Float64s(rates)
+	fmt.Fprintf(w, "Snapshots        %d, %.1f/s per client (worst %.1f, median %.1f), %.1f KB/s per client\n",
+		snapshots, float64(snapshots)/seconds, rates[0], pct(rates, 0.5), float64(bytesIn)/seconds/1024)
+	fmt.Fprintf(w, "Snapshot loss    %s of ticks missing (%d of %d), %d duplicated, %d out of order\n",
+		percent(missing, expected), missing, expected, dups, late)
+	if len(gaps) > 0 {
+		slices.Sort(gaps)
+		fmt.Fprintf(w, "Snapshot gaps    p50 %s  p95 %s  p99 %s  max %s\n",
+			durPct(gaps, 0.5), durPct(gaps, 0.95), durPct(gaps, 0.99), gaps[len(gaps)-1].Round(time.Microsecond*100))
+	}
+	if len(jitters) > 0 {
+		sort.Float64s(jitters)
+		fmt.Fprintf(w, "Jitter           mean %.2fms  p95 %.2fms  worst %.2fms (RFC 3550, per client)\n",
+			mean(jitters), pct(jitters, 0.95), jitters[len(jitters)-1])
+	}
+	if len(rtts) > 0 {
+		slices.Sort(rtts)
+		fmt.Fprintf(w, "Round trip       p50 %s  p95 %s  p99 %s  max %s, %s of %d pings lost\n",
+			durPct(rtts, 0.5), durPct(rtts, 0.95), durPct(rtts, 0.99), rtts[len(rtts)-1].Round(time.Microsecond*100),
+			percent(pingsLost, pings), pings)
+	} else {
+		fmt.Fprintf(w, "Round trip       no pongs, %d pings lost\n", pingsLost)
+	}
+
+	s, err := scrapeServer(o)
+	if err != nil {
+		fmt.Fprintf(w, "Server           unavailable (%v)\n", err)
+		return
+	}
+	if s.ticks > 0 {
+		tick := s.tickSeconds / s.ticks
+		budget := 1 / float64(max(tickRate, 1))
+		fmt.Fprintf(w, "Server ticks     %.2fms mean, %.0f%% of the tick; %.0f late, %.0f missed of %.0f\n",
+			tick*1000, 100*tick/budget, s.ticksLate, s.ticksMissed, s.ticks)
+	}
+	fmt.Fprintf(w, "Server drops     %.0f inputs at full room queues\n", s.inputDrops)
+	reasons := make([]string, 0, len(s.drops))
+	for r := range s.drops {
+		reasons = append(reasons, r)
+	}
+	sort.Strings(reasons)
+	for _, r := range reasons {
+		fmt.Fprintf(w, "                 %.0f packets: %s\n", s.drops[r], r)
+	}
+	if !o.serve {
+		fmt.Fprintln(w, "                 (server counts are since it started)")
+	}
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func mean(fs []float64) float64 {
+	var sum float64
+	for _, f := range fs {
+		sum += f
+	}
+	return sum / float64(len(fs))
+}
+
+// pct is the p-th quantile of sorted
+func pct(sorted []float64, p float64) float64 {
+	return sorted[min(int(p*float64(len(sorted))), len(sorted)-1)]
+}
+
+func durPct(sorted []time.Duration, p float64) time.Duration {
+	return sorted[min(int(p*float64(len(sorted))), len(sorted)-1)].Round(time.Microsecond * 100)
+}
+
+func percent(n, of int) string {
+	if of == 0 {
+		return "0%"
+	}
+	return fmt.Sprintf("%.2f%%", 100*float64(n)/float64(of))
+}
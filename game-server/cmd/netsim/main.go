@@ -0,0 +1,99 @@
+// SKYBATTLE Network Simulator
+// A UDP proxy that puts a bad network between game clients and a local
+// server: latency, jitter, loss, duplication, reordering and bandwidth caps,
+// separately for each client. Point the Unity client (or cmd/loadtest) at
+// the proxy instead of the server:
+//
+//	go run ./cmd/server &
+//	go run ./cmd/netsim -net jittery               # clients connect to :7101
+//	go run ./cmd/netsim -net "4g; 192.168.1.20 3g,loss=10%"
+//
+// Type new conditions and Enter while it runs to switch without
+// reconnecting. To condition the server's own socket instead, start it with
+// -netsim; see internal/netsim for the syntax.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/netsim"
+)
+
+func main() {
+	listen := flag.String("listen", ":7101", "UDP address clients connect to")
+	server := flag.String("server", "127.0.0.1:7001", "game server UDP address")
+	spec := flag.String("net", "jittery", "conditions: presets and settings, then per-client ones after semicolons")
+	presets := flag.Bool("presets", false, "list the presets and exit")
+	flag.Parse()
+
+	if *presets {
+		names := make([]string, 0, len(netsim.Presets))
+		for name := range netsim.Presets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Printf("%-8s %s\n", name, netsim.Presets[name])
+		}
+		return
+	}
+
+	policy, err := netsim.ParsePolicy(*spec)
+	if err != nil {
+		log.Fatalf("❌ -net: %v", err)
+	}
+	proxy, err := netsim.NewProxy(*listen, *server, policy)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	log.Printf("🐢 Relaying UDP %s → %s: %s", proxy.Addr(), *server, policy)
+
+	go func() {
+		sc := bufio.NewScanner(os.Stdin)
+		for sc.Scan() {
+			line := strings.TrimSpace(sc.Text())
+			if line == "" {
+				continue
+			}
+			p, err := netsim.ParsePolicy(line)
+			if err != nil {
+				log.Printf("❌ %v", err)
+				continue
+			}
+			proxy.SetPolicy(p)
+			log.Printf("🐢 Now %s", p)
+		}
+	}()
+
+	go func() {
+		var last int64
+		for range time.Tick(10 * time.Second) {
+			s := &proxy.Stats
+			if n := s.Packets.Load(); n != last {
+				last = n
+				log.Printf("%d packets: %d dropped, %d duplicated, %d reordered",
+					n, s.Dropped.Load(), s.Duplicated.Load(), s.Reordered.Load())
+			}
+		}
+	}()
+
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+		<-sig
+		proxy.Close()
+	}()
+
+	if err := proxy.Serve(); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+// SKYBATTLE Protocol Generator
+// Regenerates the server's packet code and the Unity client's
+// NetworkProtocol.cs from the protocol schema. Run after editing the schema:
+//
+//	go generate ./internal/network
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/protogen"
+)
+
+func main() {
+	schemaPath := flag.String("schema", "protocol.json", "protocol schema")
+	goOut := flag.String("go", "protocol_gen.go", "Go output file, empty to skip")
+	csOut := flag.String("cs", "", "C# output file, empty to skip")
+	flag.Parse()
+
+	s, err := protogen.Load(*schemaPath)
+	if err != nil {
+		log.Fatalf("❌ %s: %v", *schemaPath, err)
+	}
+	source := filepath.Base(*schemaPath)
+
+	if *goOut != "" {
+		src, err := protogen.Go(s, source)
+		if err != nil {
+			log.Fatalf("❌ Generated Go does not parse: %v", err)
+		}
+		write(*goOut, src)
+	}
+	if *csOut != "" {
+		write(*csOut, protogen.CSharp(s, source))
+	}
+}
+
+func write(path string, data []byte) {
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	log.Printf("✅ Wrote %s", path)
+}
@@ -0,0 +1,74 @@
+// SKYBATTLE Replay Inspector
+// Prints the kill timeline and per-player stats of a recorded match
+//
+//	go run ./cmd/replay replays/<match_id>.skyr
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/game"
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/replay"
+)
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: replay <file.skyr>")
+		os.Exit(2)
+	}
+
+	r, err := replay.Open(os.Args[1])
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	defer r.Close()
+
+	m, err := replay.Reconstruct(r)
+	if err != nil {
+		log.Fatalf("❌ Replay corrupt after tick %d: %v", m.LastTick, err)
+	}
+
+	h := m.Header
+	tickDur := time.Second / time.Duration(h.TickRate)
+	fmt.Printf("Match %s — %s on %s @ %d TPS\n", h.MatchID, h.GameMode, h.MapID, h.TickRate)
+	fmt.Printf("Started %s, %d ticks (%s)\n\n", h.StartedAt.Format(time.RFC3339), m.LastTick, (time.Duration(m.LastTick) * tickDur).Round(time.Second))
+
+	fmt.Println("Timeline")
+	for _, ev := range m.Timeline {
+		at := time.Duration(ev.Tick) * tickDur
+		stamp := fmt.Sprintf("%02d:%06.3f", int(at.Minutes()), (at % time.Minute).Seconds())
+		switch ev.Type {
+		case "KILL":
+			fmt.Printf("  [%s] tick %-6d %s killed %s (%s)\n", stamp, ev.Tick, name(m, ev.ActorID), name(m, ev.TargetID), game.Weapons[ev.WeaponID].Name)
+		default:
+			fmt.Printf("  [%s] tick %-6d %s\n", stamp, ev.Tick, ev.Type)
+		}
+	}
+
+	fmt.Println("\nScoreboard")
+	tw := tabwriter.NewWriter(os.Stdout, 2, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "  ID\tNAME\tTEAM\tKILLS\tDEATHS\tSHOTS\tINPUTS")
+	for _, ps := range m.Ranking() {
+		display := ps.Name
+		if ps.IsBot {
+			display += " (bot)"
+		}
+		fmt.Fprintf(tw, "  %d\t%s\t%s\t%d\t%d\t%d\t%d\n", ps.ID, display, ps.Team, ps.Kills, ps.Deaths, ps.ShotsFired, ps.InputCount)
+	}
+	tw.Flush()
+
+	if h.GameMode == "TDM" {
+		fmt.Printf("\nTeam kills: RED %d — BLUE %d\n", m.TeamKills["RED"], m.TeamKills["BLUE"])
+	}
+}
+
+func name(m *replay.Match, id int) string {
+	if ps, ok := m.Players[id]; ok {
+		return ps.Name
+	}
+	return fmt.Sprintf("#%d", id)
+}
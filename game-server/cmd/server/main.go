@@ -3,19 +3,210 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/admin"
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/balance"
 	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/config"
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/control"
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/discovery"
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/lifecycle"
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/metrics"
 	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/network"
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/report"
 )
 
 func main() {
-	cfg := config.Load()
-	log.Printf("🚀 SKYBATTLE Game Server starting on UDP :%d (tick rate: %d TPS)", cfg.Port, cfg.TickRate)
-	
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	log.Printf("🚀 SKYBATTLE Game Server starting on UDP :%d (tick rate: %d TPS, profile: %s)", cfg.Port, cfg.TickRate, cfg.Profile)
+
+	if cfg.Offline() && cfg.HostTokenSecret == "" {
+		cfg.HostTokenSecret = randomSecret()
+	}
+
+	var balanceReloader *balance.Reloader
+	if cfg.WeaponsFile != "" {
+		balanceReloader = balance.NewReloader(cfg.WeaponsFile, 2*time.Second)
+		if err := balanceReloader.Load(); err != nil {
+			log.Fatalf("❌ Weapon balance: %v", err)
+		}
+	}
+
 	srv := network.NewServer(cfg)
-	if err := srv.Start(); err != nil {
+	srv.Manager().SetRules(cfg.MatchRules.Resolve)
+
+	var sender report.Sender = report.NewSpoolSender(cfg.ReportDir)
+	if cfg.ProfileServiceURL != "" {
+		sender = report.NewHTTPSender(cfg.ProfileServiceURL, cfg.ServerSecret)
+	} else {
+		log.Printf("📥 No profile service, match reports spooled to %s", cfg.ReportDir)
+	}
+	reporter := report.NewReporter(sender)
+	if cfg.ProfileServiceURL != "" && cfg.ReportDir != "" {
+		// Reports the profile service could not take, in this run or an
+		// earlier one, wait in ReportDir until the next start
+		reporter.SpoolFailures(cfg.ReportDir)
+		go uploadSpooled(cfg.ReportDir, sender)
+	}
+	srv.Manager().SetFinishHandler(reporter.Enqueue)
+
+	metrics.Default.OnCollect(func() {
+		for state, n := range srv.Manager().CountByState() {
+			metrics.Rooms.With(string(state)).Set(float64(n))
+		}
+	})
+
+	var adminSrv *http.Server
+	if cfg.AdminPort != 0 {
+		api := admin.NewAPI(srv.Manager(), srv, cfg.ServerSecret)
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.Default.Handler())
+		mux.Handle("/", api.Handler())
+		adminSrv = &http.Server{Addr: net.JoinHostPort(cfg.AdminHost, strconv.Itoa(cfg.AdminPort)), Handler: mux}
+		go func() {
+			log.Printf("🛠  Admin API and /metrics listening on HTTP %s", adminSrv.Addr)
+			if err := adminSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Printf("❌ Admin API stopped: %v", err)
+			}
+		}()
+	}
+
+	// SIGTERM (Kubernetes) or Ctrl-C starts a drain; a second signal kills immediately
+	sigCtx, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+	// The embedding host app can request the same shutdown over the control protocol
+	shutdownCtx, requestShutdown := context.WithCancel(sigCtx)
+	defer requestShutdown()
+
+	serveCtx, stopServing := context.WithCancel(context.Background())
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- srv.Start(serveCtx) }()
+
+	if balanceReloader != nil {
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		go balanceReloader.Run(serveCtx, hup)
+	}
+
+	sdk := lifecycle.New(cfg.LifecycleSDK, cfg.AgonesSDKPort)
+	keeper := lifecycle.NewKeeper(sdk, func() lifecycle.Load {
+		humans, bots := srv.Manager().PlayerCount()
+		return lifecycle.Load{
+			Rooms:         len(srv.Manager().ListRooms()),
+			ActiveMatches: srv.Manager().ActiveMatches(),
+			Players:       humans,
+			Bots:          bots,
+			MaxRooms:      srv.Manager().MaxRooms(),
+		}
+	}, 2*time.Second)
+	go keeper.Run(serveCtx)
+	log.Printf("📡 Lifecycle SDK: %s", cfg.LifecycleSDK)
+
+	if cfg.LANDiscoveryPort != 0 {
+		responder := discovery.NewResponder(
+			fmt.Sprintf(":%d", cfg.LANDiscoveryPort),
+			fmt.Sprintf("%s:%d", cfg.LANBroadcastAddr, cfg.LANDiscoveryPort),
+			discovery.DefaultInterval,
+			discovery.RoomInfo(srv.Manager(), cfg.LANHostName, cfg.Port),
+		)
+		go func() {
+			if err := responder.Run(serveCtx); err != nil {
+				log.Printf("❌ LAN discovery stopped: %v", err)
+			}
+		}()
+		log.Printf("📶 LAN discovery advertising as %q on UDP :%d", cfg.LANHostName, cfg.LANDiscoveryPort)
+	}
+
+	if cfg.ControlStdin {
+		host := &control.Host{
+			Manager:     srv.Manager(),
+			Profile:     cfg.Profile,
+			TokenSecret: cfg.HostTokenSecret,
+			ReportDir:   cfg.ReportDir,
+			Shutdown:    requestShutdown,
+		}
+		go func() {
+			if err := host.Serve(os.Stdin, os.Stdout); err != nil {
+				log.Printf("❌ Control protocol stopped: %v", err)
+			}
+		}()
+		log.Printf("🎛  Accepting control commands on stdin")
+	}
+
+	select {
+	case err := <-serveErr:
 		log.Fatalf("❌ Server failed: %v", err)
+	case <-shutdownCtx.Done():
+	}
+	stopSignals()
+
+	log.Printf("🛑 Shutdown requested, draining (up to %ds for running matches)", cfg.DrainTimeoutSec)
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), time.Duration(cfg.DrainTimeoutSec)*time.Second)
+	srv.Drain(drainCtx)
+	cancelDrain()
+
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), 30*time.Second)
+	if err := reporter.Close(flushCtx); err != nil {
+		log.Printf("❌ %v", err)
+	}
+	cancelFlush()
+
+	stopServing()
+	if err := <-serveErr; err != nil {
+		log.Printf("❌ Server failed during shutdown: %v", err)
+	}
+
+	sdkCtx, cancelSDK := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := sdk.Shutdown(sdkCtx); err != nil {
+		log.Printf("❌ Lifecycle Shutdown failed: %v", err)
+	}
+	cancelSDK()
+
+	if adminSrv != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		adminSrv.Shutdown(ctx)
+		cancel()
+	}
+	log.Printf("👋 SKYBATTLE Game Server stopped")
+}
+
+func randomSecret() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		log.Fatalf("❌ Generating host token secret: %v", err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// uploadSpooled delivers the reports an earlier run could not
+func uploadSpooled(dir string, sender report.Sender) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	n, err := report.Upload(ctx, dir, sender)
+	if n > 0 {
+		log.Printf("📤 Uploaded %d spooled match reports", n)
+	}
+	if err != nil {
+		log.Printf("❌ Uploading spooled match reports: %v", err)
 	}
 }
 
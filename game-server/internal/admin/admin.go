@@ -0,0 +1,287 @@
+// SKYBATTLE — Admin HTTP API
+// Live operations for the admin dashboard: rooms, players, kicks, drain.
+// Every route requires the shared ServerSecret in the X-Server-Secret header
+// (or as a Bearer token), same as internal calls to the profile service.
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/room"
+)
+
+// SessionKicker drops the network session bound to a player so the kicked
+// client stops receiving state. network.Server implements it.
+type SessionKicker interface {
+	KickPlayer(roomID string, playerID int, reason string) bool
+}
+
+type API struct {
+	manager *room.Manager
+	kicker  SessionKicker
+	secret  string
+}
+
+func NewAPI(manager *room.Manager, kicker SessionKicker, secret string) *API {
+	return &API{manager: manager, kicker: kicker, secret: secret}
+}
+
+// Handler returns the routed, authenticated admin API.
+func (a *API) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/status", a.getStatus)
+	mux.HandleFunc("POST /v1/drain", a.drain)
+	mux.HandleFunc("GET /v1/rooms", a.listRooms)
+	mux.HandleFunc("POST /v1/rooms", a.createRoom)
+	mux.HandleFunc("GET /v1/rooms/{id}", a.getRoom)
+	mux.HandleFunc("POST /v1/rooms/{id}/kick", a.kickPlayer)
+	mux.HandleFunc("POST /v1/rooms/{id}/end", a.endRoom)
+	mux.HandleFunc("POST /v1/allocations", a.allocate)
+	return a.authenticate(mux)
+}
+
+func (a *API) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get("X-Server-Secret")
+		if token == "" {
+			token = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		}
+		if a.secret == "" || subtle.ConstantTimeCompare([]byte(token), []byte(a.secret)) != 1 {
+			writeError(w, http.StatusUnauthorized, "UNAUTHORIZED")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ── Handlers ──────────────────────────────────────────────────────────────────
+
+type statusResponse struct {
+	Draining bool `json:"draining"`
+	Rooms    int  `json:"rooms"`
+	MaxRooms int  `json:"max_rooms"`
+}
+
+func (a *API) getStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, statusResponse{
+		Draining: a.manager.IsDraining(),
+		Rooms:    len(a.manager.ListRooms()),
+		MaxRooms: a.manager.MaxRooms(),
+	})
+}
+
+func (a *API) drain(w http.ResponseWriter, r *http.Request) {
+	a.manager.Drain()
+	a.getStatus(w, r)
+}
+
+func (a *API) listRooms(w http.ResponseWriter, r *http.Request) {
+	rooms := a.manager.ListRooms()
+	infos := make([]room.RoomInfo, 0, len(rooms))
+	for _, rm := range rooms {
+		infos = append(infos, rm.Info())
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"rooms": infos})
+}
+
+type createRoomRequest struct {
+	GameMode string `json:"game_mode"`
+	MapID    string `json:"map_id"`
+}
+
+func (a *API) createRoom(w http.ResponseWriter, r *http.Request) {
+	var req createRoomRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_BODY")
+		return
+	}
+	if req.GameMode == "" {
+		req.GameMode = "FFA"
+	}
+	if req.MapID == "" {
+		req.MapID = "outpost"
+	}
+	if !room.ValidGameMode(req.GameMode) || !room.ValidMapID(req.MapID) {
+		writeError(w, http.StatusBadRequest, "INVALID_BODY")
+		return
+	}
+
+	rm, err := a.manager.CreateRoom(req.GameMode, req.MapID)
+	switch {
+	case errors.Is(err, room.ErrDraining), errors.Is(err, room.ErrServerFull):
+		writeError(w, http.StatusServiceUnavailable, errorCode(err))
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, "INTERNAL_SERVER_ERROR")
+		return
+	}
+
+	log.Printf("Admin: created room %s (%s on %s)", rm.ID, req.GameMode, req.MapID)
+	writeJSON(w, http.StatusCreated, rm.Info())
+}
+
+type roomDetail struct {
+	room.RoomInfo
+	PlayerList []room.PlayerSummary `json:"player_list"`
+}
+
+func (a *API) getRoom(w http.ResponseWriter, r *http.Request) {
+	rm, ok := a.manager.GetRoom(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "ROOM_NOT_FOUND")
+		return
+	}
+	writeJSON(w, http.StatusOK, roomDetail{RoomInfo: rm.Info(), PlayerList: rm.PlayerSummaries()})
+}
+
+type kickRequest struct {
+	PlayerID int    `json:"player_id"`
+	Reason   string `json:"reason"`
+}
+
+func (a *API) kickPlayer(w http.ResponseWriter, r *http.Request) {
+	roomID := r.PathValue("id")
+	rm, ok := a.manager.GetRoom(roomID)
+	if !ok {
+		writeError(w, http.StatusNotFound, "ROOM_NOT_FOUND")
+		return
+	}
+
+	var req kickRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.PlayerID <= 0 {
+		writeError(w, http.StatusBadRequest, "INVALID_BODY")
+		return
+	}
+	if req.Reason == "" {
+		req.Reason = "kicked by admin"
+	}
+
+	kicked := false
+	if a.kicker != nil {
+		kicked = a.kicker.KickPlayer(roomID, req.PlayerID, req.Reason)
+	} else if rm.HasPlayer(req.PlayerID) {
+		rm.RemovePlayer(req.PlayerID)
+		kicked = true
+	}
+	if !kicked {
+		writeError(w, http.StatusNotFound, "PLAYER_NOT_FOUND")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"kicked": req.PlayerID})
+}
+
+func (a *API) endRoom(w http.ResponseWriter, r *http.Request) {
+	rm, ok := a.manager.GetRoom(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "ROOM_NOT_FOUND")
+		return
+	}
+	rm.End("ended by admin")
+	writeJSON(w, http.StatusOK, rm.Info())
+}
+
+type allocationRequest struct {
+	MatchID  string `json:"match_id"`
+	GameMode string `json:"game_mode"`
+	MapID    string `json:"map_id"`
+	Players  []struct {
+		UserID string `json:"user_id"`
+	} `json:"players"`
+	ReservationTTLSec int `json:"reservation_ttl_sec"`
+}
+
+type allocationResponse struct {
+	MatchID   string    `json:"match_id"`
+	GameMode  string    `json:"game_mode"`
+	MapID     string    `json:"map_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// allocate is called by the matchmaker before it sends players here: the room
+// is created under the matchmaker's match ID and admits only the listed users.
+func (a *API) allocate(w http.ResponseWriter, r *http.Request) {
+	var req allocationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_BODY")
+		return
+	}
+	if req.GameMode == "" {
+		req.GameMode = "FFA"
+	}
+	if req.MapID == "" {
+		req.MapID = "outpost"
+	}
+	if !room.ValidGameMode(req.GameMode) || !room.ValidMapID(req.MapID) {
+		writeError(w, http.StatusBadRequest, "INVALID_BODY")
+		return
+	}
+
+	res := room.Reservation{
+		MatchID:  req.MatchID,
+		GameMode: req.GameMode,
+		MapID:    req.MapID,
+		TTL:      time.Duration(req.ReservationTTLSec) * time.Second,
+	}
+	for _, p := range req.Players {
+		if p.UserID != "" {
+			res.UserIDs = append(res.UserIDs, p.UserID)
+		}
+	}
+
+	rm, created, err := a.manager.Reserve(res)
+	switch {
+	case errors.Is(err, room.ErrInvalidRoster), errors.Is(err, room.ErrBadMatchID):
+		writeError(w, http.StatusBadRequest, "INVALID_BODY")
+		return
+	case errors.Is(err, room.ErrMatchIDInUse):
+		writeError(w, http.StatusConflict, "MATCH_ID_IN_USE")
+		return
+	case errors.Is(err, room.ErrDraining), errors.Is(err, room.ErrServerFull):
+		writeError(w, http.StatusServiceUnavailable, errorCode(err))
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, "INTERNAL_SERVER_ERROR")
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	writeJSON(w, status, allocationResponse{
+		MatchID:   rm.ID,
+		GameMode:  rm.GameMode,
+		MapID:     rm.MapID,
+		ExpiresAt: rm.ReservedUntil(),
+	})
+}
+
+// ── Helpers ───────────────────────────────────────────────────────────────────
+
+func errorCode(err error) string {
+	switch {
+	case errors.Is(err, room.ErrDraining):
+		return "SERVER_DRAINING"
+	case errors.Is(err, room.ErrServerFull):
+		return "SERVER_FULL"
+	}
+	return "INTERNAL_SERVER_ERROR"
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Admin: encoding response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, code string) {
+	writeJSON(w, status, map[string]string{"error": code})
+}
@@ -0,0 +1,226 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/room"
+)
+
+const testSecret = "test_secret"
+
+type fakeKicker struct {
+	manager *room.Manager
+	kicked  []int
+}
+
+func (k *fakeKicker) KickPlayer(roomID string, playerID int, reason string) bool {
+	r, ok := k.manager.GetRoom(roomID)
+	if !ok || !r.HasPlayer(playerID) {
+		return false
+	}
+	r.RemovePlayer(playerID)
+	k.kicked = append(k.kicked, playerID)
+	return true
+}
+
+func newTestServer(t *testing.T, maxRooms int) (*httptest.Server, *room.Manager, *fakeKicker) {
+	t.Helper()
+	m := room.NewManager(maxRooms, 30)
+	k := &fakeKicker{manager: m}
+	srv := httptest.NewServer(NewAPI(m, k, testSecret).Handler())
+	t.Cleanup(srv.Close)
+	return srv, m, k
+}
+
+func do(t *testing.T, srv *httptest.Server, method, path, body string, out interface{}) int {
+	t.Helper()
+	req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-Server-Secret", testSecret)
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("%s %s: decoding body: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestRejectsMissingOrWrongSecret(t *testing.T) {
+	srv, _, _ := newTestServer(t, 5)
+
+	resp, err := srv.Client().Get(srv.URL + "/v1/rooms")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("no secret: got %d, want 401", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest("GET", srv.URL+"/v1/rooms", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	resp, err = srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("wrong secret: got %d, want 401", resp.StatusCode)
+	}
+}
+
+func TestCreateListAndInspectRooms(t *testing.T) {
+	srv, m, _ := newTestServer(t, 5)
+
+	var created room.RoomInfo
+	if code := do(t, srv, "POST", "/v1/rooms", `{"game_mode":"TDM","map_id":"catacombs"}`, &created); code != http.StatusCreated {
+		t.Fatalf("create: got %d", code)
+	}
+	if created.GameMode != "TDM" || created.MapID != "catacombs" || created.State != room.StateWaiting {
+		t.Fatalf("unexpected room: %+v", created)
+	}
+
+	r, _ := m.GetRoom(created.ID)
+	r.AddPlayer("user-1", "Alice")
+	r.SpawnBots(2, "")
+
+	var list struct {
+		Rooms []room.RoomInfo `json:"rooms"`
+	}
+	if code := do(t, srv, "GET", "/v1/rooms", "", &list); code != http.StatusOK {
+		t.Fatalf("list: got %d", code)
+	}
+	if len(list.Rooms) != 1 || list.Rooms[0].Players != 1 || list.Rooms[0].Bots != 2 {
+		t.Fatalf("unexpected list: %+v", list.Rooms)
+	}
+
+	var detail roomDetail
+	if code := do(t, srv, "GET", "/v1/rooms/"+created.ID, "", &detail); code != http.StatusOK {
+		t.Fatalf("get: got %d", code)
+	}
+	if len(detail.PlayerList) != 3 || detail.PlayerList[0].Name != "Alice" || !detail.PlayerList[1].IsBot {
+		t.Fatalf("unexpected players: %+v", detail.PlayerList)
+	}
+
+	if code := do(t, srv, "GET", "/v1/rooms/missing", "", nil); code != http.StatusNotFound {
+		t.Fatalf("missing room: got %d, want 404", code)
+	}
+
+	for _, body := range []string{`{"game_mode":"CTF"}`, `{"map_id":"moon"}`} {
+		if code := do(t, srv, "POST", "/v1/rooms", body, nil); code != http.StatusBadRequest {
+			t.Fatalf("create %s: got %d, want 400", body, code)
+		}
+		if code := do(t, srv, "POST", "/v1/allocations", body, nil); code != http.StatusBadRequest {
+			t.Fatalf("allocate %s: got %d, want 400", body, code)
+		}
+	}
+}
+
+func TestKickPlayer(t *testing.T) {
+	srv, m, k := newTestServer(t, 5)
+	r, _ := m.CreateRoom("FFA", "outpost")
+	p, _ := r.AddPlayer("user-1", "Alice")
+
+	if code := do(t, srv, "POST", "/v1/rooms/"+r.ID+"/kick", `{"player_id":99}`, nil); code != http.StatusNotFound {
+		t.Fatalf("kick unknown player: got %d, want 404", code)
+	}
+	if code := do(t, srv, "POST", "/v1/rooms/"+r.ID+"/kick", `{"player_id":1,"reason":"afk"}`, nil); code != http.StatusOK {
+		t.Fatalf("kick: got %d", code)
+	}
+	if r.HasPlayer(p.ID) || len(k.kicked) != 1 {
+		t.Fatalf("player still in room after kick")
+	}
+}
+
+func TestEndRoom(t *testing.T) {
+	srv, m, _ := newTestServer(t, 5)
+	r, _ := m.CreateRoom("FFA", "outpost")
+
+	var info room.RoomInfo
+	if code := do(t, srv, "POST", "/v1/rooms/"+r.ID+"/end", "", &info); code != http.StatusOK {
+		t.Fatalf("end: got %d", code)
+	}
+	if info.State != room.StateFinished {
+		t.Fatalf("state = %s, want FINISHED", info.State)
+	}
+}
+
+func TestDrainRejectsNewRooms(t *testing.T) {
+	srv, _, _ := newTestServer(t, 5)
+
+	var status statusResponse
+	if code := do(t, srv, "POST", "/v1/drain", "", &status); code != http.StatusOK || !status.Draining {
+		t.Fatalf("drain: got %d %+v", code, status)
+	}
+
+	var errBody map[string]string
+	if code := do(t, srv, "POST", "/v1/rooms", `{}`, &errBody); code != http.StatusServiceUnavailable || errBody["error"] != "SERVER_DRAINING" {
+		t.Fatalf("create while draining: got %d %v", code, errBody)
+	}
+}
+
+func TestCreateRoomAtCapacity(t *testing.T) {
+	srv, _, _ := newTestServer(t, 1)
+
+	if code := do(t, srv, "POST", "/v1/rooms", `{}`, nil); code != http.StatusCreated {
+		t.Fatalf("first create: got %d", code)
+	}
+	var errBody map[string]string
+	if code := do(t, srv, "POST", "/v1/rooms", `{}`, &errBody); code != http.StatusServiceUnavailable || errBody["error"] != "SERVER_FULL" {
+		t.Fatalf("create at capacity: got %d %v", code, errBody)
+	}
+}
+
+func TestAllocateReservesRoomForRoster(t *testing.T) {
+	srv, m, _ := newTestServer(t, 5)
+	body := `{"match_id":"match-42","game_mode":"TDM","map_id":"outpost","players":[{"user_id":"u1","elo":1000},{"user_id":"u2","elo":1010}]}`
+
+	var alloc allocationResponse
+	if code := do(t, srv, "POST", "/v1/allocations", body, &alloc); code != http.StatusCreated {
+		t.Fatalf("allocate: got %d", code)
+	}
+	if alloc.MatchID != "match-42" || alloc.ExpiresAt.IsZero() {
+		t.Fatalf("unexpected allocation: %+v", alloc)
+	}
+
+	r, ok := m.GetRoom("match-42")
+	if !ok || !r.IsReserved() || r.GameMode != "TDM" {
+		t.Fatalf("room not reserved under match id")
+	}
+	if _, err := r.AddPlayer("stranger", "Eve"); err != room.ErrNotOnRoster {
+		t.Fatalf("stranger join: err = %v, want ErrNotOnRoster", err)
+	}
+	if _, err := r.AddPlayer("u1", "Alice"); err != nil {
+		t.Fatalf("rostered join: %v", err)
+	}
+
+	// Matchmaker retries are idempotent; a different match under the same id is not
+	if code := do(t, srv, "POST", "/v1/allocations", body, nil); code != http.StatusOK {
+		t.Fatalf("repeat allocate: got %d, want 200", code)
+	}
+	conflict := `{"match_id":"match-42","game_mode":"FFA","players":[{"user_id":"u3"}]}`
+	if code := do(t, srv, "POST", "/v1/allocations", conflict, nil); code != http.StatusConflict {
+		t.Fatalf("conflicting allocate: got %d, want 409", code)
+	}
+	if code := do(t, srv, "POST", "/v1/allocations", `{"match_id":"x","players":[]}`, nil); code != http.StatusBadRequest {
+		t.Fatalf("empty roster: got %d, want 400", code)
+	}
+	for _, id := range []string{"../../etc/cron.d/x", "match 1", "m\u00e9", strings.Repeat("m", 65)} {
+		body := `{"match_id":` + strconv.Quote(id) + `,"players":[{"user_id":"u1"}]}`
+		if code := do(t, srv, "POST", "/v1/allocations", body, nil); code != http.StatusBadRequest {
+			t.Errorf("match id %q: got %d, want 400", id, code)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+// SKYBATTLE — Access Token Verification
+// Verifies the HS256 JWTs issued by the auth service (payload: userId, displayName)
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMalformed    = errors.New("malformed token")
+	ErrBadSignature = errors.New("invalid token signature")
+	ErrExpired      = errors.New("token expired")
+)
+
+type Claims struct {
+	UserID      string `json:"userId"`
+	DisplayName string `json:"displayName"`
+	ExpiresAt   int64  `json:"exp,omitempty"`
+}
+
+type Verifier struct {
+	secret []byte
+	now    func() time.Time
+	guests bool
+}
+
+// NewVerifier checks tokens against the shared JWT_ACCESS_SECRET. With an
+// empty secret (local dev) nothing can be checked, so every token, JWT or
+// not, gets a stable guest identity rather than the account it claims.
+func NewVerifier(secret string) *Verifier {
+	return &Verifier{secret: []byte(secret), now: time.Now}
+}
+
+// NewHostVerifier is for offline/LAN hosting: tokens signed with the host's
+// own secret keep their identity, and any other token (a LAN guest, or an
+// online account the host cannot check) is admitted as a guest.
+func NewHostVerifier(secret string) *Verifier {
+	return &Verifier{secret: []byte(secret), now: time.Now, guests: true}
+}
+
+func (v *Verifier) Verify(token string) (Claims, error) {
+	c, err := v.verify(token)
+	if err != nil && v.guests {
+		return guestClaims(token), nil
+	}
+	return c, err
+}
+
+func (v *Verifier) verify(token string) (Claims, error) {
+	if len(v.secret) == 0 {
+		return guestClaims(token), nil
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Claims{}, ErrMalformed
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Claims{}, ErrMalformed
+	}
+
+	if header.Alg != "HS256" {
+		return Claims{}, ErrBadSignature
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Claims{}, ErrMalformed
+	}
+	if !hmac.Equal(sig, Sign(v.secret, parts[0]+"."+parts[1])) {
+		return Claims{}, ErrBadSignature
+	}
+
+	var c Claims
+	if err := decodeSegment(parts[1], &c); err != nil || c.UserID == "" {
+		return Claims{}, ErrMalformed
+	}
+	if c.ExpiresAt != 0 && v.now().Unix() >= c.ExpiresAt {
+		return Claims{}, ErrExpired
+	}
+	if c.DisplayName == "" {
+		c.DisplayName = "Player"
+	}
+	return c, nil
+}
+
+// Sign returns the HS256 signature of a JWT signing input
+func Sign(secret []byte, signingInput string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signingInput))
+	return mac.Sum(nil)
+}
+
+// Issue creates an HS256 token; used for locally hosted matches and tests
+func Issue(secret []byte, c Claims) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	body, _ := json.Marshal(c)
+	input := header + "." + base64.RawURLEncoding.EncodeToString(body)
+	return input + "." + base64.RawURLEncoding.EncodeToString(Sign(secret, input))
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	raw, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
+
+func guestClaims(token string) Claims {
+	sum := sha256.Sum256([]byte(token))
+	return Claims{UserID: "guest_" + hex.EncodeToString(sum[:6]), DisplayName: "Player"}
+}
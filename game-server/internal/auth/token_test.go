@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVerifyAuthServiceToken(t *testing.T) {
+	secret := []byte("access_secret")
+	token := Issue(secret, Claims{UserID: "u-1", DisplayName: "Ace", ExpiresAt: time.Now().Add(time.Minute).Unix()})
+
+	c, err := NewVerifier("access_secret").Verify(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.UserID != "u-1" || c.DisplayName != "Ace" {
+		t.Fatalf("claims = %+v", c)
+	}
+
+	if _, err := NewVerifier("other_secret").Verify(token); err != ErrBadSignature {
+		t.Fatalf("wrong secret: err = %v, want ErrBadSignature", err)
+	}
+	if _, err := NewVerifier("access_secret").Verify("not-a-jwt"); err != ErrMalformed {
+		t.Fatalf("garbage: err = %v, want ErrMalformed", err)
+	}
+}
+
+func TestVerifyRejectsExpired(t *testing.T) {
+	secret := []byte("s")
+	token := Issue(secret, Claims{UserID: "u-1", ExpiresAt: time.Now().Add(-time.Second).Unix()})
+	if _, err := NewVerifier("s").Verify(token); err != ErrExpired {
+		t.Fatalf("err = %v, want ErrExpired", err)
+	}
+}
+
+func TestDevModeGuestIdentity(t *testing.T) {
+	v := NewVerifier("")
+	a, err := v.Verify("device-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := v.Verify("device-b")
+	again, _ := v.Verify("device-a")
+	if a.UserID == b.UserID || a.UserID != again.UserID {
+		t.Fatalf("guest ids not stable per token: %s %s %s", a.UserID, b.UserID, again.UserID)
+	}
+
+	// Without a secret an unsigned JWT cannot claim an account
+	forged, err := v.Verify(Issue(nil, Claims{UserID: "u-1", DisplayName: "Ace"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if forged.UserID == "u-1" {
+		t.Fatalf("unsigned token kept its identity: %+v", forged)
+	}
+}
+
+func TestHostVerifierAdmitsGuests(t *testing.T) {
+	v := NewHostVerifier("host_secret")
+
+	host, err := v.Verify(Issue([]byte("host_secret"), Claims{UserID: "host", DisplayName: "Host"}))
+	if err != nil || host.UserID != "host" {
+		t.Fatalf("host token: %+v %v", host, err)
+	}
+
+	cloud := Issue([]byte("cloud_secret"), Claims{UserID: "u-1", DisplayName: "Ace"})
+	guest, err := v.Verify(cloud)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if guest.UserID == "u-1" || guest.UserID == "" {
+		t.Fatalf("unverifiable token kept its identity: %+v", guest)
+	}
+}
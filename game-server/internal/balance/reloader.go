@@ -0,0 +1,69 @@
+// SKYBATTLE — Balance Reloader
+// Loads the weapon balance file at startup and again on SIGHUP or whenever
+// the file changes on disk. A file that fails validation is logged and the
+// previous table stays in force.
+package balance
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/game"
+)
+
+type Reloader struct {
+	path     string
+	interval time.Duration
+	modTime  time.Time
+}
+
+// NewReloader watches path by polling its modification time every interval
+func NewReloader(path string, interval time.Duration) *Reloader {
+	return &Reloader{path: path, interval: interval}
+}
+
+// Load reads the file and publishes it for rooms created from now on
+func (r *Reloader) Load() error {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return err
+	}
+	table, err := game.LoadWeapons(r.path)
+	if err != nil {
+		return err
+	}
+	r.modTime = info.ModTime()
+	game.SetWeapons(table)
+	log.Printf("Balance: loaded %d weapons from %s (applies to new rooms)", len(table), r.path)
+	return nil
+}
+
+// Run reloads on every value from hup and on file changes until ctx is done
+func (r *Reloader) Run(ctx context.Context, hup <-chan os.Signal) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			r.reload("SIGHUP")
+		case <-ticker.C:
+			if info, err := os.Stat(r.path); err == nil && !info.ModTime().Equal(r.modTime) {
+				r.reload("file changed")
+			}
+		}
+	}
+}
+
+func (r *Reloader) reload(why string) {
+	if err := r.Load(); err != nil {
+		log.Printf("Balance: reload (%s) rejected, keeping current values: %v", why, err)
+		// Don't retry the same broken file every tick
+		if info, statErr := os.Stat(r.path); statErr == nil {
+			r.modTime = info.ModTime()
+		}
+	}
+}
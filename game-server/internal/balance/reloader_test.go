@@ -0,0 +1,84 @@
+package balance
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/game"
+)
+
+func shippedFile(t *testing.T) (path string, body string) {
+	t.Helper()
+	data, err := os.ReadFile("../game/weapons.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path = filepath.Join(t.TempDir(), "weapons.json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path, string(data)
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not reached")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestReloadOnSignalAndKeepOnBadFile(t *testing.T) {
+	t.Cleanup(func() { game.SetWeapons(game.Weapons) })
+	path, body := shippedFile(t)
+
+	r := NewReloader(path, time.Hour)
+	if err := r.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	hup := make(chan os.Signal, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Run(ctx, hup)
+
+	tuned := strings.Replace(body, `"damage_per_shot": 80, "fire_rate_per_sec": 0.5`, `"damage_per_shot": 95, "fire_rate_per_sec": 0.5`, 1)
+	os.WriteFile(path, []byte(tuned), 0o644)
+	hup <- syscall.SIGHUP
+	waitFor(t, func() bool { return game.CurrentWeapons()[game.WeaponSniperRifle].DamagePerShot == 95 })
+
+	// A broken file is rejected and the tuned values stay
+	os.WriteFile(path, []byte(strings.Replace(tuned, `"pellets": 8`, `"pellets": 0`, 1)), 0o644)
+	hup <- syscall.SIGHUP
+	time.Sleep(50 * time.Millisecond)
+	if got := game.CurrentWeapons()[game.WeaponSniperRifle].DamagePerShot; got != 95 {
+		t.Fatalf("bad file replaced the table: sniper damage %v", got)
+	}
+}
+
+func TestReloadOnFileChange(t *testing.T) {
+	t.Cleanup(func() { game.SetWeapons(game.Weapons) })
+	path, body := shippedFile(t)
+
+	r := NewReloader(path, 10*time.Millisecond)
+	if err := r.Load(); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Run(ctx, nil)
+
+	os.WriteFile(path, []byte(strings.Replace(body, `"max_ammo": 45`, `"max_ammo": 50`, 1)), 0o644)
+	// Make sure the mtime moves even on coarse-grained filesystems
+	later := time.Now().Add(time.Second)
+	os.Chtimes(path, later, later)
+	waitFor(t, func() bool { return game.CurrentWeapons()[game.WeaponSMG].MaxAmmo == 50 })
+}
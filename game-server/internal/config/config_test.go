@@ -0,0 +1,104 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "server.json")
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// cloudSecrets sets the secrets the cloud profile cannot run without
+func cloudSecrets(t *testing.T) {
+	t.Setenv("JWT_ACCESS_SECRET", "access_secret")
+	t.Setenv("SERVER_SECRET", "server_secret")
+}
+
+func TestLayering(t *testing.T) {
+	path := writeFile(t, `{"port": 7101, "tick_rate": 60, "max_rooms_per_server": 5}`)
+	cloudSecrets(t)
+	t.Setenv("TICK_RATE", "40")
+	t.Setenv("MAX_ROOMS_PER_SERVER", "8")
+
+	c, err := Load([]string{"-config", path, "-max-rooms", "9", "-control-stdin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Port != 7101 || c.TickRate != 40 || c.MaxRoomsPerServer != 9 || !c.ControlStdin {
+		t.Fatalf("file < env < flags not respected: port=%d tick=%d rooms=%d stdin=%v", c.Port, c.TickRate, c.MaxRoomsPerServer, c.ControlStdin)
+	}
+	if c.AdminPort != 7080 || c.AdminHost != "127.0.0.1" || c.ReplayDir != "" || c.LANDiscoveryPort != 0 {
+		t.Fatalf("unset value lost its default: admin_host=%q admin_port=%d replay_dir=%q lan_discovery_port=%d", c.AdminHost, c.AdminPort, c.ReplayDir, c.LANDiscoveryPort)
+	}
+}
+
+func TestAdminNeedsRealSecret(t *testing.T) {
+	cloudSecrets(t)
+	t.Setenv("SERVER_SECRET", "dev_server_secret")
+	if _, err := Load(nil); err == nil || !strings.Contains(err.Error(), "server_secret") {
+		t.Fatalf("public dev secret accepted for the admin API: %v", err)
+	}
+	if _, err := Load([]string{"-admin-port", "0"}); err != nil {
+		t.Fatalf("admin API disabled: %v", err)
+	}
+}
+
+func TestProfileFromFileSelectsDefaults(t *testing.T) {
+	c, err := Load([]string{"-config", writeFile(t, `{"profile": "offline"}`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.Offline() || c.ProfileServiceURL != "" || c.MaxRoomsPerServer != 2 || c.LANDiscoveryPort != 7002 {
+		t.Fatalf("offline defaults not applied: %+v", c)
+	}
+}
+
+func TestValidationErrors(t *testing.T) {
+	t.Setenv("SERVER_PORT", "seven")
+	path := writeFile(t, `{"lifecycle_sdk": "k8s", "netsim": "latency=slow", "match_rules": {"modes": {"CTF": {}}, "maps": {"outpost": {"bot_fill": 12, "bot_difficulty": "godlike"}}}}`)
+
+	_, err := Load([]string{"-config", path})
+	if err == nil {
+		t.Fatal("invalid config accepted")
+	}
+	for _, want := range []string{"SERVER_PORT", "lifecycle_sdk", `unknown game mode "CTF"`, "bot_fill 12", `bot_difficulty "godlike"`, "netsim: latency", "jwt_access_secret"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error does not mention %s:\n%v", want, err)
+		}
+	}
+}
+
+func TestUnknownFileKey(t *testing.T) {
+	if _, err := Load([]string{"-config", writeFile(t, `{"tickrate": 30}`)}); err == nil || !strings.Contains(err.Error(), "tickrate") {
+		t.Fatalf("typo in config file not reported: %v", err)
+	}
+}
+
+func TestMatchRulesResolve(t *testing.T) {
+	cloudSecrets(t)
+	c, err := Load([]string{"-config", writeFile(t, `{"match_rules": {
+		"default": {"time_limit_sec": 600},
+		"modes": {"TDM": {"kill_limit": 40, "max_players": 12}},
+		"maps": {"catacombs": {"max_players": 8, "bot_fill": 6}}
+	}}`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ffa := c.MatchRules.Resolve("FFA", "outpost")
+	if ffa.TimeLimitSec != 600 || ffa.KillLimit != 20 || ffa.MaxPlayers != 10 {
+		t.Fatalf("FFA/outpost = %+v", ffa)
+	}
+	tdm := c.MatchRules.Resolve("TDM", "catacombs")
+	if tdm.KillLimit != 40 || tdm.MaxPlayers != 8 || tdm.BotFill != 6 || tdm.TimeLimitSec != 600 {
+		t.Fatalf("TDM/catacombs = %+v", tdm)
+	}
+}
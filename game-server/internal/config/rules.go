@@ -0,0 +1,117 @@
+// SKYBATTLE — Match Rules Config
+// The "match_rules" section of the config file. Overrides layer as
+// default < game mode < map, and only the keys present are changed:
+//
+//	"match_rules": {
+//	  "default": {"time_limit_sec": 300},
+//	  "modes":   {"TDM": {"kill_limit": 40, "friendly_fire": false}},
+//	  "maps":    {"catacombs": {"max_players": 8, "bot_fill": 6, "bot_difficulty": "hard"}}
+//	}
+package config
+
+import (
+	"fmt"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/game"
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/room"
+)
+
+type MatchRules struct {
+	Default RulesOverride            `json:"default"`
+	Modes   map[string]RulesOverride `json:"modes"`
+	Maps    map[string]RulesOverride `json:"maps"`
+}
+
+// RulesOverride uses pointers so an absent key leaves the lower layer alone
+type RulesOverride struct {
+	MaxPlayers      *int     `json:"max_players,omitempty"`
+	TimeLimitSec    *int     `json:"time_limit_sec,omitempty"`
+	KillLimit       *int     `json:"kill_limit,omitempty"`
+	RespawnDelaySec *float64 `json:"respawn_delay_sec,omitempty"`
+	FriendlyFire    *bool    `json:"friendly_fire,omitempty"`
+	BotFill         *int     `json:"bot_fill,omitempty"`
+	BotDifficulty   *string  `json:"bot_difficulty,omitempty"`
+}
+
+func (o RulesOverride) apply(r *room.Rules) {
+	if o.MaxPlayers != nil {
+		r.MaxPlayers = *o.MaxPlayers
+	}
+	if o.TimeLimitSec != nil {
+		r.TimeLimitSec = *o.TimeLimitSec
+	}
+	if o.KillLimit != nil {
+		r.KillLimit = *o.KillLimit
+	}
+	if o.RespawnDelaySec != nil {
+		r.RespawnDelaySec = *o.RespawnDelaySec
+	}
+	if o.FriendlyFire != nil {
+		r.FriendlyFire = *o.FriendlyFire
+	}
+	if o.BotFill != nil {
+		r.BotFill = *o.BotFill
+	}
+	if o.BotDifficulty != nil {
+		r.BotDifficulty = *o.BotDifficulty
+	}
+}
+
+// Resolve returns the rules for a room; suitable for room.Manager.SetRules
+func (m MatchRules) Resolve(gameMode, mapID string) room.Rules {
+	r := room.DefaultRules()
+	m.Default.apply(&r)
+	m.Modes[gameMode].apply(&r)
+	m.Maps[mapID].apply(&r)
+	return r
+}
+
+func (m MatchRules) validate() []error {
+	var errs []error
+	for mode := range m.Modes {
+		if !contains(room.GameModes, mode) {
+			errs = append(errs, fmt.Errorf("match_rules.modes: unknown game mode %q", mode))
+		}
+	}
+	for mapID := range m.Maps {
+		if !contains(room.MapIDs, mapID) {
+			errs = append(errs, fmt.Errorf("match_rules.maps: unknown map %q", mapID))
+		}
+	}
+
+	// Check every combination, since a map override can undo a mode's
+	for _, mode := range room.GameModes {
+		for _, mapID := range room.MapIDs {
+			r := m.Resolve(mode, mapID)
+			where := fmt.Sprintf("match_rules for %s on %s", mode, mapID)
+			if r.MaxPlayers < 1 || r.MaxPlayers > 64 {
+				errs = append(errs, fmt.Errorf("%s: max_players %d must be 1-64", where, r.MaxPlayers))
+			}
+			if r.TimeLimitSec < 1 {
+				errs = append(errs, fmt.Errorf("%s: time_limit_sec %d must be positive", where, r.TimeLimitSec))
+			}
+			if r.KillLimit < 1 {
+				errs = append(errs, fmt.Errorf("%s: kill_limit %d must be positive", where, r.KillLimit))
+			}
+			if r.RespawnDelaySec < 0 {
+				errs = append(errs, fmt.Errorf("%s: respawn_delay_sec %g must not be negative", where, r.RespawnDelaySec))
+			}
+			if r.BotFill < 0 || r.BotFill > r.MaxPlayers {
+				errs = append(errs, fmt.Errorf("%s: bot_fill %d must be 0-%d (max_players)", where, r.BotFill, r.MaxPlayers))
+			}
+			if _, ok := game.BotProfileFor(r.BotDifficulty); !ok {
+				errs = append(errs, fmt.Errorf("%s: bot_difficulty %q must be one of %v", where, r.BotDifficulty, game.BotDifficulties()))
+			}
+		}
+	}
+	return errs
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
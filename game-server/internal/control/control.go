@@ -0,0 +1,166 @@
+// SKYBATTLE — Host Control Protocol
+// Lets the app embedding the server (ServerProcessManager in the Unity client)
+// drive it over stdin/stdout: one JSON request per line in, one JSON response
+// per line out. Logs stay on stderr so the two never mix.
+//
+//	{"id":"1","cmd":"start_room","game_mode":"FFA","map_id":"outpost"}
+//	{"id":"1","ok":true,"data":{"id":"…","state":"WAITING",…}}
+package control
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"sync"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/auth"
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/report"
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/room"
+)
+
+type Request struct {
+	ID          string `json:"id,omitempty"`
+	Cmd         string `json:"cmd"`
+	GameMode    string `json:"game_mode,omitempty"`
+	MapID       string `json:"map_id,omitempty"`
+	RoomID      string `json:"room_id,omitempty"`
+	UserID      string `json:"user_id,omitempty"`
+	DisplayName string `json:"display_name,omitempty"`
+}
+
+type Response struct {
+	ID    string      `json:"id,omitempty"`
+	OK    bool        `json:"ok"`
+	Error string      `json:"error,omitempty"`
+	Data  interface{} `json:"data,omitempty"`
+}
+
+type Status struct {
+	Profile        string          `json:"profile"`
+	Draining       bool            `json:"draining"`
+	Rooms          []room.RoomInfo `json:"rooms"`
+	Players        int             `json:"players"`
+	Bots           int             `json:"bots"`
+	PendingReports int             `json:"pending_reports"`
+}
+
+var (
+	errUnknownCommand = errors.New("UNKNOWN_COMMAND")
+	errRoomNotFound   = errors.New("ROOM_NOT_FOUND")
+	errInvalidRequest = errors.New("INVALID_REQUEST")
+)
+
+// Host executes control commands against the running server
+type Host struct {
+	Manager     *room.Manager
+	Profile     string
+	TokenSecret string // signs tokens handed out by the "token" command
+	ReportDir   string
+	Shutdown    func() // starts the same drain as SIGTERM
+
+	shutdownOnce sync.Once
+}
+
+// Serve handles requests from in until it is closed. The host app owning our
+// stdin going away means nobody can stop us any more, so EOF shuts down too.
+func (h *Host) Serve(in io.Reader, out io.Writer) error {
+	enc := json.NewEncoder(out)
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		if err := enc.Encode(h.handle(line)); err != nil {
+			return err
+		}
+	}
+	log.Printf("Control: input closed, shutting down")
+	h.shutdown()
+	return scanner.Err()
+}
+
+func (h *Host) handle(line []byte) Response {
+	var req Request
+	if err := json.Unmarshal(line, &req); err != nil {
+		return Response{Error: errInvalidRequest.Error()}
+	}
+	data, err := h.execute(req)
+	if err != nil {
+		code := err.Error()
+		switch {
+		case errors.Is(err, room.ErrDraining):
+			code = "SERVER_DRAINING"
+		case errors.Is(err, room.ErrServerFull):
+			code = "SERVER_FULL"
+		}
+		return Response{ID: req.ID, Error: code}
+	}
+	return Response{ID: req.ID, OK: true, Data: data}
+}
+
+func (h *Host) execute(req Request) (interface{}, error) {
+	switch req.Cmd {
+	case "status":
+		return h.status(), nil
+
+	case "start_room":
+		if req.GameMode == "" {
+			req.GameMode = "FFA"
+		}
+		if req.MapID == "" {
+			req.MapID = "outpost"
+		}
+		if !room.ValidGameMode(req.GameMode) || !room.ValidMapID(req.MapID) {
+			return nil, errInvalidRequest
+		}
+		r, err := h.Manager.CreateRoom(req.GameMode, req.MapID)
+		if err != nil {
+			return nil, err
+		}
+		return r.Info(), nil
+
+	case "stop_room":
+		r, ok := h.Manager.GetRoom(req.RoomID)
+		if !ok {
+			return nil, errRoomNotFound
+		}
+		r.End("stopped by host")
+		h.Manager.RemoveRoom(r.ID)
+		return r.Info(), nil
+
+	case "token":
+		if req.UserID == "" {
+			return nil, errInvalidRequest
+		}
+		token := auth.Issue([]byte(h.TokenSecret), auth.Claims{UserID: req.UserID, DisplayName: req.DisplayName})
+		return map[string]string{"token": token}, nil
+
+	case "shutdown":
+		h.shutdown()
+		return h.status(), nil
+	}
+	return nil, errUnknownCommand
+}
+
+func (h *Host) status() Status {
+	st := Status{Profile: h.Profile, Draining: h.Manager.IsDraining(), Rooms: []room.RoomInfo{}}
+	for _, r := range h.Manager.ListRooms() {
+		st.Rooms = append(st.Rooms, r.Info())
+	}
+	st.Players, st.Bots = h.Manager.PlayerCount()
+	if files, err := report.Pending(h.ReportDir); err == nil {
+		st.PendingReports = len(files)
+	}
+	return st
+}
+
+func (h *Host) shutdown() {
+	h.shutdownOnce.Do(func() {
+		if h.Shutdown != nil {
+			h.Shutdown()
+		}
+	})
+}
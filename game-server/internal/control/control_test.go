@@ -0,0 +1,88 @@
+package control
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/auth"
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/room"
+)
+
+func run(t *testing.T, h *Host, input string) []Response {
+	t.Helper()
+	var out bytes.Buffer
+	if err := h.Serve(strings.NewReader(input), &out); err != nil {
+		t.Fatal(err)
+	}
+	var resps []Response
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var r Response
+		if err := dec.Decode(&r); err != nil {
+			t.Fatal(err)
+		}
+		resps = append(resps, r)
+	}
+	return resps
+}
+
+func TestHostCommands(t *testing.T) {
+	m := room.NewManager(1, 20)
+	shutdowns := 0
+	h := &Host{Manager: m, Profile: "offline", TokenSecret: "host", ReportDir: t.TempDir(), Shutdown: func() { shutdowns++ }}
+
+	resps := run(t, h, strings.Join([]string{
+		`{"id":"1","cmd":"start_room","map_id":"catacombs"}`,
+		`{"id":"2","cmd":"start_room"}`,
+		`{"id":"3","cmd":"token","user_id":"host-player","display_name":"Me"}`,
+		`{"id":"4","cmd":"status"}`,
+		`{"id":"5","cmd":"fly"}`,
+		`not json`,
+		`{"id":"7","cmd":"start_room","game_mode":"CTF"}`,
+	}, "\n"))
+	if len(resps) != 7 {
+		t.Fatalf("got %d responses, want 7", len(resps))
+	}
+
+	if !resps[0].OK || resps[0].ID != "1" {
+		t.Fatalf("start_room: %+v", resps[0])
+	}
+	if resps[1].OK || resps[1].Error != "SERVER_FULL" {
+		t.Fatalf("start_room over limit: %+v", resps[1])
+	}
+
+	token := resps[2].Data.(map[string]interface{})["token"].(string)
+	claims, err := auth.NewHostVerifier("host").Verify(token)
+	if err != nil || claims.UserID != "host-player" || claims.DisplayName != "Me" {
+		t.Fatalf("host token: %+v %v", claims, err)
+	}
+
+	rooms := resps[3].Data.(map[string]interface{})["rooms"].([]interface{})
+	if len(rooms) != 1 {
+		t.Fatalf("status rooms = %v", rooms)
+	}
+	if resps[4].Error != "UNKNOWN_COMMAND" || resps[5].Error != "INVALID_REQUEST" || resps[6].Error != "INVALID_REQUEST" {
+		t.Fatalf("bad requests: %+v %+v %+v", resps[4], resps[5], resps[6])
+	}
+
+	// Input closing (host app gone) shuts the server down exactly once
+	if shutdowns != 1 {
+		t.Fatalf("shutdowns = %d, want 1", shutdowns)
+	}
+}
+
+func TestStopRoom(t *testing.T) {
+	m := room.NewManager(2, 20)
+	r, _ := m.CreateRoom("FFA", "outpost")
+	h := &Host{Manager: m, ReportDir: t.TempDir()}
+
+	resps := run(t, h, `{"cmd":"stop_room","room_id":"`+r.ID+`"}`+"\n"+`{"cmd":"stop_room","room_id":"nope"}`)
+	if !resps[0].OK || resps[1].Error != "ROOM_NOT_FOUND" {
+		t.Fatalf("stop_room: %+v", resps)
+	}
+	if _, ok := m.GetRoom(r.ID); ok {
+		t.Fatal("room still listed after stop_room")
+	}
+}
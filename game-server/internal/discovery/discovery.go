@@ -0,0 +1,161 @@
+// SKYBATTLE — LAN Discovery
+// Advertises this server to the Unity client's LANDiscovery scanner: a
+// LANServerData JSON datagram broadcast to the discovery port every couple of
+// seconds, plus a unicast reply to anyone who sends QueryMessage.
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"time"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/room"
+)
+
+const (
+	DefaultPort = 7002
+	// Same period as LANDiscovery.BroadcastInterval; the client drops a server
+	// after 5s without hearing from it.
+	DefaultInterval = 2 * time.Second
+	QueryMessage    = "SKYBATTLE_DISCOVER"
+)
+
+// ServerData mirrors the client's LANServerData. Field names are the JSON keys
+// because JsonUtility matches on them exactly; IPAddress and LastSeen are
+// filled in by the client from the datagram it received.
+type ServerData struct {
+	HostName    string
+	MapName     string
+	PlayerCount int
+	MaxPlayers  int
+	Port        int
+	IPAddress   string
+	LastSeen    int64
+}
+
+type Responder struct {
+	listenAddr    string
+	broadcastAddr string
+	interval      time.Duration
+	info          func() (ServerData, bool)
+}
+
+// NewResponder answers queries arriving on listenAddr and broadcasts to
+// broadcastAddr every interval. info reports what to advertise; returning
+// false (draining, no open room) keeps the server quiet so clients forget it.
+func NewResponder(listenAddr, broadcastAddr string, interval time.Duration, info func() (ServerData, bool)) *Responder {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return &Responder{listenAddr: listenAddr, broadcastAddr: broadcastAddr, interval: interval, info: info}
+}
+
+// Run advertises until ctx is done. If the discovery port is already bound
+// by something that does not share it (a scanning client on the same
+// machine), the responder still broadcasts but cannot answer queries.
+func (r *Responder) Run(ctx context.Context) error {
+	target, err := net.ResolveUDPAddr("udp4", r.broadcastAddr)
+	if err != nil {
+		return fmt.Errorf("discovery: broadcast address: %w", err)
+	}
+
+	lc := net.ListenConfig{Control: reuseAddr}
+	pc, err := lc.ListenPacket(ctx, "udp4", r.listenAddr)
+	if err != nil {
+		log.Printf("Discovery: cannot listen on %s (%v), broadcasting only", r.listenAddr, err)
+		if pc, err = net.ListenPacket("udp4", ":0"); err != nil {
+			return fmt.Errorf("discovery: %w", err)
+		}
+	} else {
+		go r.answerQueries(ctx, pc)
+	}
+	conn := pc.(*net.UDPConn)
+	defer conn.Close()
+
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		if data, ok := r.announcement(); ok {
+			if _, err := conn.WriteToUDP(data, target); err != nil && ctx.Err() == nil {
+				log.Printf("Discovery: broadcast to %s failed: %v", target, err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Responder) answerQueries(ctx context.Context, pc net.PacketConn) {
+	buf := make([]byte, 512)
+	for {
+		n, addr, err := pc.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+		// Our own broadcasts and other servers' announcements land here too
+		if !bytes.Equal(bytes.TrimSpace(buf[:n]), []byte(QueryMessage)) {
+			continue
+		}
+		// Discovery is for the local network only; never reply off-LAN
+		udpAddr, ok := addr.(*net.UDPAddr)
+		if !ok || !isLocal(udpAddr.IP) {
+			continue
+		}
+		if data, ok := r.announcement(); ok {
+			pc.WriteTo(data, addr)
+		}
+	}
+}
+
+func (r *Responder) announcement() ([]byte, bool) {
+	d, ok := r.info()
+	if !ok {
+		return nil, false
+	}
+	data, err := json.Marshal(d)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+func isLocal(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast()
+}
+
+// RoomInfo advertises the room a LAN client lands in when it joins without a
+// match ID: the first room that is not reserved for a matchmade roster.
+func RoomInfo(m *room.Manager, hostName string, gamePort int) func() (ServerData, bool) {
+	return func() (ServerData, bool) {
+		if m.IsDraining() {
+			return ServerData{}, false
+		}
+		for _, r := range m.ListRooms() {
+			info := r.Info()
+			if info.Reserved || info.State == room.StateFinished {
+				continue
+			}
+			return ServerData{
+				HostName:    hostName,
+				MapName:     info.MapID,
+				PlayerCount: info.Players,
+				MaxPlayers:  info.MaxPlayers,
+				Port:        gamePort,
+			}, true
+		}
+		return ServerData{}, false
+	}
+}
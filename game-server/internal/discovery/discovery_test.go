@@ -0,0 +1,141 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/room"
+)
+
+func freePort(t *testing.T) int {
+	t.Helper()
+	c, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	return c.LocalAddr().(*net.UDPAddr).Port
+}
+
+// lanServerData decodes the way the client's JsonUtility does: by field name
+type lanServerData struct {
+	HostName    string
+	MapName     string
+	PlayerCount int
+	MaxPlayers  int
+	Port        int
+}
+
+func readAnnouncement(t *testing.T, c net.PacketConn) lanServerData {
+	t.Helper()
+	c.SetReadDeadline(time.Now().Add(3 * time.Second))
+	buf := make([]byte, 512)
+	for {
+		n, _, err := c.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("no announcement received: %v", err)
+		}
+		var d lanServerData
+		if json.Unmarshal(buf[:n], &d) == nil && d.HostName != "" {
+			return d
+		}
+	}
+}
+
+func startResponder(t *testing.T, m *room.Manager, listenPort, scanPort int) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	r := NewResponder(
+		fmt.Sprintf("127.0.0.1:%d", listenPort),
+		fmt.Sprintf("127.255.255.255:%d", scanPort),
+		100*time.Millisecond,
+		RoomInfo(m, "test-host", 7001),
+	)
+	go func() {
+		defer close(done)
+		if err := r.Run(ctx); err != nil {
+			t.Errorf("Run: %v", err)
+		}
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func TestBroadcastReachesScanner(t *testing.T) {
+	m := room.NewManager(5, 30)
+	r, _ := m.CreateRoom("FFA", "catacombs")
+	r.AddPlayer("u1", "Alice")
+	r.AddPlayer("u2", "Bob")
+
+	scanPort := freePort(t)
+	lc := net.ListenConfig{Control: reuseAddr}
+	scanner, err := lc.ListenPacket(context.Background(), "udp4", fmt.Sprintf(":%d", scanPort))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer scanner.Close()
+
+	startResponder(t, m, freePort(t), scanPort)
+
+	got := readAnnouncement(t, scanner)
+	want := lanServerData{HostName: "test-host", MapName: "catacombs", PlayerCount: 2, MaxPlayers: 10, Port: 7001}
+	if got != want {
+		t.Fatalf("announcement = %+v, want %+v", got, want)
+	}
+}
+
+func TestAnswersQuery(t *testing.T) {
+	m := room.NewManager(5, 30)
+	m.CreateRoom("FFA", "outpost")
+
+	listenPort := freePort(t)
+	startResponder(t, m, listenPort, freePort(t))
+
+	client, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	server := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: listenPort}
+	// The responder may not be bound yet on the first attempt
+	for i := 0; i < 10; i++ {
+		client.WriteTo([]byte(QueryMessage), server)
+		client.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+		buf := make([]byte, 512)
+		n, _, err := client.ReadFrom(buf)
+		if err != nil {
+			continue
+		}
+		var d lanServerData
+		if err := json.Unmarshal(buf[:n], &d); err != nil {
+			t.Fatalf("reply is not LANServerData JSON: %q", buf[:n])
+		}
+		if d.MapName != "outpost" || d.Port != 7001 {
+			t.Fatalf("unexpected reply: %+v", d)
+		}
+		return
+	}
+	t.Fatal("no reply to discovery query")
+}
+
+func TestSilentWhileDraining(t *testing.T) {
+	m := room.NewManager(5, 30)
+	m.CreateRoom("FFA", "outpost")
+	info := RoomInfo(m, "test-host", 7001)
+
+	if _, ok := info(); !ok {
+		t.Fatal("open room not advertised")
+	}
+	m.Drain()
+	if _, ok := info(); ok {
+		t.Fatal("draining server still advertised")
+	}
+}
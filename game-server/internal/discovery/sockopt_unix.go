@@ -0,0 +1,20 @@
+//go:build unix
+
+package discovery
+
+import (
+	"syscall"
+)
+
+// reuseAddr lets the responder share the discovery port with other sockets
+// that also opt in, so several servers on one host can all hear queries.
+func reuseAddr(network, address string, c syscall.RawConn) error {
+	var sockErr error
+	err := c.Control(func(fd uintptr) {
+		sockErr = syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_REUSEADDR, 1)
+	})
+	if err != nil {
+		return err
+	}
+	return sockErr
+}
@@ -9,9 +9,10 @@ import (
 type BotState string
 
 const (
-	StatePatrol BotState = "PATROL"
-	StateChase  BotState = "CHASE"
-	StateAttack BotState = "ATTACK"
+	StatePatrol  BotState = "PATROL"
+	StateChase   BotState = "CHASE"
+	StateAttack  BotState = "ATTACK"
+	StateRetreat BotState = "RETREAT" // low on health, heading for a health pickup
 )
 
 type BotController struct {
@@ -21,34 +22,63 @@ type BotController struct {
 	TargetPos   Vec2
 	LastUpdate  time.Time
 	TargetTick  int
-	Difficulty  float32 // 0.0 to 1.0
+	Profile     BotProfile
+	Weapons     WeaponTable // the room's balance snapshot, for lead and range
+
+	// Nav is the map's waypoint graph; nil falls back to steering straight at the target
+	Nav        *NavGraph
+	path       []NavStep
+	pathGoal   Vec2
+	bestDist   float64 // closest we've been to path[0], for spotting a stuck bot
+	stuckFor   float32
+	stuckCount int
+	patrolIdx  int
+
+	clock        float32 // seconds of match time seen by this bot
+	spottedAt    float32 // when Target was acquired
+	nextSwitchAt float32
 }
 
-func NewBotController(player *Player, difficulty float32) *BotController {
+const (
+	waypointReachedDist = 0.75
+	patrolReachedDist   = 1.5
+	repathDistance      = 3.0 // re-plan a chase once the target strays this far from the planned goal
+	stuckReplanSec      = 1.5 // re-plan after this long without getting closer to the next waypoint
+	stuckGiveUp         = 2   // re-plans in a row before patrol moves on to the next point
+
+	pickupSeekRange   = 15.0
+	disengageMargin   = 4.0 // attack turns back into chase this far beyond the preferred range
+	botSwitchCooldown = 2.0
+)
+
+func NewBotController(player *Player, profile BotProfile) *BotController {
 	return &BotController{
 		Player:     player,
 		State:      StatePatrol,
-		Difficulty: difficulty,
+		Profile:    profile,
 		LastUpdate: time.Now(),
+		patrolIdx:  -1,
 	}
 }
 
-func (b *BotController) Update(deltaTime float32, players map[int]*Player) PlayerInput {
+func (b *BotController) Update(deltaTime float32, players map[int]*Player, pickups []*Pickup) PlayerInput {
 	b.Player.Lock()
 	defer b.Player.Unlock()
 
 	if !b.Player.IsAlive {
+		b.Target = nil
 		return PlayerInput{}
 	}
+	b.clock += deltaTime
 
 	input := PlayerInput{
 		Sequence: b.Player.LastInputSeq + 1,
-		WeaponID: uint8(b.Player.PrimaryWeapon),
+		WeaponID: uint8(b.Player.ActiveWeapon()),
 	}
 
 	// 1. Perception: Find closest enemy
 	var closestEnemy *Player
-	minDist := 20.0 // Detection range
+	minDist := b.Profile.DetectionRange
 
 	for _, p := range players {
 		if p.ID == b.Player.ID || !p.IsAlive || p.Team == b.Player.Team {
@@ -60,13 +90,36 @@ func (b *BotController) Update(deltaTime float32, players map[int]*Player) Playe
 			closestEnemy = p
 		}
 	}
+	if closestEnemy != b.Target {
+		// Reaction time and aim settling start over on a new target
+		b.Target = closestEnemy
+		b.spottedAt = b.clock
+	}
+
+	// Hurt bots break off to heal, still shooting back on the way
+	if b.Profile.RetreatHealth > 0 && b.Player.Health < b.Profile.RetreatHealth {
+		if pk := nearestPickup(pickups, b.Player.Position, func(pk *Pickup) bool { return pk.Type == PickupHealth }); pk != nil {
+			b.State = StateRetreat
+			b.navigateTo(pk.Position, deltaTime, &input)
+			if b.Target != nil {
+				b.shoot(&input)
+			}
+			return input
+		}
+	}
+	if b.State == StateRetreat {
+		b.State = StatePatrol
+	}
 
 	// 2. Decision Making (FSM)
 	switch b.State {
 	case StatePatrol:
-		if closestEnemy != nil {
+		if b.Target != nil {
 			b.State = StateChase
-			b.Target = closestEnemy
+		} else if pk := b.wantedPickup(pickups); pk != nil {
+			b.navigateTo(pk.Position, deltaTime, &input)
+		} else if b.Nav != nil {
+			b.patrol(deltaTime, &input)
 		} else {
 			// Random movement
 			if time.Since(b.LastUpdate).Seconds() > 2.0 {
@@ -80,40 +133,40 @@ func (b *BotController) Update(deltaTime float32, players map[int]*Player) Playe
 		}
 
 	case StateChase:
-		if closestEnemy == nil {
+		if b.Target == nil {
 			b.State = StatePatrol
-			b.Target = nil
 		} else {
-			b.Target = closestEnemy
 			dist := b.Player.Position.Distance(b.Target.Position)
-			if dist < 8.0 {
+			if dist <= EngagementRangeFor(b.Player.ActiveWeapon()).Max {
 				b.State = StateAttack
 			} else {
-				b.moveTowards(b.Target.Position, &input)
+				b.navigateTo(b.Target.Position, deltaTime, &input)
 			}
 		}
 
 	case StateAttack:
-		if closestEnemy == nil || !closestEnemy.IsAlive {
+		if b.Target == nil {
 			b.State = StatePatrol
-			b.Target = nil
 		} else {
-			b.Target = closestEnemy
 			dist := b.Player.Position.Distance(b.Target.Position)
-			if dist > 12.0 {
+			band := EngagementRangeFor(b.Player.ActiveWeapon())
+			if dist > band.Max+disengageMargin {
 				b.State = StateChase
 			} else {
-				// Aim and Fire
+				b.chooseWeapon(dist, &input)
+				b.shoot(&input)
+
+				// Hold the preferred distance: back off when too close, close in when too far
 				dx := b.Target.Position.X - b.Player.Position.X
-				dy := b.Target.Position.Y - b.Player.Position.Y
-				input.AimAngle = float32(math.Atan2(float64(dy), float64(dx)) * 180 / math.Pi)
-				
-				// Simulate reaction time and accuracy
-				if rand.Float32() < 0.8 * b.Difficulty {
-					input.Firing = true
+				switch {
+				case dist < band.Min:
+					input.Horizontal = -float32(math.Copysign(1, float64(dx)))
+				case dist > band.Max:
+					input.Horizontal = float32(math.Copysign(1, float64(dx)))
 				}
-				
+
 				// Small hops if target is higher
+				dy := b.Target.Position.Y - b.Player.Position.Y
 				if dy > 2.0 && b.Player.JetpackFuel > 20 {
 					input.Vertical = 1.0
 					input.IsFlying = true
@@ -125,6 +178,85 @@ func (b *BotController) Update(deltaTime float32, players map[int]*Player) Playe
 	return input
 }
 
+// shoot aims at the target, leading it for projectile weapons, with an aim
+// error that shrinks the longer the target is tracked, and fires once the
+// reaction time has passed
+func (b *BotController) shoot(input *PlayerInput) {
+	from, aimAt := b.Player.Position, b.Target.Position
+	tracking := b.clock - b.spottedAt
+
+	if spec, ok := b.weapons()[b.Player.ActiveWeapon()]; ok && !spec.IsHitscan && spec.ProjectileSpeed > 0 {
+		flight := float32(from.Distance(aimAt)) / spec.ProjectileSpeed
+		aimAt.X += b.Target.Velocity.X * flight * b.Profile.Lead
+		aimAt.Y += b.Target.Velocity.Y * flight * b.Profile.Lead
+	}
+	angle := math.Atan2(float64(aimAt.Y-from.Y), float64(aimAt.X-from.X)) * 180 / math.Pi
+	if err := b.Profile.AimError(tracking); err > 0 {
+		angle += (rand.Float64()*2 - 1) * float64(err)
+	}
+	input.AimAngle = float32(angle)
+
+	if tracking >= b.Profile.ReactionSec && rand.Float32() < b.Profile.FireChance {
+		input.Firing = true
+	}
+}
+
+// chooseWeapon switches to the other slot when its weapon suits the range better
+func (b *BotController) chooseWeapon(dist float64, input *PlayerInput) {
+	other := b.Player.SlotWeapon(b.Player.OtherSlot())
+	if !b.Profile.SwitchWeapons || other == 0 || b.clock < b.nextSwitchAt {
+		return
+	}
+	if EngagementRangeFor(other).miss(dist) < EngagementRangeFor(b.Player.ActiveWeapon()).miss(dist) {
+		input.Switch = true
+		b.nextSwitchAt = b.clock + botSwitchCooldown
+	}
+}
+
+// wantedPickup is a nearby pickup worth a detour: a weapon for an empty
+// secondary slot, or health when hurt
+func (b *BotController) wantedPickup(pickups []*Pickup) *Pickup {
+	if !b.Profile.SeekPickups {
+		return nil
+	}
+	p := b.Player
+	pk := nearestPickup(pickups, p.Position, func(pk *Pickup) bool {
+		switch pk.Type {
+		case PickupWeapon:
+			_, held := p.WeaponSlot(pk.WeaponID)
+			return p.SecondaryWeapon == 0 && !held && pk.WeaponID != WeaponGrenade && pk.WeaponID != WeaponProximityMine
+		case PickupHealth:
+			return p.Health < p.MaxHealth*3/4
+		}
+		return false
+	})
+	if pk == nil || p.Position.Distance(pk.Position) > pickupSeekRange {
+		return nil
+	}
+	return pk
+}
+
+func nearestPickup(pickups []*Pickup, from Vec2, want func(*Pickup) bool) *Pickup {
+	var best *Pickup
+	bestDist := math.MaxFloat64
+	for _, pk := range pickups {
+		if !pk.IsActive || !want(pk) {
+			continue
+		}
+		if d := from.Distance(pk.Position); d < bestDist {
+			best, bestDist = pk, d
+		}
+	}
+	return best
+}
+
+func (b *BotController) weapons() WeaponTable {
+	if b.Weapons != nil {
+		return b.Weapons
+	}
+	return CurrentWeapons()
+}
+
 func (b *BotController) moveTowards(target Vec2, input *PlayerInput) {
 	dx := target.X - b.Player.Position.X
 	if math.Abs(float64(dx)) > 0.5 {
@@ -141,3 +273,77 @@ func (b *BotController) moveTowards(target Vec2, input *PlayerInput) {
 		input.IsFlying = true
 	}
 }
+
+// patrol walks the map's pickups and hotspots in turn, starting at a random one
+func (b *BotController) patrol(deltaTime float32, input *PlayerInput) {
+	points := b.Nav.PatrolPoints()
+	if len(points) == 0 {
+		return
+	}
+	if b.patrolIdx < 0 || b.patrolIdx >= len(points) {
+		b.patrolIdx = rand.Intn(len(points))
+	}
+	if b.Player.Position.Distance(points[b.patrolIdx]) < patrolReachedDist || b.stuckCount >= stuckGiveUp {
+		b.patrolIdx = (b.patrolIdx + 1) % len(points)
+		b.path, b.stuckCount = nil, 0
+	}
+	b.navigateTo(points[b.patrolIdx], deltaTime, input)
+}
+
+// navigateTo follows a planned path towards goal, re-planning when the goal
+// moves away from the plan or the bot stops making progress
+func (b *BotController) navigateTo(goal Vec2, deltaTime float32, input *PlayerInput) {
+	if b.Nav == nil {
+		b.moveTowards(goal, input)
+		return
+	}
+	pos := b.Player.Position
+	if b.path == nil || goal.Distance(b.pathGoal) > repathDistance {
+		b.plan(goal)
+	}
+	for len(b.path) > 0 && pos.Distance(b.path[0].Pos) < waypointReachedDist {
+		b.path = b.path[1:]
+		b.bestDist, b.stuckFor, b.stuckCount = math.MaxFloat64, 0, 0
+	}
+	if len(b.path) == 0 {
+		// Off the end of the graph: close the last stretch directly
+		b.moveTowards(goal, input)
+		return
+	}
+
+	if d := pos.Distance(b.path[0].Pos); d < b.bestDist-0.25 {
+		b.bestDist, b.stuckFor = d, 0
+	} else if b.stuckFor += deltaTime; b.stuckFor > stuckReplanSec {
+		b.stuckCount++
+		b.plan(goal)
+		if len(b.path) == 0 {
+			b.moveTowards(goal, input)
+			return
+		}
+	}
+	b.steer(b.path[0], input)
+}
+
+// steer heads for a waypoint. Climbs throttle the jetpack by the height
+// still to go so the bot settles on the waypoint instead of overshooting;
+// walk links (including drops) leave the jetpack off.
+func (b *BotController) steer(step NavStep, input *PlayerInput) {
+	dx := step.Pos.X - b.Player.Position.X
+	if math.Abs(float64(dx)) > 0.3 {
+		input.Horizontal = float32(math.Max(-1, math.Min(1, float64(dx))))
+	}
+	dy := step.Pos.Y - b.Player.Position.Y
+	if (step.Kind != NavWalk || dy > 0.5) && b.Player.JetpackFuel > 0 {
+		input.IsFlying = true
+		input.Vertical = float32(math.Max(-1, math.Min(1, float64(dy)/2)))
+	}
+}
+
+func (b *BotController) plan(goal Vec2) {
+	b.path = b.Nav.FindPath(b.Player.Position, goal, b.Player.JetpackFuel)
+	if b.path == nil {
+		b.path = []NavStep{} // unreachable: head straight for it until the goal moves
+	}
+	b.pathGoal = goal
+	b.bestDist, b.stuckFor = math.MaxFloat64, 0
+}
@@ -0,0 +1,110 @@
+package game
+
+import (
+	"math"
+	"testing"
+)
+
+const botTick = float32(1.0 / 30)
+
+func duel(profile BotProfile) (*BotController, *Player, map[int]*Player) {
+	bot := NewPlayer(1, "bot_uid", "Bot_1", "RED")
+	enemy := NewPlayer(2, "u2", "Bob", "BLUE")
+	bot.Position = Vec2{X: 0, Y: 0}
+	enemy.Position = Vec2{X: 8, Y: 0}
+	return NewBotController(bot, profile), enemy, map[int]*Player{1: bot, 2: enemy}
+}
+
+func TestBotWaitsForReactionTime(t *testing.T) {
+	profile := BotProfiles["easy"]
+	profile.FireChance = 1
+	b, _, players := duel(profile)
+
+	firstShot := float32(-1)
+	for i := 1; i <= 60 && firstShot < 0; i++ {
+		if b.Update(botTick, players, nil).Firing {
+			firstShot = float32(i) * botTick
+		}
+	}
+	if firstShot < profile.ReactionSec {
+		t.Fatalf("first shot after %.2fs, reaction time is %.2fs", firstShot, profile.ReactionSec)
+	}
+}
+
+func TestAimErrorShrinksWhileTracking(t *testing.T) {
+	for name, p := range BotProfiles {
+		if p.AimError(0) != p.AimErrorDeg || p.AimError(p.AimSettleSec) != p.MinAimErrorDeg {
+			t.Errorf("%s: error %v at spotting, %v once settled", name, p.AimError(0), p.AimError(p.AimSettleSec))
+		}
+		if mid := p.AimError(p.AimSettleSec / 2); mid >= p.AimErrorDeg || mid <= p.MinAimErrorDeg {
+			t.Errorf("%s: error %v halfway through settling", name, mid)
+		}
+	}
+	if !(BotProfiles["easy"].AimErrorDeg > BotProfiles["normal"].AimErrorDeg &&
+		BotProfiles["normal"].AimErrorDeg > BotProfiles["hard"].AimErrorDeg &&
+		BotProfiles["hard"].AimErrorDeg > BotProfiles["insane"].AimErrorDeg) {
+		t.Fatal("aim error does not fall with difficulty")
+	}
+}
+
+func TestInsaneBotLeadsProjectiles(t *testing.T) {
+	b, enemy, players := duel(BotProfiles["insane"])
+	b.Player.SetSlot(SlotPrimary, WeaponRocketLauncher, 4)
+	enemy.Velocity = Vec2{Y: 9}
+
+	var input PlayerInput
+	for i := 0; i < 30; i++ { // settle the aim
+		input = b.Update(botTick, players, nil)
+	}
+	// Rocket at 18 u/s takes 8/18 s to arrive, by which time the target is 4 units up
+	want := math.Atan2(4, 8) * 180 / math.Pi
+	if math.Abs(float64(input.AimAngle)-want) > 0.5 {
+		t.Fatalf("aim %.1f°, want about %.1f° (led)", input.AimAngle, want)
+	}
+}
+
+func TestBotKeepsPreferredRange(t *testing.T) {
+	b, enemy, players := duel(BotProfiles["hard"])
+	b.Player.SetSlot(SlotPrimary, WeaponSniperRifle, 5)
+	b.State = StateAttack
+
+	input := b.Update(botTick, players, nil)
+	if input.Horizontal >= 0 {
+		t.Fatalf("sniper 8 units from an enemy at +x moved %v, want backing off", input.Horizontal)
+	}
+
+	b.Player.SetSlot(SlotSecondary, WeaponShotgun, 8)
+	enemy.Position = Vec2{X: 3}
+	if input = b.Update(botTick, players, nil); !input.Switch {
+		t.Fatal("did not switch to the shotgun at close range")
+	}
+}
+
+func TestHurtBotRetreatsToHealth(t *testing.T) {
+	b, _, players := duel(BotProfiles["normal"])
+	b.Player.Health = 20
+	health := &Pickup{ID: 4, Type: PickupHealth, Position: Vec2{X: -10}, IsActive: true}
+
+	input := b.Update(botTick, players, []*Pickup{health})
+	if b.State != StateRetreat || input.Horizontal >= 0 {
+		t.Fatalf("state %s, horizontal %v: want retreating towards -x", b.State, input.Horizontal)
+	}
+
+	// Easy bots never retreat
+	b, _, players = duel(BotProfiles["easy"])
+	b.Player.Health = 20
+	b.Update(botTick, players, []*Pickup{health})
+	if b.State == StateRetreat {
+		t.Fatal("easy bot retreated")
+	}
+}
+
+func TestBotSeeksWeaponWhenIdle(t *testing.T) {
+	bot := NewPlayer(1, "bot_uid", "Bot_1", "RED")
+	shotgun := &Pickup{ID: 1, Type: PickupWeapon, WeaponID: WeaponShotgun, Position: Vec2{X: 10}, IsActive: true}
+
+	b := NewBotController(bot, BotProfiles["hard"])
+	if input := b.Update(botTick, map[int]*Player{1: bot}, []*Pickup{shotgun}); input.Horizontal <= 0 {
+		t.Fatalf("horizontal %v, want heading for the shotgun", input.Horizontal)
+	}
+}
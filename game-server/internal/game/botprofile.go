@@ -0,0 +1,107 @@
+// SKYBATTLE — Bot Difficulty Profiles
+// A profile is everything that separates an easy bot from an insane one:
+// how long it takes to react, how well it aims and leads, how far it looks,
+// and whether it plays smart (retreating to heal, grabbing pickups, picking
+// the right gun for the range).
+package game
+
+import "sort"
+
+type BotProfile struct {
+	Name           string
+	ReactionSec    float32 // time from spotting an enemy to the first shot
+	AimErrorDeg    float32 // aim error on a fresh target...
+	MinAimErrorDeg float32 // ...shrinking to this after AimSettleSec of tracking
+	AimSettleSec   float32
+	Lead           float32 // 0-1: how much of a moving target's travel projectiles are led by
+	FireChance     float32 // chance per tick of pulling the trigger once ready
+	DetectionRange float64
+	RetreatHealth  int  // head for a health pickup below this, 0 never retreats
+	SeekPickups    bool // detour for weapons and health when not fighting
+	SwitchWeapons  bool // switch to the slot that suits the range
+}
+
+const DefaultBotDifficulty = "normal"
+
+var BotProfiles = map[string]BotProfile{
+	"easy": {
+		Name: "easy", ReactionSec: 0.8, AimErrorDeg: 20, MinAimErrorDeg: 8, AimSettleSec: 2,
+		Lead: 0, FireChance: 0.3, DetectionRange: 14,
+	},
+	"normal": {
+		Name: "normal", ReactionSec: 0.45, AimErrorDeg: 12, MinAimErrorDeg: 4, AimSettleSec: 1.5,
+		Lead: 0.5, FireChance: 0.5, DetectionRange: 18,
+		RetreatHealth: 30, SeekPickups: true,
+	},
+	"hard": {
+		Name: "hard", ReactionSec: 0.25, AimErrorDeg: 8, MinAimErrorDeg: 2, AimSettleSec: 1,
+		Lead: 0.9, FireChance: 0.7, DetectionRange: 22,
+		RetreatHealth: 40, SeekPickups: true, SwitchWeapons: true,
+	},
+	"insane": {
+		Name: "insane", ReactionSec: 0.1, AimErrorDeg: 4, MinAimErrorDeg: 0, AimSettleSec: 0.5,
+		Lead: 1, FireChance: 0.9, DetectionRange: 28,
+		RetreatHealth: 50, SeekPickups: true, SwitchWeapons: true,
+	},
+}
+
+// BotProfileFor looks a profile up by name; "" is the default
+func BotProfileFor(name string) (BotProfile, bool) {
+	if name == "" {
+		name = DefaultBotDifficulty
+	}
+	p, ok := BotProfiles[name]
+	return p, ok
+}
+
+// BotDifficulties lists the profile names, for validation messages
+func BotDifficulties() []string {
+	names := make([]string, 0, len(BotProfiles))
+	for n := range BotProfiles {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AimError is the error in degrees after tracking a target for trackingSec
+func (p BotProfile) AimError(trackingSec float32) float32 {
+	if p.AimSettleSec <= 0 || trackingSec >= p.AimSettleSec {
+		return p.MinAimErrorDeg
+	}
+	return p.AimErrorDeg - (p.AimErrorDeg-p.MinAimErrorDeg)*trackingSec/p.AimSettleSec
+}
+
+// EngagementRange is the distance band a bot tries to fight in with a weapon
+type EngagementRange struct {
+	Min, Max float64
+}
+
+var engagementRanges = map[WeaponID]EngagementRange{
+	WeaponAssaultRifle:   {4, 12},
+	WeaponSniperRifle:    {12, 25},
+	WeaponShotgun:        {0, 5},
+	WeaponRocketLauncher: {6, 15},
+	WeaponFlamethrower:   {0, 4},
+	WeaponSMG:            {2, 9},
+	WeaponDualPistols:    {2, 10},
+	WeaponLaserGun:       {6, 18},
+}
+
+func EngagementRangeFor(id WeaponID) EngagementRange {
+	if r, ok := engagementRanges[id]; ok {
+		return r
+	}
+	return EngagementRange{4, 10}
+}
+
+// miss is how far outside the band a distance is, 0 when inside
+func (r EngagementRange) miss(dist float64) float64 {
+	switch {
+	case dist < r.Min:
+		return r.Min - dist
+	case dist > r.Max:
+		return dist - r.Max
+	}
+	return 0
+}
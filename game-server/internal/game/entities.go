@@ -22,33 +22,12 @@ const (
 	WeaponLaserGun      WeaponID = 8
 	WeaponProximityMine WeaponID = 9
 	WeaponGrenade       WeaponID = 10
-)
 
-type WeaponSpec struct {
-	ID             WeaponID
-	Name           string
-	DamagePerShot  float32
-	FireRatePerSec float32 // shots per second
-	MaxAmmo        int
-	ReloadTimeSec  float32
-	IsHitscan      bool   // true = raycast, false = projectile
-	ProjectileSpeed float32 // only for non-hitscan
-	BlastRadius    float32 // for explosive weapons
-}
+	// WeaponMelee tags melee kills in events; it has no entry in weapons.json
+	WeaponMelee WeaponID = 11
+)
 
-// Weapon specs from doc 18 — weapon balance sheet
-var Weapons = map[WeaponID]WeaponSpec{
-	WeaponAssaultRifle:  {1, "Assault Rifle", 12, 8, 30, 1.8, true, 0, 0},
-	WeaponSniperRifle:   {2, "Sniper Rifle", 80, 0.5, 5, 2.5, true, 0, 0},
-	WeaponShotgun:       {3, "Shotgun", 6, 1.5, 8, 1.5, true, 0, 0}, // 8 pellets × 6 = 48 total
-	WeaponRocketLauncher:{4, "Rocket Launcher", 120, 0.4, 4, 3.0, false, 18, 3.0},
-	WeaponFlamethrower:  {5, "Flamethrower", 8, 10, 100, 2.0, false, 8, 0},
-	WeaponSMG:           {6, "SMG", 8, 12, 45, 1.5, true, 0, 0},
-	WeaponDualPistols:   {7, "Dual Pistols", 12, 4, 24, 1.0, true, 0, 0},
-	WeaponLaserGun:      {8, "Laser Gun", 25, 3, 20, 2.0, true, 0, 0},
-	WeaponProximityMine: {9, "Proximity Mine", 90, 0, 3, 0, false, 0, 2.5},
-	WeaponGrenade:       {10, "Grenade", 80, 0, 2, 0, false, 12, 3.5},
-}
+// Specs for each weapon are data, see weapons.json / weapons.go
 
 // ── Player ────────────────────────────────────────────────────────────────────
 
@@ -84,9 +63,17 @@ type Player struct {
 	PrimaryAmmo     int     `msgpack:"ammo1"`
 	SecondaryAmmo   int     `msgpack:"ammo2"`
 	Kills           int     `msgpack:"-"`
+	BotKills        int     `msgpack:"-"` // the part of Kills scored on bots
 	Deaths          int     `msgpack:"-"`
 	DamageDealt     int     `msgpack:"-"`
-	LastFireTime    time.Time `msgpack:"-"`
+	ActiveSlot      int     `msgpack:"slot"` // SlotPrimary or SlotSecondary
+	SwitchReadyAt   time.Time `msgpack:"-"`
+	SlotFireTime    [2]time.Time `msgpack:"-"`
+	SlotReloadAt    [2]time.Time `msgpack:"-"` // zero unless the slot's magazine is being refilled
+	Grenades        int     `msgpack:"gren"` // throwables are carried apart from the two gun slots
+	Mines           int     `msgpack:"mines"`
+	MeleeReadyAt    time.Time `msgpack:"-"`
+	ThrowReadyAt    time.Time `msgpack:"-"`
 	LastInputSeq    uint32  `msgpack:"seq"`
 	IsAlive         bool    `msgpack:"alive"`
 	RespawnAt       time.Time `msgpack:"-"`
@@ -94,6 +81,63 @@ type Player struct {
 	SpawnY          float32 `msgpack:"-"`
 }
 
+// PlayerState is what clients see of a player: the fields of Player sent in
+// world state, as a plain value that can be copied and shared
+type PlayerState struct {
+	ID              int      `msgpack:"id"`
+	UserID          string   `msgpack:"uid"`
+	DisplayName     string   `msgpack:"name"`
+	Team            string   `msgpack:"team"`
+	Position        Vec2     `msgpack:"pos"`
+	Velocity        Vec2     `msgpack:"vel"`
+	AimAngleDeg     float32  `msgpack:"aim"`
+	Health          int      `msgpack:"hp"`
+	MaxHealth       int      `msgpack:"mhp"`
+	JetpackFuel     float32  `msgpack:"fuel"`
+	MaxFuel         float32  `msgpack:"mfuel"`
+	IsGrounded      bool     `msgpack:"grnd"`
+	IsFlying        bool     `msgpack:"fly"`
+	PrimaryWeapon   WeaponID `msgpack:"wpn1"`
+	SecondaryWeapon WeaponID `msgpack:"wpn2"`
+	PrimaryAmmo     int      `msgpack:"ammo1"`
+	SecondaryAmmo   int      `msgpack:"ammo2"`
+	ActiveSlot      int      `msgpack:"slot"`
+	Grenades        int      `msgpack:"gren"`
+	Mines           int      `msgpack:"mines"`
+	LastInputSeq    uint32   `msgpack:"seq"`
+	IsAlive         bool     `msgpack:"alive"`
+}
+
+// State copies the player's networked fields
+func (p *Player) State() PlayerState {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return PlayerState{
+		ID:              p.ID,
+		UserID:          p.UserID,
+		DisplayName:     p.DisplayName,
+		Team:            p.Team,
+		Position:        p.Position,
+		Velocity:        p.Velocity,
+		AimAngleDeg:     p.AimAngleDeg,
+		Health:          p.Health,
+		MaxHealth:       p.MaxHealth,
+		JetpackFuel:     p.JetpackFuel,
+		MaxFuel:         p.MaxFuel,
+		IsGrounded:      p.IsGrounded,
+		IsFlying:        p.IsFlying,
+		PrimaryWeapon:   p.PrimaryWeapon,
+		SecondaryWeapon: p.SecondaryWeapon,
+		PrimaryAmmo:     p.PrimaryAmmo,
+		SecondaryAmmo:   p.SecondaryAmmo,
+		ActiveSlot:      p.ActiveSlot,
+		Grenades:        p.Grenades,
+		Mines:           p.Mines,
+		LastInputSeq:    p.LastInputSeq,
+		IsAlive:         p.IsAlive,
+	}
+}
+
 const (
 	MaxHealth   = 100
 	MaxFuel     = 100.0
@@ -116,7 +160,7 @@ func NewPlayer(id int, userID, displayName, team string) *Player {
 		MaxFuel:     MaxFuel,
 		IsAlive:     true,
 		PrimaryWeapon: WeaponAssaultRifle,
-		PrimaryAmmo: Weapons[WeaponAssaultRifle].MaxAmmo,
+		PrimaryAmmo: CurrentWeapons()[WeaponAssaultRifle].MaxAmmo,
 	}
 }
 
@@ -169,7 +213,12 @@ type PlayerInput struct {
 	AimAngle   float32 `msgpack:"aim"`
 	IsFlying   bool    `msgpack:"fly"`
 	Firing     bool    `msgpack:"fire"`
-	WeaponID   uint8   `msgpack:"wpn"`
+	WeaponID   uint8   `msgpack:"wpn"` // weapon the client thinks is active; naming the other slot's weapon switches
+	Switch     bool    `msgpack:"sw"`  // toggle between primary and secondary
+	Interact   bool    `msgpack:"use"` // swap the active weapon for the one on the ground
+	Melee      bool    `msgpack:"mel"`
+	Throw      bool    `msgpack:"thr"`  // throw a grenade
+	PlaceMine  bool    `msgpack:"mine"` // drop a proximity mine
 	Sequence   uint32  `msgpack:"seq"`
 }
 
@@ -192,7 +241,7 @@ type Projectile struct {
 // MatchEvent defines a game event like a kill or pickup
 type MatchEvent struct {
 	Tick       int           `msgpack:"tick"`
-	Type       string        `msgpack:"type"` // "KILL", "PICKUP", "MATCH_END"
+	Type       string        `msgpack:"type"` // "KILL", "PICKUP", "MELEE", "THROW", "MATCH_END"
 	ActorID    int           `msgpack:"actor"`
 	TargetID   int           `msgpack:"target"`
 	WeaponID   WeaponID      `msgpack:"wpn"`
@@ -210,11 +259,14 @@ const (
 )
 
 type Pickup struct {
-	ID          int
-	Type        PickupType
-	WeaponID    WeaponID  // only for WEAPON pickups
-	Position    Vec2
-	HealAmount  int       // for HEALTH pickups
-	IsActive    bool
-	RespawnAt   time.Time
+	ID          int        `msgpack:"id"`
+	Type        PickupType `msgpack:"type"`
+	WeaponID    WeaponID   `msgpack:"wpnId"` // only for WEAPON pickups
+	Position    Vec2       `msgpack:"pos"`
+	HealAmount  int        `msgpack:"-"`     // for HEALTH pickups
+	IsActive    bool       `msgpack:"active"`
+	RespawnAt   time.Time  `msgpack:"-"`
+	Ammo        int        `msgpack:"ammo"`    // dropped weapons keep the ammo they had
+	Dropped     bool       `msgpack:"dropped"` // left behind by a swap; removed once taken or expired
+	ExpiresAt   time.Time  `msgpack:"-"`
 }
@@ -0,0 +1,70 @@
+// SKYBATTLE — Weapon Inventory
+// Two weapon slots per player. Callers must hold the player's lock.
+package game
+
+import "time"
+
+const (
+	SlotPrimary   = 0
+	SlotSecondary = 1
+
+	WeaponSwitchDelaySec = 0.4  // no firing while the new weapon comes up
+	PickupRadius         = 1.0  // units
+	PickupRespawnSec     = 20.0 // map pickups
+	DroppedWeaponLifeSec = 30.0 // weapons dropped on a swap
+
+	// Melee is a short swipe in the aim direction, independent of the held gun
+	MeleeDamage      = 35
+	MeleeRange       = 1.5  // units
+	MeleeArcDeg      = 90.0 // total arc centred on the aim angle
+	MeleeCooldownSec = 0.6
+
+	// Grenades and mines are counted, not slotted; the weapons.json max_ammo
+	// of each is both the spawn count and the carry limit
+	ThrowCooldownSec = 0.8
+	MineLifeSec      = 60.0 // a placed mine stays armed this long
+)
+
+func (p *Player) SlotWeapon(slot int) WeaponID {
+	if slot == SlotSecondary {
+		return p.SecondaryWeapon
+	}
+	return p.PrimaryWeapon
+}
+
+func (p *Player) SlotAmmo(slot int) *int {
+	if slot == SlotSecondary {
+		return &p.SecondaryAmmo
+	}
+	return &p.PrimaryAmmo
+}
+
+func (p *Player) SetSlot(slot int, id WeaponID, ammo int) {
+	if slot == SlotSecondary {
+		p.SecondaryWeapon, p.SecondaryAmmo = id, ammo
+	} else {
+		p.PrimaryWeapon, p.PrimaryAmmo = id, ammo
+	}
+	p.SlotReloadAt[slot] = time.Time{}
+}
+
+func (p *Player) ActiveWeapon() WeaponID {
+	return p.SlotWeapon(p.ActiveSlot)
+}
+
+func (p *Player) OtherSlot() int {
+	return 1 - p.ActiveSlot
+}
+
+// WeaponSlot reports which slot holds id
+func (p *Player) WeaponSlot(id WeaponID) (int, bool) {
+	switch {
+	case id == 0:
+		return 0, false
+	case p.PrimaryWeapon == id:
+		return SlotPrimary, true
+	case p.SecondaryWeapon == id:
+		return SlotSecondary, true
+	}
+	return 0, false
+}
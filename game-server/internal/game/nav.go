@@ -0,0 +1,278 @@
+// SKYBATTLE — Bot Navigation
+// Each map ships a waypoint graph (maps/<map_id>.json): nodes stand on
+// surfaces bots can rest on, links say how to get from one to the next by
+// walking (including dropping off a ledge), a short jump or a jetpack climb.
+// Travel time and fuel for each link are worked out from the geometry, and
+// FindPath runs A* over them without planning a climb the bot can't fuel.
+package game
+
+import (
+	"container/heap"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"path"
+	"strings"
+)
+
+type NavLinkKind string
+
+const (
+	NavWalk    NavLinkKind = "walk"
+	NavJump    NavLinkKind = "jump"
+	NavJetpack NavLinkKind = "jetpack"
+)
+
+// Node tags; pickups and hotspots make up the patrol route
+const (
+	NavTagGround  = "ground"
+	NavTagSpawn   = "spawn"
+	NavTagPickup  = "pickup"
+	NavTagHotspot = "hotspot"
+)
+
+const (
+	navFallAccel   = 20.0 // matches the room's gravity
+	navFuelMargin  = 1.25 // climbs are budgeted with some fuel to spare
+	navFuelWeight  = 0.02 // seconds of cost per unit of fuel, so walking wins a tie
+	navMaxFlySpeed = 20.0 // fastest diagonal flight, keeps the heuristic admissible
+)
+
+type NavNode struct {
+	ID  int    `json:"id"`
+	Pos Vec2   `json:"pos"`
+	Tag string `json:"tag"`
+}
+
+type NavLink struct {
+	From    int         `json:"from"`
+	To      int         `json:"to"`
+	Kind    NavLinkKind `json:"kind"`
+	TimeSec float32     `json:"-"`
+	Fuel    float32     `json:"-"`
+}
+
+// NavStep is one waypoint of a path and how to reach it from the previous one
+type NavStep struct {
+	Pos  Vec2
+	Kind NavLinkKind
+}
+
+type NavGraph struct {
+	MapID string
+	Nodes []NavNode
+	links map[int][]NavLink
+	index map[int]int // node ID -> position in Nodes
+}
+
+//go:embed maps/*.json
+var navMapFiles embed.FS
+
+var navGraphs = mustLoadNavGraphs()
+
+// NavGraphFor returns the graph for a map, nil if the map has none
+func NavGraphFor(mapID string) *NavGraph {
+	return navGraphs[mapID]
+}
+
+func ParseNavGraph(data []byte) (*NavGraph, error) {
+	var file struct {
+		MapID string    `json:"map_id"`
+		Nodes []NavNode `json:"nodes"`
+		Links []NavLink `json:"links"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	if len(file.Nodes) == 0 {
+		return nil, fmt.Errorf("no nodes")
+	}
+
+	g := &NavGraph{
+		MapID: file.MapID,
+		Nodes: file.Nodes,
+		links: make(map[int][]NavLink),
+		index: make(map[int]int, len(file.Nodes)),
+	}
+	for i, n := range file.Nodes {
+		if _, dup := g.index[n.ID]; dup {
+			return nil, fmt.Errorf("node %d listed twice", n.ID)
+		}
+		g.index[n.ID] = i
+	}
+	for _, l := range file.Links {
+		from, ok1 := g.node(l.From)
+		to, ok2 := g.node(l.To)
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("link %d->%d: unknown node", l.From, l.To)
+		}
+		switch l.Kind {
+		case NavWalk, NavJump, NavJetpack:
+		default:
+			return nil, fmt.Errorf("link %d->%d: unknown kind %q", l.From, l.To, l.Kind)
+		}
+		l.TimeSec, l.Fuel = linkCost(from.Pos, to.Pos, l.Kind)
+		g.links[l.From] = append(g.links[l.From], l)
+	}
+	return g, nil
+}
+
+// linkCost estimates travel time and jetpack fuel for a link
+func linkCost(from, to Vec2, kind NavLinkKind) (timeSec, fuel float32) {
+	dx := math.Abs(float64(to.X - from.X))
+	dy := float64(to.Y - from.Y)
+	run := dx / MaxSpeedX
+	switch {
+	case kind == NavWalk && dy < 0:
+		return float32(math.Max(run, math.Sqrt(-2*dy/navFallAccel))), 0
+	case kind == NavWalk:
+		return float32(run), 0
+	}
+	t := math.Max(run, math.Max(dy, 0)/MaxSpeedY)
+	return float32(t), float32(t * FuelDrainPerSec * navFuelMargin)
+}
+
+func (g *NavGraph) node(id int) (NavNode, bool) {
+	i, ok := g.index[id]
+	if !ok {
+		return NavNode{}, false
+	}
+	return g.Nodes[i], true
+}
+
+// Links returns the links leaving a node
+func (g *NavGraph) Links(id int) []NavLink {
+	return g.links[id]
+}
+
+// Nearest returns the node closest to pos
+func (g *NavGraph) Nearest(pos Vec2) NavNode {
+	best, bestDist := g.Nodes[0], math.MaxFloat64
+	for _, n := range g.Nodes {
+		if d := pos.Distance(n.Pos); d < bestDist {
+			best, bestDist = n, d
+		}
+	}
+	return best
+}
+
+// PatrolPoints lists the pickup and hotspot nodes in map order
+func (g *NavGraph) PatrolPoints() []Vec2 {
+	var pts []Vec2
+	for _, n := range g.Nodes {
+		if n.Tag == NavTagPickup || n.Tag == NavTagHotspot {
+			pts = append(pts, n.Pos)
+		}
+	}
+	return pts
+}
+
+// FindPath plans from the node nearest from to the node nearest to. fuel
+// is what the bot has now; standing on any node recharges it, at the cost
+// of the wait, so a climb is only planned once there is fuel for it. The
+// path excludes the start node; nil means the goal is unreachable.
+func (g *NavGraph) FindPath(from, to Vec2, fuel float32) []NavStep {
+	start, goal := g.Nearest(from), g.Nearest(to)
+	if start.ID == goal.ID {
+		return []NavStep{{Pos: goal.Pos, Kind: NavWalk}}
+	}
+
+	type label struct {
+		cost float64
+		fuel float32
+		prev int
+		via  NavLinkKind
+	}
+	best := map[int]label{start.ID: {fuel: fuel, prev: -1}}
+	closed := map[int]bool{}
+	open := &navQueue{{id: start.ID, priority: g.heuristic(start.Pos, goal.Pos)}}
+
+	for open.Len() > 0 {
+		cur := heap.Pop(open).(navItem)
+		if closed[cur.id] {
+			continue
+		}
+		if cur.id == goal.ID {
+			break
+		}
+		closed[cur.id] = true
+		here := best[cur.id]
+
+		for _, l := range g.links[cur.id] {
+			if closed[l.To] || l.Fuel > MaxFuel {
+				continue
+			}
+			cost, left := here.cost+float64(l.TimeSec)+float64(l.Fuel)*navFuelWeight, here.fuel
+			if l.Fuel > left {
+				cost += float64(l.Fuel-left) / FuelRechargePerSec
+				left = l.Fuel
+			}
+			left -= l.Fuel
+			if prev, seen := best[l.To]; seen && prev.cost <= cost {
+				continue
+			}
+			best[l.To] = label{cost: cost, fuel: left, prev: cur.id, via: l.Kind}
+			to, _ := g.node(l.To)
+			heap.Push(open, navItem{id: l.To, priority: cost + g.heuristic(to.Pos, goal.Pos)})
+		}
+	}
+
+	if _, reached := best[goal.ID]; !reached {
+		return nil
+	}
+	var steps []NavStep
+	for id := goal.ID; id != start.ID; id = best[id].prev {
+		n, _ := g.node(id)
+		steps = append(steps, NavStep{Pos: n.Pos, Kind: best[id].via})
+	}
+	for i, j := 0, len(steps)-1; i < j; i, j = i+1, j-1 {
+		steps[i], steps[j] = steps[j], steps[i]
+	}
+	return steps
+}
+
+func (g *NavGraph) heuristic(a, b Vec2) float64 {
+	return a.Distance(b) / navMaxFlySpeed
+}
+
+type navItem struct {
+	id       int
+	priority float64
+}
+
+type navQueue []navItem
+
+func (q navQueue) Len() int            { return len(q) }
+func (q navQueue) Less(i, j int) bool  { return q[i].priority < q[j].priority }
+func (q navQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *navQueue) Push(x interface{}) { *q = append(*q, x.(navItem)) }
+func (q *navQueue) Pop() interface{} {
+	old := *q
+	it := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return it
+}
+
+func mustLoadNavGraphs() map[string]*NavGraph {
+	entries, err := navMapFiles.ReadDir("maps")
+	if err != nil {
+		panic(err)
+	}
+	graphs := make(map[string]*NavGraph, len(entries))
+	for _, e := range entries {
+		data, err := navMapFiles.ReadFile(path.Join("maps", e.Name()))
+		if err != nil {
+			panic(err)
+		}
+		g, err := ParseNavGraph(data)
+		if err != nil {
+			panic("shipped nav graph " + e.Name() + ": " + err.Error())
+		}
+		if want := strings.TrimSuffix(e.Name(), ".json"); g.MapID != want {
+			panic(fmt.Sprintf("shipped nav graph %s: map_id %q", e.Name(), g.MapID))
+		}
+		graphs[g.MapID] = g
+	}
+	return graphs
+}
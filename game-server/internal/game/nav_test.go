@@ -0,0 +1,97 @@
+package game
+
+import "testing"
+
+func TestShippedNavGraphsAreConnected(t *testing.T) {
+	for _, mapID := range []string{"outpost", "catacombs"} {
+		g := NavGraphFor(mapID)
+		if g == nil {
+			t.Fatalf("%s: no nav graph", mapID)
+		}
+		for _, from := range g.Nodes {
+			for _, to := range g.Nodes {
+				if from.ID != to.ID && g.FindPath(from.Pos, to.Pos, MaxFuel) == nil {
+					t.Errorf("%s: no path from node %d to node %d", mapID, from.ID, to.ID)
+				}
+			}
+		}
+		if len(g.PatrolPoints()) == 0 {
+			t.Errorf("%s: no patrol points", mapID)
+		}
+	}
+}
+
+func TestFindPathClimbsWithJetpack(t *testing.T) {
+	g := NavGraphFor("outpost")
+	path := g.FindPath(Vec2{X: 2, Y: 0}, Vec2{X: 8, Y: 16}, MaxFuel)
+	if len(path) == 0 || path[len(path)-1].Pos != (Vec2{X: 8, Y: 16}) {
+		t.Fatalf("path = %+v", path)
+	}
+	climbed := false
+	for _, s := range path {
+		climbed = climbed || s.Kind == NavJetpack
+	}
+	if !climbed {
+		t.Fatalf("reached a platform 16 units up without a jetpack link: %+v", path)
+	}
+}
+
+func TestFindPathAvoidsClimbsBeyondFuel(t *testing.T) {
+	// 1 -> 3 directly is a climb no tank can make; the long way walks round
+	g, err := ParseNavGraph([]byte(`{
+		"map_id": "test",
+		"nodes": [
+			{"id": 1, "pos": {"x": 0, "y": 0}, "tag": "ground"},
+			{"id": 2, "pos": {"x": 30, "y": 0}, "tag": "ground"},
+			{"id": 3, "pos": {"x": 0, "y": 150}, "tag": "pickup"}
+		],
+		"links": [
+			{"from": 1, "to": 3, "kind": "jetpack"},
+			{"from": 1, "to": 2, "kind": "walk"},
+			{"from": 2, "to": 3, "kind": "walk"}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := g.FindPath(Vec2{X: 0, Y: 0}, Vec2{X: 0, Y: 150}, MaxFuel)
+	if len(path) != 2 || path[0].Pos != (Vec2{X: 30, Y: 0}) {
+		t.Fatalf("path = %+v, want the walk round", path)
+	}
+}
+
+func TestFindPathWaitsForFuelOnlyWhenWorthIt(t *testing.T) {
+	// A short climb beats a long walk even if the bot has to recharge first
+	g, err := ParseNavGraph([]byte(`{
+		"map_id": "test",
+		"nodes": [
+			{"id": 1, "pos": {"x": 0, "y": 0}, "tag": "ground"},
+			{"id": 2, "pos": {"x": 60, "y": 0}, "tag": "ground"},
+			{"id": 3, "pos": {"x": 0, "y": 6}, "tag": "pickup"}
+		],
+		"links": [
+			{"from": 1, "to": 3, "kind": "jetpack"},
+			{"from": 1, "to": 2, "kind": "walk"},
+			{"from": 2, "to": 3, "kind": "walk"}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path := g.FindPath(Vec2{}, Vec2{X: 0, Y: 6}, 0); len(path) != 1 || path[0].Kind != NavJetpack {
+		t.Fatalf("path = %+v, want the climb", path)
+	}
+}
+
+func TestParseNavGraphRejectsBadLinks(t *testing.T) {
+	for name, data := range map[string]string{
+		"unknown node": `{"nodes": [{"id": 1}], "links": [{"from": 1, "to": 2, "kind": "walk"}]}`,
+		"unknown kind": `{"nodes": [{"id": 1}, {"id": 2}], "links": [{"from": 1, "to": 2, "kind": "teleport"}]}`,
+		"duplicate":    `{"nodes": [{"id": 1}, {"id": 1}]}`,
+		"empty":        `{"nodes": []}`,
+	} {
+		if _, err := ParseNavGraph([]byte(data)); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
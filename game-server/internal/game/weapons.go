@@ -0,0 +1,152 @@
+// SKYBATTLE — Weapon Balance Data
+// Specs come from weapons.json (doc 18 — weapon balance sheet), embedded as
+// the shipped defaults. A replacement file can be loaded at runtime; rooms
+// take a snapshot of the current table when created, so a reload only
+// affects matches that start afterwards.
+package game
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync/atomic"
+)
+
+type WeaponSpec struct {
+	ID              WeaponID `json:"id"`
+	Name            string   `json:"name"`
+	DamagePerShot   float32  `json:"damage_per_shot"`
+	FireRatePerSec  float32  `json:"fire_rate_per_sec"` // shots per second
+	MaxAmmo         int      `json:"max_ammo"`
+	ReloadTimeSec   float32  `json:"reload_time_sec"`
+	IsHitscan       bool     `json:"is_hitscan"`       // true = raycast, false = projectile
+	ProjectileSpeed float32  `json:"projectile_speed"` // only for non-hitscan
+	BlastRadius     float32  `json:"blast_radius"`     // for explosive weapons
+
+	Range             float32 `json:"range"`              // max reach in units, 0 = unlimited (projectiles live DefaultProjectileLifeSec)
+	SpreadDeg         float32 `json:"spread_deg"`         // total cone the pellets are spread across
+	Pellets           int     `json:"pellets"`            // projectiles/rays per shot
+	FalloffStart      float32 `json:"falloff_start"`      // distance where damage starts dropping, 0 = no falloff
+	FalloffMinScale   float32 `json:"falloff_min_scale"`  // damage multiplier reached at Range
+	ProjectileGravity float32 `json:"projectile_gravity"` // units/sec^2 pulling projectiles down
+	FuseTimeSec       float32 `json:"fuse_time_sec"`      // > 0: detonates after the fuse instead of on contact
+	DualWield         bool    `json:"dual_wield"`         // fires together with the other slot when both allow it
+}
+
+const DefaultProjectileLifeSec = 5.0
+
+// WeaponTable is never modified once published; reloads swap in a new one
+type WeaponTable map[WeaponID]WeaponSpec
+
+//go:embed weapons.json
+var shippedWeapons []byte
+
+// Weapons is the shipped balance sheet
+var Weapons = mustParseWeapons(shippedWeapons)
+
+var currentWeapons atomic.Pointer[WeaponTable]
+
+func init() {
+	SetWeapons(Weapons)
+}
+
+// CurrentWeapons returns the table new rooms should use
+func CurrentWeapons() WeaponTable {
+	return *currentWeapons.Load()
+}
+
+// SetWeapons publishes a table for rooms created from now on
+func SetWeapons(t WeaponTable) {
+	currentWeapons.Store(&t)
+}
+
+// LoadWeapons reads and validates a balance file
+func LoadWeapons(path string) (WeaponTable, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	t, err := ParseWeapons(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return t, nil
+}
+
+func ParseWeapons(data []byte) (WeaponTable, error) {
+	var specs []WeaponSpec
+	if err := json.Unmarshal(data, &specs); err != nil {
+		return nil, err
+	}
+	t := make(WeaponTable, len(specs))
+	for _, s := range specs {
+		if err := s.validate(); err != nil {
+			return nil, fmt.Errorf("weapon %d (%s): %w", s.ID, s.Name, err)
+		}
+		if _, dup := t[s.ID]; dup {
+			return nil, fmt.Errorf("weapon %d listed twice", s.ID)
+		}
+		t[s.ID] = s
+	}
+	// The client knows weapons by ID, so a file may retune but not drop any
+	for id := WeaponAssaultRifle; id <= WeaponGrenade; id++ {
+		if _, ok := t[id]; !ok {
+			return nil, fmt.Errorf("weapon %d missing", id)
+		}
+	}
+	return t, nil
+}
+
+func (s WeaponSpec) validate() error {
+	switch {
+	case s.ID < WeaponAssaultRifle || s.ID > WeaponGrenade:
+		return fmt.Errorf("unknown weapon id")
+	case s.Name == "":
+		return fmt.Errorf("name is required")
+	case s.DamagePerShot < 0, s.FireRatePerSec < 0, s.MaxAmmo < 0, s.ReloadTimeSec < 0, s.BlastRadius < 0:
+		return fmt.Errorf("damage, fire rate, ammo, reload time and blast radius must not be negative")
+	case !s.IsHitscan && s.ProjectileSpeed < 0:
+		return fmt.Errorf("projectile_speed must not be negative")
+	case s.Range < 0, s.SpreadDeg < 0 || s.SpreadDeg > 180:
+		return fmt.Errorf("range must not be negative and spread_deg must be 0-180")
+	case s.Pellets < 1:
+		return fmt.Errorf("pellets must be at least 1")
+	case s.FalloffStart < 0, s.FalloffMinScale < 0 || s.FalloffMinScale > 1:
+		return fmt.Errorf("falloff_start must not be negative and falloff_min_scale must be 0-1")
+	case s.FalloffStart > 0 && (s.Range == 0 || s.FalloffStart >= s.Range):
+		return fmt.Errorf("falloff_start needs a range beyond it")
+	case s.FuseTimeSec < 0:
+		return fmt.Errorf("fuse_time_sec must not be negative")
+	}
+	return nil
+}
+
+// ProjectileLifeSec is how long a projectile flies before it expires
+func (s WeaponSpec) ProjectileLifeSec() float32 {
+	if s.Range > 0 && s.ProjectileSpeed > 0 {
+		return s.Range / s.ProjectileSpeed
+	}
+	return DefaultProjectileLifeSec
+}
+
+// DamageAt applies range falloff: full damage up to FalloffStart, then
+// linearly down to FalloffMinScale at Range.
+func (s WeaponSpec) DamageAt(distance float32) float32 {
+	if s.FalloffStart <= 0 || distance <= s.FalloffStart {
+		return s.DamagePerShot
+	}
+	t := (distance - s.FalloffStart) / (s.Range - s.FalloffStart)
+	if t > 1 {
+		t = 1
+	}
+	return s.DamagePerShot * (1 - t*(1-s.FalloffMinScale))
+}
+
+func mustParseWeapons(data []byte) WeaponTable {
+	t, err := ParseWeapons(data)
+	if err != nil {
+		panic("shipped weapons.json: " + err.Error())
+	}
+	return t
+}
@@ -0,0 +1,65 @@
+package game
+
+import "testing"
+
+// The balance sheet as it was hard-coded before it moved to weapons.json
+var legacyWeapons = map[WeaponID]WeaponSpec{
+	WeaponAssaultRifle:   {ID: 1, Name: "Assault Rifle", DamagePerShot: 12, FireRatePerSec: 8, MaxAmmo: 30, ReloadTimeSec: 1.8, IsHitscan: true},
+	WeaponSniperRifle:    {ID: 2, Name: "Sniper Rifle", DamagePerShot: 80, FireRatePerSec: 0.5, MaxAmmo: 5, ReloadTimeSec: 2.5, IsHitscan: true},
+	WeaponShotgun:        {ID: 3, Name: "Shotgun", DamagePerShot: 6, FireRatePerSec: 1.5, MaxAmmo: 8, ReloadTimeSec: 1.5, IsHitscan: true},
+	WeaponRocketLauncher: {ID: 4, Name: "Rocket Launcher", DamagePerShot: 120, FireRatePerSec: 0.4, MaxAmmo: 4, ReloadTimeSec: 3.0, ProjectileSpeed: 18, BlastRadius: 3.0},
+	WeaponFlamethrower:   {ID: 5, Name: "Flamethrower", DamagePerShot: 8, FireRatePerSec: 10, MaxAmmo: 100, ReloadTimeSec: 2.0, ProjectileSpeed: 8},
+	WeaponSMG:            {ID: 6, Name: "SMG", DamagePerShot: 8, FireRatePerSec: 12, MaxAmmo: 45, ReloadTimeSec: 1.5, IsHitscan: true},
+	WeaponDualPistols:    {ID: 7, Name: "Dual Pistols", DamagePerShot: 12, FireRatePerSec: 4, MaxAmmo: 24, ReloadTimeSec: 1.0, IsHitscan: true},
+	WeaponLaserGun:       {ID: 8, Name: "Laser Gun", DamagePerShot: 25, FireRatePerSec: 3, MaxAmmo: 20, ReloadTimeSec: 2.0, IsHitscan: true},
+	WeaponProximityMine:  {ID: 9, Name: "Proximity Mine", DamagePerShot: 90, MaxAmmo: 3, BlastRadius: 2.5},
+	WeaponGrenade:        {ID: 10, Name: "Grenade", DamagePerShot: 80, MaxAmmo: 2, ProjectileSpeed: 12, BlastRadius: 3.5},
+}
+
+func TestShippedFileMatchesLegacyValues(t *testing.T) {
+	if len(Weapons) != len(legacyWeapons) {
+		t.Fatalf("shipped file has %d weapons, want %d", len(Weapons), len(legacyWeapons))
+	}
+	for id, want := range legacyWeapons {
+		got := Weapons[id]
+		old := got
+		old.Range, old.SpreadDeg, old.Pellets = 0, 0, 0
+		old.FalloffStart, old.FalloffMinScale, old.ProjectileGravity, old.FuseTimeSec = 0, 0, 0, 0
+		old.DualWield = false
+		if old != want {
+			t.Errorf("weapon %d:\n got %+v\nwant %+v", id, old, want)
+		}
+
+		// New fields must leave behaviour as it was: projectiles live 5s,
+		// no falloff, gravity or fuse, one projectile per shot
+		if !got.IsHitscan && (got.ProjectileLifeSec() != DefaultProjectileLifeSec || got.Pellets != 1) {
+			t.Errorf("weapon %d: lifetime %vs, %d pellets", id, got.ProjectileLifeSec(), got.Pellets)
+		}
+		if got.DamageAt(1000) != got.DamagePerShot || got.ProjectileGravity != 0 || got.FuseTimeSec != 0 {
+			t.Errorf("weapon %d: new fields change behaviour: %+v", id, got)
+		}
+	}
+}
+
+func TestParseWeaponsValidation(t *testing.T) {
+	for name, body := range map[string]string{
+		"negative damage": `[{"id":1,"name":"AR","damage_per_shot":-1,"pellets":1}]`,
+		"unknown id":      `[{"id":42,"name":"Railgun","pellets":1}]`,
+		"no pellets":      `[{"id":1,"name":"AR","pellets":0}]`,
+		"falloff":         `[{"id":1,"name":"AR","pellets":1,"falloff_start":10}]`,
+		"missing weapons": `[{"id":1,"name":"AR","pellets":1}]`,
+	} {
+		if _, err := ParseWeapons([]byte(body)); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
+
+func TestDamageFalloff(t *testing.T) {
+	s := WeaponSpec{DamagePerShot: 100, Range: 30, FalloffStart: 10, FalloffMinScale: 0.5}
+	for dist, want := range map[float32]float32{5: 100, 10: 100, 20: 75, 30: 50, 60: 50} {
+		if got := s.DamageAt(dist); got != want {
+			t.Errorf("DamageAt(%v) = %v, want %v", dist, got, want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// SKYBATTLE — Agones SDK over HTTP
+// Speaks the REST gateway of the Agones SDK sidecar (and of the local
+// `sdk-server --local` binary), which listens on AGONES_SDK_HTTP_PORT.
+package lifecycle
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const DefaultAgonesHTTPPort = 9358
+
+type AgonesHTTP struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewAgonesHTTP(port int) *AgonesHTTP {
+	if port == 0 {
+		port = DefaultAgonesHTTPPort
+	}
+	return &AgonesHTTP{
+		baseURL: fmt.Sprintf("http://localhost:%d", port),
+		client:  &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+type keyValue struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+func (a *AgonesHTTP) Ready(ctx context.Context) error {
+	return a.call(ctx, http.MethodPost, "/ready", struct{}{})
+}
+func (a *AgonesHTTP) Allocate(ctx context.Context) error {
+	return a.call(ctx, http.MethodPost, "/allocate", struct{}{})
+}
+func (a *AgonesHTTP) Health(ctx context.Context) error {
+	return a.call(ctx, http.MethodPost, "/health", struct{}{})
+}
+func (a *AgonesHTTP) Shutdown(ctx context.Context) error {
+	return a.call(ctx, http.MethodPost, "/shutdown", struct{}{})
+}
+
+func (a *AgonesHTTP) SetLabel(ctx context.Context, key, value string) error {
+	return a.call(ctx, http.MethodPut, "/metadata/label", keyValue{Key: key, Value: value})
+}
+
+func (a *AgonesHTTP) SetAnnotation(ctx context.Context, key, value string) error {
+	return a.call(ctx, http.MethodPut, "/metadata/annotation", keyValue{Key: key, Value: value})
+}
+
+func (a *AgonesHTTP) call(ctx context.Context, method, path string, body interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, a.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("agones %s: %w", path, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("agones %s: status %d", path, resp.StatusCode)
+	}
+	return nil
+}
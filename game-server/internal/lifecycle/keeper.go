@@ -0,0 +1,104 @@
+// SKYBATTLE — Lifecycle Keeper
+// Background loop that pings health and publishes current load as labels so
+// fleet autoscaling can see it.
+package lifecycle
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strconv"
+	"time"
+)
+
+// Load is what the keeper publishes about this server
+type Load struct {
+	Rooms         int `json:"rooms"`
+	ActiveMatches int `json:"active_matches"`
+	Players       int `json:"players"`
+	Bots          int `json:"bots"`
+	MaxRooms      int `json:"max_rooms"`
+}
+
+const (
+	LabelPlayers       = "players"
+	LabelRooms         = "rooms"
+	LabelActiveMatches = "active-matches"
+	AnnotationLoad     = "skybattle-load"
+)
+
+type Keeper struct {
+	sdk      SDK
+	load     func() Load
+	interval time.Duration
+
+	selfAllocate bool
+	allocated    bool
+	last         Load
+	published    bool
+}
+
+// NewKeeper reports load from the load func every interval (Agones expects a
+// health ping well within its default 5s period). Under Agones only the
+// allocator moves a server to Allocated; the in-memory SDK has no allocator,
+// so there the keeper marks the server Allocated once a match starts.
+func NewKeeper(sdk SDK, load func() Load, interval time.Duration) *Keeper {
+	_, local := sdk.(*Local)
+	return &Keeper{sdk: sdk, load: load, interval: interval, selfAllocate: local}
+}
+
+// Run marks the server Ready, then pings health and syncs labels until ctx is done.
+func (k *Keeper) Run(ctx context.Context) {
+	if err := k.sdk.Ready(ctx); err != nil {
+		log.Printf("Lifecycle: Ready failed: %v", err)
+	}
+
+	ticker := time.NewTicker(k.interval)
+	defer ticker.Stop()
+	for {
+		k.Sync(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sync performs one health ping and publishes load if it changed.
+func (k *Keeper) Sync(ctx context.Context) {
+	if err := k.sdk.Health(ctx); err != nil {
+		log.Printf("Lifecycle: health ping failed: %v", err)
+	}
+
+	load := k.load()
+	if k.selfAllocate && load.ActiveMatches > 0 && !k.allocated {
+		if err := k.sdk.Allocate(ctx); err != nil {
+			log.Printf("Lifecycle: Allocate failed: %v", err)
+		} else {
+			k.allocated = true
+			log.Printf("Lifecycle: allocated (%d active matches)", load.ActiveMatches)
+		}
+	}
+
+	if k.published && load == k.last {
+		return
+	}
+	labels := map[string]int{
+		LabelPlayers:       load.Players,
+		LabelRooms:         load.Rooms,
+		LabelActiveMatches: load.ActiveMatches,
+	}
+	for key, v := range labels {
+		if err := k.sdk.SetLabel(ctx, key, strconv.Itoa(v)); err != nil {
+			log.Printf("Lifecycle: label %s: %v", key, err)
+			return
+		}
+	}
+	detail, _ := json.Marshal(load)
+	if err := k.sdk.SetAnnotation(ctx, AnnotationLoad, string(detail)); err != nil {
+		log.Printf("Lifecycle: annotation: %v", err)
+		return
+	}
+	k.last, k.published = load, true
+}
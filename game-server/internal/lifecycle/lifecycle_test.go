@@ -0,0 +1,176 @@
+package lifecycle
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+type sdkCall struct {
+	Method string
+	Path   string
+	Body   map[string]string
+}
+
+// fakeAgones records requests the way the Agones local sdk-server would accept them
+func fakeAgones(t *testing.T) (*AgonesHTTP, func() []sdkCall) {
+	t.Helper()
+	var mu sync.Mutex
+	var calls []sdkCall
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			http.Error(w, "bad content type", http.StatusBadRequest)
+			return
+		}
+		raw, _ := io.ReadAll(r.Body)
+		body := map[string]string{}
+		if err := json.Unmarshal(raw, &body); err != nil {
+			http.Error(w, "bad body", http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		calls = append(calls, sdkCall{Method: r.Method, Path: r.URL.Path, Body: body})
+		mu.Unlock()
+		w.Write([]byte("{}"))
+	}))
+	t.Cleanup(srv.Close)
+
+	_, portStr, _ := net.SplitHostPort(srv.Listener.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+	return NewAgonesHTTP(port), func() []sdkCall {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]sdkCall(nil), calls...)
+	}
+}
+
+func TestAgonesHTTPEndpoints(t *testing.T) {
+	sdk, calls := fakeAgones(t)
+	ctx := context.Background()
+
+	steps := []func() error{
+		func() error { return sdk.Ready(ctx) },
+		func() error { return sdk.Health(ctx) },
+		func() error { return sdk.Allocate(ctx) },
+		func() error { return sdk.SetLabel(ctx, "players", "4") },
+		func() error { return sdk.SetAnnotation(ctx, "skybattle-load", `{"rooms":1}`) },
+		func() error { return sdk.Shutdown(ctx) },
+	}
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+	}
+
+	want := []sdkCall{
+		{"POST", "/ready", nil},
+		{"POST", "/health", nil},
+		{"POST", "/allocate", nil},
+		{"PUT", "/metadata/label", map[string]string{"key": "players", "value": "4"}},
+		{"PUT", "/metadata/annotation", map[string]string{"key": "skybattle-load", "value": `{"rooms":1}`}},
+		{"POST", "/shutdown", nil},
+	}
+	got := calls()
+	if len(got) != len(want) {
+		t.Fatalf("got %d calls, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i].Method != want[i].Method || got[i].Path != want[i].Path {
+			t.Errorf("call %d = %s %s, want %s %s", i, got[i].Method, got[i].Path, want[i].Method, want[i].Path)
+		}
+		for k, v := range want[i].Body {
+			if got[i].Body[k] != v {
+				t.Errorf("call %d body[%s] = %q, want %q", i, k, got[i].Body[k], v)
+			}
+		}
+	}
+}
+
+func TestAgonesHTTPErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	_, portStr, _ := net.SplitHostPort(srv.Listener.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+	if err := NewAgonesHTTP(port).Ready(context.Background()); err == nil {
+		t.Fatal("expected error for 500 response")
+	}
+}
+
+func TestKeeperAllocatesAndPublishesLoad(t *testing.T) {
+	sdk := NewLocal()
+	load := Load{Rooms: 1}
+	k := NewKeeper(sdk, func() Load { return load }, time.Hour)
+	ctx := context.Background()
+
+	k.Sync(ctx)
+	if sdk.State() != StateScheduled {
+		t.Fatalf("state = %s before any match, want Scheduled", sdk.State())
+	}
+	if sdk.Label(LabelRooms) != "1" || sdk.Label(LabelPlayers) != "0" {
+		t.Fatalf("labels not published: rooms=%q players=%q", sdk.Label(LabelRooms), sdk.Label(LabelPlayers))
+	}
+
+	load = Load{Rooms: 1, ActiveMatches: 1, Players: 3, Bots: 2}
+	k.Sync(ctx)
+	if sdk.State() != StateAllocated {
+		t.Fatalf("state = %s with an active match, want Allocated", sdk.State())
+	}
+	if sdk.Label(LabelPlayers) != "3" || sdk.Label(LabelActiveMatches) != "1" {
+		t.Fatalf("labels not updated: players=%q matches=%q", sdk.Label(LabelPlayers), sdk.Label(LabelActiveMatches))
+	}
+	var published Load
+	if err := json.Unmarshal([]byte(sdk.Annotation(AnnotationLoad)), &published); err != nil || published != load {
+		t.Fatalf("annotation = %q (%v), want %+v", sdk.Annotation(AnnotationLoad), err, load)
+	}
+	if sdk.HealthPings() != 2 {
+		t.Fatalf("health pings = %d, want 2", sdk.HealthPings())
+	}
+}
+
+// Allocation is the Agones allocator's call, not the server's
+func TestKeeperLeavesAgonesAllocationAlone(t *testing.T) {
+	sdk, calls := fakeAgones(t)
+	NewKeeper(sdk, func() Load { return Load{Rooms: 1, ActiveMatches: 1} }, time.Hour).Sync(context.Background())
+	for _, c := range calls() {
+		if c.Path == "/allocate" {
+			t.Fatal("keeper allocated itself under Agones")
+		}
+	}
+	if len(calls()) == 0 {
+		t.Fatal("keeper made no SDK calls")
+	}
+}
+
+func TestKeeperRunMarksReady(t *testing.T) {
+	sdk := NewLocal()
+	k := NewKeeper(sdk, func() Load { return Load{} }, 10*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() { k.Run(ctx); close(done) }()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for sdk.HealthPings() < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if sdk.State() != StateReady {
+		t.Fatalf("state = %s, want Ready", sdk.State())
+	}
+	if sdk.HealthPings() < 3 {
+		t.Fatalf("only %d health pings", sdk.HealthPings())
+	}
+}
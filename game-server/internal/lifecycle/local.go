@@ -0,0 +1,89 @@
+// SKYBATTLE — In-memory Lifecycle SDK
+// Stand-in for Agones in tests, local development and LAN/on-device hosting.
+package lifecycle
+
+import (
+	"context"
+	"sync"
+)
+
+type State string
+
+const (
+	StateScheduled State = "Scheduled"
+	StateReady     State = "Ready"
+	StateAllocated State = "Allocated"
+	StateShutdown  State = "Shutdown"
+)
+
+type Local struct {
+	mu          sync.Mutex
+	state       State
+	healthPings int
+	labels      map[string]string
+	annotations map[string]string
+}
+
+func NewLocal() *Local {
+	return &Local{
+		state:       StateScheduled,
+		labels:      make(map[string]string),
+		annotations: make(map[string]string),
+	}
+}
+
+func (l *Local) Ready(ctx context.Context) error    { return l.setState(StateReady) }
+func (l *Local) Allocate(ctx context.Context) error { return l.setState(StateAllocated) }
+func (l *Local) Shutdown(ctx context.Context) error { return l.setState(StateShutdown) }
+
+func (l *Local) Health(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.healthPings++
+	return nil
+}
+
+func (l *Local) SetLabel(ctx context.Context, key, value string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.labels[key] = value
+	return nil
+}
+
+func (l *Local) SetAnnotation(ctx context.Context, key, value string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.annotations[key] = value
+	return nil
+}
+
+func (l *Local) setState(s State) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.state = s
+	return nil
+}
+
+func (l *Local) State() State {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.state
+}
+
+func (l *Local) HealthPings() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.healthPings
+}
+
+func (l *Local) Label(key string) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.labels[key]
+}
+
+func (l *Local) Annotation(key string) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.annotations[key]
+}
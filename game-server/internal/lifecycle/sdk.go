@@ -0,0 +1,26 @@
+// SKYBATTLE — Game Server Lifecycle
+// Tells the orchestrator (Agones on Kubernetes) where this process is in its
+// life: Ready to take a match, Allocated to one, healthy, shutting down, and
+// how full it is via labels/annotations.
+package lifecycle
+
+import "context"
+
+// SDK is the subset of the Agones game server SDK the server relies on
+type SDK interface {
+	Ready(ctx context.Context) error
+	Allocate(ctx context.Context) error
+	Health(ctx context.Context) error
+	Shutdown(ctx context.Context) error
+	SetLabel(ctx context.Context, key, value string) error
+	SetAnnotation(ctx context.Context, key, value string) error
+}
+
+// New picks an SDK by name: "agones" talks to the sidecar on sdkPort,
+// anything else gets the in-memory stand-in used for tests and LAN play.
+func New(kind string, sdkPort int) SDK {
+	if kind == "agones" {
+		return NewAgonesHTTP(sdkPort)
+	}
+	return NewLocal()
+}
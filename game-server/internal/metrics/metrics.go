@@ -0,0 +1,345 @@
+// SKYBATTLE — Metrics
+// Minimal counters, gauges and histograms rendered in the Prometheus text
+// exposition format, so the server can be scraped without a client library.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+type Registry struct {
+	mu       sync.Mutex
+	families []family
+	hooks    []func()
+}
+
+type family interface {
+	write(w *bufio.Writer)
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// OnCollect registers f to run before every scrape, for gauges that are
+// cheaper to compute on demand (e.g. rooms by state) than to keep updated.
+func (r *Registry) OnCollect(f func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hooks = append(r.hooks, f)
+}
+
+func (r *Registry) register(f family) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.families = append(r.families, f)
+}
+
+// WriteTo renders every registered metric in text exposition format.
+func (r *Registry) WriteTo(out io.Writer) (int64, error) {
+	r.mu.Lock()
+	hooks := append([]func(){}, r.hooks...)
+	families := append([]family{}, r.families...)
+	r.mu.Unlock()
+
+	for _, h := range hooks {
+		h()
+	}
+
+	cw := &countingWriter{w: out}
+	w := bufio.NewWriter(cw)
+	for _, f := range families {
+		f.write(w)
+	}
+	err := w.Flush()
+	return cw.n, err
+}
+
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.WriteTo(w)
+	})
+}
+
+// ── Series bookkeeping ────────────────────────────────────────────────────────
+
+type desc struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+}
+
+func (d *desc) header(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.name, d.help, d.name, d.kind)
+}
+
+// labelString renders {a="x",b="y"} plus any extra trailing pair (used for le).
+func (d *desc) labelString(values []string, extraName, extraValue string) string {
+	if len(d.labels) == 0 && extraName == "" {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, name := range d.labels {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(name)
+		sb.WriteString(`="`)
+		sb.WriteString(escapeLabel(values[i]))
+		sb.WriteByte('"')
+	}
+	if extraName != "" {
+		if len(d.labels) > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(extraName)
+		sb.WriteString(`="`)
+		sb.WriteString(extraValue)
+		sb.WriteByte('"')
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+type vec[T any] struct {
+	desc
+	mu     sync.RWMutex
+	series map[string]*T
+	values map[string][]string
+	newT   func() *T
+}
+
+func newVec[T any](name, help, kind string, labels []string, newT func() *T) vec[T] {
+	return vec[T]{
+		desc:   desc{name: name, help: help, kind: kind, labels: labels},
+		series: make(map[string]*T),
+		values: make(map[string][]string),
+		newT:   newT,
+	}
+}
+
+func (v *vec[T]) with(values ...string) *T {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", v.name, len(v.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+
+	v.mu.RLock()
+	s, ok := v.series[key]
+	v.mu.RUnlock()
+	if ok {
+		return s
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if s, ok = v.series[key]; !ok {
+		s = v.newT()
+		v.series[key] = s
+		v.values[key] = append([]string(nil), values...)
+	}
+	return s
+}
+
+// Delete drops the series for the given label values (e.g. a closed room).
+func (v *vec[T]) Delete(values ...string) {
+	key := strings.Join(values, "\xff")
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	delete(v.series, key)
+	delete(v.values, key)
+}
+
+// Reset drops every series.
+func (v *vec[T]) Reset() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.series = make(map[string]*T)
+	v.values = make(map[string][]string)
+}
+
+func (v *vec[T]) each(f func(values []string, s *T)) {
+	v.mu.RLock()
+	keys := make([]string, 0, len(v.series))
+	for k := range v.series {
+		keys = append(keys, k)
+	}
+	v.mu.RUnlock()
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v.mu.RLock()
+		s, ok := v.series[k]
+		values := v.values[k]
+		v.mu.RUnlock()
+		if ok {
+			f(values, s)
+		}
+	}
+}
+
+// ── Counter ───────────────────────────────────────────────────────────────────
+
+type Counter struct {
+	n atomic.Uint64
+}
+
+func (c *Counter) Inc()          { c.n.Add(1) }
+func (c *Counter) Add(n int)     { c.n.Add(uint64(n)) }
+func (c *Counter) Value() uint64 { return c.n.Load() }
+
+type CounterVec struct {
+	vec[Counter]
+}
+
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{newVec(name, help, "counter", labels, func() *Counter { return &Counter{} })}
+	r.register(c)
+	return c
+}
+
+func (c *CounterVec) With(values ...string) *Counter { return c.with(values...) }
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	c.header(w)
+	c.each(func(values []string, s *Counter) {
+		fmt.Fprintf(w, "%s%s %d\n", c.name, c.labelString(values, "", ""), s.Value())
+	})
+}
+
+// ── Gauge ─────────────────────────────────────────────────────────────────────
+
+type Gauge struct {
+	bits atomic.Uint64
+}
+
+func (g *Gauge) Set(v float64) { g.bits.Store(math.Float64bits(v)) }
+func (g *Gauge) Inc()          { g.Add(1) }
+func (g *Gauge) Dec()          { g.Add(-1) }
+
+func (g *Gauge) Add(delta float64) {
+	for {
+		old := g.bits.Load()
+		next := math.Float64bits(math.Float64frombits(old) + delta)
+		if g.bits.CompareAndSwap(old, next) {
+			return
+		}
+	}
+}
+
+func (g *Gauge) Value() float64 { return math.Float64frombits(g.bits.Load()) }
+
+type GaugeVec struct {
+	vec[Gauge]
+}
+
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{newVec(name, help, "gauge", labels, func() *Gauge { return &Gauge{} })}
+	r.register(g)
+	return g
+}
+
+func (g *GaugeVec) With(values ...string) *Gauge { return g.with(values...) }
+
+func (g *GaugeVec) write(w *bufio.Writer) {
+	g.header(w)
+	g.each(func(values []string, s *Gauge) {
+		fmt.Fprintf(w, "%s%s %s\n", g.name, g.labelString(values, "", ""), formatFloat(s.Value()))
+	})
+}
+
+// ── Histogram ─────────────────────────────────────────────────────────────────
+
+type Histogram struct {
+	mu      sync.Mutex
+	bounds  []float64
+	buckets []uint64 // non-cumulative; cumulated when rendered
+	count   uint64
+	sum     float64
+}
+
+func (h *Histogram) Observe(v float64) {
+	i := sort.SearchFloat64s(h.bounds, v)
+	h.mu.Lock()
+	if i < len(h.buckets) {
+		h.buckets[i]++
+	}
+	h.count++
+	h.sum += v
+	h.mu.Unlock()
+}
+
+type HistogramVec struct {
+	vec[Histogram]
+	bounds []float64
+}
+
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	bounds := append([]float64(nil), buckets...)
+	sort.Float64s(bounds)
+	h := &HistogramVec{bounds: bounds}
+	h.vec = newVec(name, help, "histogram", labels, func() *Histogram {
+		return &Histogram{bounds: bounds, buckets: make([]uint64, len(bounds))}
+	})
+	r.register(h)
+	return h
+}
+
+func (h *HistogramVec) With(values ...string) *Histogram { return h.with(values...) }
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.header(w)
+	h.each(func(values []string, s *Histogram) {
+		s.mu.Lock()
+		buckets := append([]uint64(nil), s.buckets...)
+		count, sum := s.count, s.sum
+		s.mu.Unlock()
+
+		var cum uint64
+		for i, b := range h.bounds {
+			cum += buckets[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelString(values, "le", formatFloat(b)), cum)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, h.labelString(values, "le", "+Inf"), count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, h.labelString(values, "", ""), formatFloat(sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, h.labelString(values, "", ""), count)
+	})
+}
+
+// ── Helpers ───────────────────────────────────────────────────────────────────
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,132 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func scrape(t *testing.T, r *Registry) string {
+	t.Helper()
+	srv := httptest.NewServer(r.Handler())
+	defer srv.Close()
+
+	resp, err := srv.Client().Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Fatalf("content type = %q", ct)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func assertLines(t *testing.T, body string, want ...string) {
+	t.Helper()
+	for _, line := range want {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("missing line %q in scrape:\n%s", line, body)
+		}
+	}
+}
+
+func TestScrapeCountersAndGauges(t *testing.T) {
+	r := NewRegistry()
+	packets := r.NewCounterVec("test_packets_total", "Packets.", "type")
+	sessions := r.NewGaugeVec("test_sessions", "Sessions.")
+
+	packets.With("input").Add(3)
+	packets.With("auth").Inc()
+	sessions.With().Set(2)
+	sessions.With().Dec()
+
+	assertLines(t, scrape(t, r),
+		"# HELP test_packets_total Packets.",
+		"# TYPE test_packets_total counter",
+		`test_packets_total{type="auth"} 1`,
+		`test_packets_total{type="input"} 3`,
+		"# TYPE test_sessions gauge",
+		"test_sessions 1",
+	)
+}
+
+func TestScrapeHistogram(t *testing.T) {
+	r := NewRegistry()
+	h := r.NewHistogramVec("test_tick_seconds", "Tick time.", []float64{0.01, 0.05}, "room")
+
+	h.With("r1").Observe(0.005)
+	h.With("r1").Observe(0.02)
+	h.With("r1").Observe(0.2)
+
+	assertLines(t, scrape(t, r),
+		"# TYPE test_tick_seconds histogram",
+		`test_tick_seconds_bucket{room="r1",le="0.01"} 1`,
+		`test_tick_seconds_bucket{room="r1",le="0.05"} 2`,
+		`test_tick_seconds_bucket{room="r1",le="+Inf"} 3`,
+		`test_tick_seconds_sum{room="r1"} 0.225`,
+		`test_tick_seconds_count{room="r1"} 3`,
+	)
+}
+
+func TestCollectHookResetsSeries(t *testing.T) {
+	r := NewRegistry()
+	rooms := r.NewGaugeVec("test_rooms", "Rooms.", "state")
+	r.OnCollect(func() {
+		rooms.Reset()
+		rooms.With("IN_PROGRESS").Set(4)
+	})
+	rooms.With("STALE").Set(1)
+
+	body := scrape(t, r)
+	assertLines(t, body, `test_rooms{state="IN_PROGRESS"} 4`)
+	if strings.Contains(body, "STALE") {
+		t.Errorf("collect hook did not reset stale series:\n%s", body)
+	}
+}
+
+func TestDeleteSeries(t *testing.T) {
+	r := NewRegistry()
+	ticks := r.NewCounterVec("test_ticks_total", "Ticks.", "room")
+	ticks.With("r1").Inc()
+	ticks.With("r2").Inc()
+
+	ticks.Delete("r1")
+	body := scrape(t, r)
+	assertLines(t, body, `test_ticks_total{room="r2"} 1`)
+	if strings.Contains(body, `room="r1"`) {
+		t.Errorf("deleted series still rendered:\n%s", body)
+	}
+}
+
+func TestLabelEscaping(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("test_total", "Escapes.", "v").With("a\"b\\c").Inc()
+	assertLines(t, scrape(t, r), `test_total{v="a\"b\\c"} 1`)
+}
+
+func TestDefaultRegistryExposesServerMetrics(t *testing.T) {
+	TickDuration.With("room-1").Observe(0.004)
+	PacketsIn.With("input").Inc()
+	AntiCheatEvents.With("fire_rate").Inc()
+	defer ForgetRoom("room-1")
+
+	body := scrape(t, Default)
+	for _, name := range []string{
+		"skybattle_room_tick_duration_seconds_bucket{room=\"room-1\",le=\"0.005\"} 1",
+		"# TYPE skybattle_room_ticks_late_total counter",
+		"# TYPE skybattle_room_ticks_missed_total counter",
+		"# TYPE skybattle_bytes_sent_total counter",
+		"# TYPE skybattle_active_sessions gauge",
+		"# TYPE skybattle_rooms gauge",
+		"# TYPE skybattle_decode_errors_total counter",
+		`skybattle_anticheat_events_total{check="fire_rate"} 1`,
+	} {
+		assertLines(t, body, name)
+	}
+}
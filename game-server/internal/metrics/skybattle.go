@@ -0,0 +1,52 @@
+// SKYBATTLE — Server Metrics
+// Every metric the game server exports on /metrics
+package metrics
+
+// Default is the registry served on the admin port at /metrics
+var Default = NewRegistry()
+
+// Tick timing buckets in seconds; a 30 TPS room has a 33ms budget
+var tickBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.02, 0.033, 0.05, 0.1, 0.25}
+
+var (
+	TickDuration = Default.NewHistogramVec("skybattle_room_tick_duration_seconds",
+		"Time spent simulating one room tick.", tickBuckets, "room")
+	TicksLate = Default.NewCounterVec("skybattle_room_ticks_late_total",
+		"Ticks whose simulation took longer than the tick interval.", "room")
+	TicksMissed = Default.NewCounterVec("skybattle_room_ticks_missed_total",
+		"Ticks skipped because the room loop fell behind the ticker.", "room")
+	InputQueueDepth = Default.NewGaugeVec("skybattle_room_input_queue_depth",
+		"Inputs waiting for the room's last tick, out of its input queue's capacity.", "room")
+	InputsDropped = Default.NewCounterVec("skybattle_room_inputs_dropped_total",
+		"Inputs dropped because the room's input queue was full.", "room")
+
+	PacketsIn = Default.NewCounterVec("skybattle_packets_received_total",
+		"UDP packets received, by packet type.", "type")
+	BytesIn = Default.NewCounterVec("skybattle_bytes_received_total",
+		"UDP payload bytes received, by packet type.", "type")
+	PacketsOut = Default.NewCounterVec("skybattle_packets_sent_total",
+		"UDP packets sent, by packet type.", "type")
+	BytesOut = Default.NewCounterVec("skybattle_bytes_sent_total",
+		"UDP payload bytes sent, by packet type.", "type")
+	DecodeErrors = Default.NewCounterVec("skybattle_decode_errors_total",
+		"Packets dropped because their payload failed to decode.", "type")
+	PacketsDropped = Default.NewCounterVec("skybattle_packets_dropped_total",
+		"Packets ignored or replies withheld by the handshake and rate limits, by reason.", "reason")
+
+	ActiveSessions = Default.NewGaugeVec("skybattle_active_sessions",
+		"Authenticated client sessions.")
+	Rooms = Default.NewGaugeVec("skybattle_rooms",
+		"Rooms currently held by the room manager, by state.", "state")
+
+	AntiCheatEvents = Default.NewCounterVec("skybattle_anticheat_events_total",
+		"Inputs rejected or corrected by server-side validation, by check.", "check")
+)
+
+// ForgetRoom drops the per-room series once a room is removed
+func ForgetRoom(roomID string) {
+	TickDuration.Delete(roomID)
+	TicksLate.Delete(roomID)
+	TicksMissed.Delete(roomID)
+	InputQueueDepth.Delete(roomID)
+	InputsDropped.Delete(roomID)
+}
@@ -0,0 +1,249 @@
+// SKYBATTLE — Simulated Network Conditions
+// Makes a local server feel like the networks players are really on, for
+// QA, Unity devs and integration tests. Conditions say what happens to
+// each packet on its way (delay, jitter, loss, duplication, reordering and
+// a bandwidth cap). A Conn applies them inside the server, wrapping its
+// game port; a Proxy applies them between unmodified clients and a server
+// (see cmd/netsim).
+//
+// Every client gets its own path each way, so one client's queue or burst
+// of jitter never delays another's. Latency and jitter apply each way: a
+// round trip sees twice the latency.
+package netsim
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Conditions are what a path does to the packets on it
+type Conditions struct {
+	Latency   time.Duration // added to every packet
+	Jitter    time.Duration // each packet's delay varies by up to this either side of Latency
+	Loss      float64       // fraction of packets dropped
+	Duplicate float64       // fraction of packets delivered twice
+	Reorder   float64       // fraction of packets held back so the ones behind overtake them
+	Rate      int           // bits per second, 0 for no cap
+	Queue     time.Duration // with Rate, the longest a packet waits to be sent before it is dropped
+}
+
+// Presets are named conditions, each way
+var Presets = map[string]Conditions{
+	"lan":  {},
+	"wifi": {Latency: 4 * time.Millisecond, Jitter: 3 * time.Millisecond, Loss: 0.005},
+	"4g":   {Latency: 35 * time.Millisecond, Jitter: 15 * time.Millisecond, Loss: 0.01, Rate: 4_000_000},
+	"3g":   {Latency: 100 * time.Millisecond, Jitter: 30 * time.Millisecond, Loss: 0.02, Rate: 750_000},
+	// Round trips of 150-300ms with loss and the odd packet out of order:
+	// players on congested mobile networks
+	"jittery": {Latency: 110 * time.Millisecond, Jitter: 40 * time.Millisecond, Loss: 0.02, Duplicate: 0.005, Reorder: 0.01},
+}
+
+const defaultQueue = 200 * time.Millisecond
+
+// queue is how long packets may wait for a capped link
+func (c Conditions) queue() time.Duration {
+	if c.Queue > 0 {
+		return c.Queue
+	}
+	return defaultQueue
+}
+
+// IsZero reports whether c leaves packets alone
+func (c Conditions) IsZero() bool {
+	return c == Conditions{}
+}
+
+// ParseConditions reads a comma-separated list of presets and settings,
+// later ones overriding earlier ones:
+//
+//	3g,loss=5%
+//	latency=120ms,jitter=40ms,loss=2%,dup=0.5%,reorder=1%,rate=512kbit,queue=300ms
+func ParseConditions(s string) (Conditions, error) {
+	var c Conditions
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(item, "=")
+		if !ok {
+			preset, ok := Presets[item]
+			if !ok {
+				return c, fmt.Errorf("%q: not a preset (%s) or key=value", item, strings.Join(presetNames(), ", "))
+			}
+			c = preset
+			continue
+		}
+		var err error
+		switch key {
+		case "latency":
+			c.Latency, err = time.ParseDuration(value)
+		case "jitter":
+			c.Jitter, err = time.ParseDuration(value)
+		case "loss":
+			c.Loss, err = parseFraction(value)
+		case "dup":
+			c.Duplicate, err = parseFraction(value)
+		case "reorder":
+			c.Reorder, err = parseFraction(value)
+		case "rate":
+			c.Rate, err = parseRate(value)
+		case "queue":
+			c.Queue, err = time.ParseDuration(value)
+		default:
+			return c, fmt.Errorf("%q: unknown setting, want latency, jitter, loss, dup, reorder, rate or queue", key)
+		}
+		if err != nil {
+			return c, fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	if c.Latency < 0 || c.Jitter < 0 || c.Queue < 0 {
+		return c, fmt.Errorf("latency, jitter and queue must not be negative")
+	}
+	return c, nil
+}
+
+func (c Conditions) String() string {
+	var parts []string
+	add := func(ok bool, format string, args ...interface{}) {
+		if ok {
+			parts = append(parts, fmt.Sprintf(format, args...))
+		}
+	}
+	add(c.Latency > 0, "latency=%s", c.Latency)
+	add(c.Jitter > 0, "jitter=%s", c.Jitter)
+	add(c.Loss > 0, "loss=%g%%", c.Loss*100)
+	add(c.Duplicate > 0, "dup=%g%%", c.Duplicate*100)
+	add(c.Reorder > 0, "reorder=%g%%", c.Reorder*100)
+	add(c.Rate > 0 && c.Rate%1_000_000 == 0, "rate=%dmbit", c.Rate/1_000_000)
+	add(c.Rate > 0 && c.Rate%1_000_000 != 0, "rate=%gkbit", float64(c.Rate)/1000)
+	add(c.Queue > 0, "queue=%s", c.Queue)
+	if len(parts) == 0 {
+		return "lan"
+	}
+	return strings.Join(parts, ",")
+}
+
+// parseFraction reads "2%" or "0.02"
+func parseFraction(s string) (float64, error) {
+	scale := 1.0
+	if strings.HasSuffix(s, "%") {
+		s, scale = strings.TrimSuffix(s, "%"), 0.01
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f*scale < 0 || f*scale > 1 {
+		return 0, fmt.Errorf("%q is not a fraction from 0 to 1, or a percentage", s)
+	}
+	return f * scale, nil
+}
+
+// parseRate reads bits per second as tc does: "512kbit", "2mbit", "64000"
+func parseRate(s string) (int, error) {
+	scale := 1.0
+	for _, u := range []struct {
+		suffix string
+		scale  float64
+	}{{"kbit", 1e3}, {"mbit", 1e6}, {"bit", 1}} {
+		if strings.HasSuffix(s, u.suffix) {
+			s, scale = strings.TrimSuffix(s, u.suffix), u.scale
+			break
+		}
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("%q is not a rate like 512kbit or 2mbit", s)
+	}
+	return int(f * scale), nil
+}
+
+func presetNames() []string {
+	names := make([]string, 0, len(Presets))
+	for name := range Presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Policy picks the conditions for each client: Default, unless Clients has
+// its address (IP:port) or IP
+type Policy struct {
+	Default Conditions
+	Clients map[string]Conditions
+}
+
+// ParsePolicy reads the default conditions followed by per-client ones,
+// separated by semicolons, each client's starting with its address:
+//
+//	jittery; 127.0.0.3 3g,loss=10%; 192.168.1.20:50123 lan
+func ParsePolicy(s string) (Policy, error) {
+	var p Policy
+	for i, section := range strings.Split(s, ";") {
+		section = strings.TrimSpace(section)
+		if section == "" {
+			continue
+		}
+		addr, rest, _ := strings.Cut(section, " ")
+		if !isAddress(addr) {
+			if i > 0 {
+				return p, fmt.Errorf("%q: per-client conditions start with the client's IP or IP:port", section)
+			}
+			c, err := ParseConditions(section)
+			if err != nil {
+				return p, err
+			}
+			p.Default = c
+			continue
+		}
+		c, err := ParseConditions(rest)
+		if err != nil {
+			return p, fmt.Errorf("%s: %w", addr, err)
+		}
+		if p.Clients == nil {
+			p.Clients = map[string]Conditions{}
+		}
+		p.Clients[addr] = c
+	}
+	return p, nil
+}
+
+func isAddress(s string) bool {
+	if _, err := netip.ParseAddrPort(s); err == nil {
+		return true
+	}
+	_, err := netip.ParseAddr(s)
+	return err == nil
+}
+
+// For returns the conditions for a client
+func (p Policy) For(addr *net.UDPAddr) Conditions {
+	if len(p.Clients) > 0 {
+		ap := addr.AddrPort()
+		ip := ap.Addr().Unmap()
+		if c, ok := p.Clients[netip.AddrPortFrom(ip, ap.Port()).String()]; ok {
+			return c
+		}
+		if c, ok := p.Clients[ip.String()]; ok {
+			return c
+		}
+	}
+	return p.Default
+}
+
+func (p Policy) String() string {
+	s := p.Default.String()
+	addrs := make([]string, 0, len(p.Clients))
+	for addr := range p.Clients {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+	for _, addr := range addrs {
+		s += "; " + addr + " " + p.Clients[addr].String()
+	}
+	return s
+}
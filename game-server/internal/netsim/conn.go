@@ -0,0 +1,164 @@
+package netsim
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	maxDatagram = 65535
+	inboxSize   = 4096            // delivered packets waiting to be read, like a socket's receive buffer
+	idleAfter   = 2 * time.Minute // a client's paths are forgotten after this long without packets
+)
+
+// Conn wraps a server's UDP socket, applying the policy's conditions to
+// packets from each client as they are read and to packets to it as they
+// are written. It has the socket methods the game server uses, so the
+// server can read and write through it unchanged.
+type Conn struct {
+	conn  *net.UDPConn
+	sched *scheduler
+	inbox chan datagram
+	done  chan struct{}
+	once  sync.Once
+
+	mu     sync.Mutex
+	policy Policy
+	paths  map[pathKey]*path
+	swept  time.Time
+
+	Stats Stats
+}
+
+type datagram struct {
+	data []byte
+	addr *net.UDPAddr
+}
+
+type pathKey struct {
+	addr     string
+	outbound bool
+}
+
+// Wrap starts reading conn; from then on read and write through the Conn
+func Wrap(conn *net.UDPConn, policy Policy) *Conn {
+	c := &Conn{
+		conn:   conn,
+		sched:  newScheduler(),
+		inbox:  make(chan datagram, inboxSize),
+		done:   make(chan struct{}),
+		policy: policy,
+		paths:  map[pathKey]*path{},
+	}
+	go c.receive()
+	return c
+}
+
+// receive reads the socket and sends each packet down its client's inbound
+// path
+func (c *Conn) receive() {
+	buf := make([]byte, maxDatagram)
+	var ats []time.Time
+	for {
+		n, addr, err := c.conn.ReadFromUDP(buf)
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			continue
+		}
+		data := append([]byte(nil), buf[:n]...)
+		ats = c.schedule(pathKey{addr.String(), false}, addr, data, ats[:0])
+	}
+}
+
+// schedule sends data down a client's path
+func (c *Conn) schedule(key pathKey, addr *net.UDPAddr, data []byte, ats []time.Time) []time.Time {
+	now := time.Now()
+	c.mu.Lock()
+	p := c.paths[key]
+	if p == nil {
+		p = c.newPath(key, addr)
+		c.paths[key] = p
+	}
+	ats = p.arrivals(now, len(data), &c.Stats, ats)
+	if now.Sub(c.swept) > idleAfter {
+		c.swept = now
+		for k, p := range c.paths {
+			if now.Sub(p.lastUsed) > idleAfter {
+				delete(c.paths, k)
+			}
+		}
+	}
+	c.mu.Unlock()
+
+	for _, at := range ats {
+		c.sched.add(at, data, p)
+	}
+	return ats
+}
+
+func (c *Conn) newPath(key pathKey, addr *net.UDPAddr) *path {
+	if key.outbound {
+		return newPath(addr, c.policy.For(addr), func(data []byte) {
+			c.conn.WriteToUDP(data, addr)
+		})
+	}
+	return newPath(addr, c.policy.For(addr), func(data []byte) {
+		select {
+		case c.inbox <- datagram{data, addr}:
+		default: // nobody reading fast enough
+			c.Stats.Dropped.Add(1)
+		}
+	})
+}
+
+// ReadFromUDP returns the next packet to arrive from a client
+func (c *Conn) ReadFromUDP(b []byte) (int, *net.UDPAddr, error) {
+	select {
+	case d := <-c.inbox:
+		return copy(b, d.data), d.addr, nil
+	case <-c.done:
+		return 0, nil, net.ErrClosed
+	}
+}
+
+// WriteToUDP sends b to a client down its outbound path. It returns at
+// once; b can be reused.
+func (c *Conn) WriteToUDP(b []byte, addr *net.UDPAddr) (int, error) {
+	select {
+	case <-c.done:
+		return 0, net.ErrClosed
+	default:
+	}
+	c.schedule(pathKey{addr.String(), true}, addr, append([]byte(nil), b...), nil)
+	return len(b), nil
+}
+
+func (c *Conn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+// Close closes the socket, dropping packets still on their way
+func (c *Conn) Close() error {
+	var err error
+	c.once.Do(func() {
+		close(c.done)
+		c.sched.stop()
+		err = c.conn.Close()
+	})
+	return err
+}
+
+// SetPolicy changes the conditions, for clients already talking as well as
+// new ones
+func (c *Conn) SetPolicy(policy Policy) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.policy = policy
+	for _, p := range c.paths {
+		p.cond = policy.For(p.addr)
+	}
+}
@@ -0,0 +1,244 @@
+package netsim
+
+import (
+	"math/rand"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestParsePolicy(t *testing.T) {
+	p, err := ParsePolicy("jittery; 127.0.0.3 3g, loss=10%; 127.0.0.4:5000 latency=5ms,rate=2mbit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Default != Presets["jittery"] {
+		t.Errorf("default %s", p.Default)
+	}
+	want3g := Presets["3g"]
+	want3g.Loss = 0.1
+	for _, tc := range []struct {
+		addr string
+		want Conditions
+	}{
+		{"127.0.0.3:40000", want3g},
+		{"127.0.0.4:5000", Conditions{Latency: 5 * time.Millisecond, Rate: 2_000_000}},
+		{"127.0.0.4:5001", p.Default},
+		{"[::ffff:127.0.0.3]:1", want3g},
+	} {
+		if got := p.For(udpAddr(t, tc.addr)); got != tc.want {
+			t.Errorf("%s: got %s, want %s", tc.addr, got, tc.want)
+		}
+	}
+
+	if again, err := ParsePolicy(p.String()); err != nil || again.String() != p.String() {
+		t.Errorf("%q did not parse back: %v", p.String(), err)
+	}
+
+	for _, bad := range []string{"latency=fast", "loss=120%", "rate=lots", "satellite", "jittery; wifi", "jitter=-5ms"} {
+		if _, err := ParsePolicy(bad); err == nil {
+			t.Errorf("%q accepted", bad)
+		}
+	}
+}
+
+func udpAddr(t *testing.T, s string) *net.UDPAddr {
+	t.Helper()
+	addr, err := net.ResolveUDPAddr("udp", s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return addr
+}
+
+// testPath is a path with a fixed seed, driven by simulated time
+func testPath(c Conditions) *path {
+	p := newPath(nil, c, nil)
+	p.rng = rand.New(rand.NewSource(1))
+	return p
+}
+
+func TestLossAndDuplication(t *testing.T) {
+	const n = 20000
+	p := testPath(Conditions{Loss: 0.1, Duplicate: 0.05})
+	var stats Stats
+	now := time.Now()
+	delivered := 0
+	for i := 0; i < n; i++ {
+		delivered += len(p.arrivals(now, 100, &stats, nil))
+	}
+	if f := float64(stats.Dropped.Load()) / n; f < 0.09 || f > 0.11 {
+		t.Errorf("%.3f lost, want 0.1", f)
+	}
+	if f := float64(stats.Duplicated.Load()) / n; f < 0.04 || f > 0.06 {
+		t.Errorf("%.3f duplicated, want 0.05", f)
+	}
+	if want := n - int(stats.Dropped.Load()) + int(stats.Duplicated.Load()); delivered != want {
+		t.Errorf("%d copies delivered, want %d", delivered, want)
+	}
+}
+
+// Jitter spreads the delay without letting packets overtake each other
+func TestJitterKeepsOrder(t *testing.T) {
+	c := Conditions{Latency: 100 * time.Millisecond, Jitter: 40 * time.Millisecond}
+	p := testPath(c)
+	var stats Stats
+	start := time.Now()
+	var last time.Time
+	lo, hi := time.Hour, time.Duration(0)
+	for i := 0; i < 1000; i++ {
+		sent := start.Add(time.Duration(i) * 10 * time.Millisecond)
+		at := p.arrivals(sent, 100, &stats, nil)[0]
+		if at.Before(last) {
+			t.Fatalf("packet %d overtook the one before", i)
+		}
+		last = at
+		d := at.Sub(sent)
+		lo, hi = min(lo, d), max(hi, d)
+	}
+	if lo < c.Latency-c.Jitter || hi > c.Latency+c.Jitter {
+		t.Errorf("delays %s-%s, want within %s±%s", lo, hi, c.Latency, c.Jitter)
+	}
+	if hi-lo < c.Jitter {
+		t.Errorf("delays %s-%s hardly vary", lo, hi)
+	}
+}
+
+func TestReorder(t *testing.T) {
+	p := testPath(Conditions{Latency: 50 * time.Millisecond, Reorder: 0.1})
+	var stats Stats
+	start := time.Now()
+	var last time.Time
+	overtaken := 0
+	for i := 0; i < 1000; i++ {
+		at := p.arrivals(start.Add(time.Duration(i)*20*time.Millisecond), 100, &stats, nil)[0]
+		if at.Before(last) {
+			overtaken++
+		}
+		last = at
+	}
+	if r := stats.Reordered.Load(); r < 70 || r > 130 {
+		t.Errorf("%d of 1000 reordered, want about 100", r)
+	}
+	if overtaken == 0 {
+		t.Error("no packet was overtaken")
+	}
+}
+
+// A capped link delivers at its rate and drops what overflows its queue
+func TestRateCap(t *testing.T) {
+	c := Conditions{Rate: 80_000, Queue: 200 * time.Millisecond} // 10 KB/s
+	p := testPath(c)
+	var stats Stats
+	start := time.Now()
+	delivered := 0
+	var last time.Time
+	for i := 0; i < 1000; i++ { // 100 KB/s offered for a second
+		for _, at := range p.arrivals(start.Add(time.Duration(i)*time.Millisecond), 100, &stats, nil) {
+			delivered++
+			last = at
+		}
+	}
+	// A second at the rate, plus what the queue held when it ended
+	if delivered < 110 || delivered > 125 {
+		t.Errorf("%d packets delivered, want about 120", delivered)
+	}
+	if d := last.Sub(start); d < 1100*time.Millisecond || d > 1250*time.Millisecond {
+		t.Errorf("last packet after %s, want about 1.2s", d)
+	}
+	if int(stats.Dropped.Load()) != 1000-delivered {
+		t.Errorf("%d dropped, %d delivered", stats.Dropped.Load(), delivered)
+	}
+}
+
+// echo answers every packet read from conn
+func echo(conn interface {
+	ReadFromUDP([]byte) (int, *net.UDPAddr, error)
+	WriteToUDP([]byte, *net.UDPAddr) (int, error)
+}) {
+	buf := make([]byte, 1500)
+	for {
+		n, addr, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		conn.WriteToUDP(buf[:n], addr)
+	}
+}
+
+// roundTrip sends to addr from conn and times the echo, 0 if none comes
+func roundTrip(t *testing.T, conn *net.UDPConn, addr *net.UDPAddr) time.Duration {
+	t.Helper()
+	start := time.Now()
+	if _, err := conn.WriteToUDP([]byte("ping"), addr); err != nil {
+		t.Fatal(err)
+	}
+	conn.SetReadDeadline(start.Add(500 * time.Millisecond))
+	buf := make([]byte, 16)
+	if _, _, err := conn.ReadFromUDP(buf); err != nil {
+		return 0
+	}
+	return time.Since(start)
+}
+
+func listenLocal(t *testing.T) *net.UDPConn {
+	t.Helper()
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// A wrapped server socket delays each client by its own conditions
+func TestConnConditionsPerClient(t *testing.T) {
+	slow, fast, lost := listenLocal(t), listenLocal(t), listenLocal(t)
+	policy := Policy{
+		Default: Conditions{Latency: 60 * time.Millisecond},
+		Clients: map[string]Conditions{
+			fast.LocalAddr().String(): {},
+			lost.LocalAddr().String(): {Loss: 1},
+		},
+	}
+	server := Wrap(listenLocal(t), policy)
+	defer server.Close()
+	go echo(server)
+	addr := server.LocalAddr().(*net.UDPAddr)
+
+	if rtt := roundTrip(t, slow, addr); rtt < 120*time.Millisecond {
+		t.Errorf("slow client's round trip %s, want 120ms or more", rtt)
+	}
+	if rtt := roundTrip(t, fast, addr); rtt == 0 || rtt > 50*time.Millisecond {
+		t.Errorf("fast client's round trip %s", rtt)
+	}
+	if rtt := roundTrip(t, lost, addr); rtt != 0 {
+		t.Errorf("lossy client got an answer after %s", rtt)
+	}
+
+	server.SetPolicy(Policy{})
+	if rtt := roundTrip(t, slow, addr); rtt == 0 || rtt > 50*time.Millisecond {
+		t.Errorf("round trip %s after clearing the conditions", rtt)
+	}
+}
+
+func TestProxy(t *testing.T) {
+	server := listenLocal(t)
+	go echo(server)
+
+	proxy, err := NewProxy("127.0.0.1:0", server.LocalAddr().String(), Policy{Default: Conditions{Latency: 40 * time.Millisecond}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go proxy.Serve()
+	defer proxy.Close()
+
+	client := listenLocal(t)
+	if rtt := roundTrip(t, client, proxy.Addr()); rtt < 80*time.Millisecond {
+		t.Errorf("round trip %s through the proxy, want 80ms or more", rtt)
+	}
+	proxy.SetPolicy(Policy{Default: Conditions{Loss: 1}})
+	if rtt := roundTrip(t, client, proxy.Addr()); rtt != 0 {
+		t.Errorf("answer after %s through a proxy losing everything", rtt)
+	}
+}
@@ -0,0 +1,202 @@
+package netsim
+
+import (
+	"container/heap"
+	"math/rand"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// reorderHold is the least a reordered packet is held back, long enough for
+// the next packet at 30-60 Hz to overtake it
+const reorderHold = 50 * time.Millisecond
+
+// Stats count what the paths did, summed over every client and direction
+type Stats struct {
+	Packets    atomic.Int64 // offered to a path
+	Dropped    atomic.Int64 // lost, or over a capped link's queue
+	Duplicated atomic.Int64
+	Reordered  atomic.Int64
+}
+
+// seeds gives each path its own random sequence
+var seeds atomic.Int64
+
+func init() {
+	seeds.Store(time.Now().UnixNano())
+}
+
+// path is one direction for one client. Callers serialise use of it.
+type path struct {
+	addr      *net.UDPAddr // the client's
+	cond      Conditions
+	rng       *rand.Rand
+	busyUntil time.Time // when a capped link has sent what is queued on it
+	lastAt    time.Time // when the last in-order packet arrives
+	lastUsed  time.Time
+	deliver   func(data []byte)
+}
+
+func newPath(addr *net.UDPAddr, cond Conditions, deliver func(data []byte)) *path {
+	return &path{addr: addr, cond: cond, rng: rand.New(rand.NewSource(seeds.Add(1))), deliver: deliver}
+}
+
+// arrivals appends when each copy of a size-byte packet sent at now
+// arrives: none if it is lost, two if it is duplicated
+func (p *path) arrivals(now time.Time, size int, stats *Stats, ats []time.Time) []time.Time {
+	c := p.cond
+	p.lastUsed = now
+	stats.Packets.Add(1)
+	if c.IsZero() {
+		return append(ats, now)
+	}
+	if c.Loss > 0 && p.rng.Float64() < c.Loss {
+		stats.Dropped.Add(1)
+		return ats
+	}
+
+	// A capped link sends one packet at a time; those behind it queue, and
+	// the queue overflowing drops them
+	sent := now
+	if c.Rate > 0 {
+		start := now
+		if p.busyUntil.After(now) {
+			start = p.busyUntil
+		}
+		if start.Sub(now) > c.queue() {
+			stats.Dropped.Add(1)
+			return ats
+		}
+		sent = start.Add(time.Duration(int64(size) * 8 * int64(time.Second) / int64(c.Rate)))
+		p.busyUntil = sent
+	}
+
+	copies := 1
+	if c.Duplicate > 0 && p.rng.Float64() < c.Duplicate {
+		copies = 2
+		stats.Duplicated.Add(1)
+	}
+	for i := 0; i < copies; i++ {
+		at := sent.Add(p.delay())
+		if c.Reorder > 0 && p.rng.Float64() < c.Reorder {
+			// Held back out of line, so the next packets overtake it
+			at = at.Add(reorderHold + time.Duration(p.rng.Int63n(int64(2*c.Jitter)+1)))
+			stats.Reordered.Add(1)
+		} else {
+			// Jitter varies the delay but, as on most real paths, does not
+			// reorder: a packet never arrives before the one sent ahead of it
+			if at.Before(p.lastAt) {
+				at = p.lastAt
+			}
+			p.lastAt = at
+		}
+		ats = append(ats, at)
+	}
+	return ats
+}
+
+// delay is the latency with jitter, spread evenly either side
+func (p *path) delay() time.Duration {
+	d := p.cond.Latency
+	if j := p.cond.Jitter; j > 0 {
+		d += time.Duration(p.rng.Int63n(int64(2*j)+1)) - j
+	}
+	return max(d, 0)
+}
+
+// packet is a copy of a datagram on its way
+type packet struct {
+	at   time.Time
+	seq  uint64 // keeps packets due at the same time in order
+	data []byte
+	path *path
+}
+
+type packetHeap []packet
+
+func (h packetHeap) Len() int { return len(h) }
+func (h packetHeap) Less(i, j int) bool {
+	if h[i].at.Equal(h[j].at) {
+		return h[i].seq < h[j].seq
+	}
+	return h[i].at.Before(h[j].at)
+}
+func (h packetHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *packetHeap) Push(x interface{}) { *h = append(*h, x.(packet)) }
+func (h *packetHeap) Pop() interface{} {
+	old := *h
+	p := old[len(old)-1]
+	old[len(old)-1] = packet{}
+	*h = old[:len(old)-1]
+	return p
+}
+
+// scheduler delivers packets when they are due, on one goroutine so
+// packets due in order are delivered in order
+type scheduler struct {
+	mu    sync.Mutex
+	queue packetHeap
+	seq   uint64
+	wake  chan struct{}
+	done  chan struct{}
+	once  sync.Once
+}
+
+func newScheduler() *scheduler {
+	s := &scheduler{wake: make(chan struct{}, 1), done: make(chan struct{})}
+	go s.run()
+	return s
+}
+
+func (s *scheduler) add(at time.Time, data []byte, p *path) {
+	s.mu.Lock()
+	s.seq++
+	heap.Push(&s.queue, packet{at: at, seq: s.seq, data: data, path: p})
+	first := s.queue[0].seq == s.seq
+	s.mu.Unlock()
+	if first {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (s *scheduler) run() {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+	var due []packet
+	for {
+		s.mu.Lock()
+		now := time.Now()
+		for len(s.queue) > 0 && !s.queue[0].at.After(now) {
+			due = append(due, heap.Pop(&s.queue).(packet))
+		}
+		wait := time.Hour
+		if len(s.queue) > 0 {
+			wait = s.queue[0].at.Sub(now)
+		}
+		s.mu.Unlock()
+
+		for i := range due {
+			due[i].path.deliver(due[i].data)
+			due[i] = packet{}
+		}
+		due = due[:0]
+
+		timer.Reset(wait)
+		select {
+		case <-timer.C:
+		case <-s.wake:
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// stop drops whatever is still on its way
+func (s *scheduler) stop() {
+	s.once.Do(func() { close(s.done) })
+}
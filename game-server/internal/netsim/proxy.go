@@ -0,0 +1,181 @@
+package netsim
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// Proxy relays UDP between clients and a server, applying the policy's
+// conditions both ways. Each client gets its own socket to the server, so
+// the server tells them apart as it would on a real network, though they
+// all come from the proxy's address.
+type Proxy struct {
+	listen *net.UDPConn
+	server *net.UDPAddr
+	sched  *scheduler
+	done   chan struct{}
+	once   sync.Once
+
+	mu      sync.Mutex
+	policy  Policy
+	clients map[string]*proxyClient
+
+	Stats Stats
+}
+
+type proxyClient struct {
+	addr     *net.UDPAddr
+	upstream *net.UDPConn // connected to the server
+	up, down *path
+}
+
+// NewProxy listens for clients on listen and relays them to server
+func NewProxy(listen, server string, policy Policy) (*Proxy, error) {
+	serverAddr, err := net.ResolveUDPAddr("udp", server)
+	if err != nil {
+		return nil, err
+	}
+	listenAddr, err := net.ResolveUDPAddr("udp", listen)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp", listenAddr)
+	if err != nil {
+		return nil, err
+	}
+	return &Proxy{
+		listen:  conn,
+		server:  serverAddr,
+		sched:   newScheduler(),
+		done:    make(chan struct{}),
+		policy:  policy,
+		clients: map[string]*proxyClient{},
+	}, nil
+}
+
+// Addr is where clients connect
+func (p *Proxy) Addr() *net.UDPAddr {
+	return p.listen.LocalAddr().(*net.UDPAddr)
+}
+
+// Serve relays until Close
+func (p *Proxy) Serve() error {
+	go p.sweep()
+	buf := make([]byte, maxDatagram)
+	var ats []time.Time
+	for {
+		n, addr, err := p.listen.ReadFromUDP(buf)
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		if err != nil {
+			continue
+		}
+		client, err := p.client(addr)
+		if err != nil {
+			continue
+		}
+		data := append([]byte(nil), buf[:n]...)
+		ats = p.schedule(client.up, data, ats[:0])
+	}
+}
+
+// client finds or starts the relay for addr
+func (p *Proxy) client(addr *net.UDPAddr) (*proxyClient, error) {
+	key := addr.String()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if c, ok := p.clients[key]; ok {
+		return c, nil
+	}
+	upstream, err := net.DialUDP("udp", nil, p.server)
+	if err != nil {
+		return nil, err
+	}
+	cond := p.policy.For(addr)
+	c := &proxyClient{addr: addr, upstream: upstream}
+	c.up = newPath(addr, cond, func(data []byte) { upstream.Write(data) })
+	c.down = newPath(addr, cond, func(data []byte) { p.listen.WriteToUDP(data, addr) })
+	p.clients[key] = c
+	go p.relayDown(c)
+	return c, nil
+}
+
+// relayDown sends what the server says to a client down its path, until
+// the client's socket is closed
+func (p *Proxy) relayDown(c *proxyClient) {
+	buf := make([]byte, maxDatagram)
+	var ats []time.Time
+	for {
+		n, err := c.upstream.Read(buf)
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			continue // an ICMP error from an earlier send
+		}
+		data := append([]byte(nil), buf[:n]...)
+		ats = p.schedule(c.down, data, ats[:0])
+	}
+}
+
+func (p *Proxy) schedule(path *path, data []byte, ats []time.Time) []time.Time {
+	p.mu.Lock()
+	ats = path.arrivals(time.Now(), len(data), &p.Stats, ats)
+	p.mu.Unlock()
+	for _, at := range ats {
+		p.sched.add(at, data, path)
+	}
+	return ats
+}
+
+// sweep closes relays for clients gone quiet
+func (p *Proxy) sweep() {
+	ticker := time.NewTicker(idleAfter / 4)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.done:
+			return
+		case now := <-ticker.C:
+			p.mu.Lock()
+			for key, c := range p.clients {
+				if now.Sub(c.up.lastUsed) > idleAfter && now.Sub(c.down.lastUsed) > idleAfter {
+					c.upstream.Close()
+					delete(p.clients, key)
+				}
+			}
+			p.mu.Unlock()
+		}
+	}
+}
+
+// SetPolicy changes the conditions, for clients already connected as well
+// as new ones
+func (p *Proxy) SetPolicy(policy Policy) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.policy = policy
+	for _, c := range p.clients {
+		c.up.cond = policy.For(c.addr)
+		c.down.cond = c.up.cond
+	}
+}
+
+// Close stops relaying, dropping packets still on their way
+func (p *Proxy) Close() error {
+	var err error
+	p.once.Do(func() {
+		close(p.done)
+		p.sched.stop()
+		err = p.listen.Close()
+		p.mu.Lock()
+		for _, c := range p.clients {
+			c.upstream.Close()
+		}
+		p.mu.Unlock()
+	})
+	return err
+}
@@ -0,0 +1,110 @@
+// SKYBATTLE — Batched Sends
+// A room's world state goes out as one batch: encoded once per capability
+// set into a pooled buffer, sealed per session into the batch's arena, then
+// handed to the socket in one sendmmsg(2) call where the platform has it
+// (Linux on amd64 and arm64) or one WriteToUDP per packet elsewhere.
+// Batches and encode buffers come from pools, so a steady broadcast does
+// not allocate for sending.
+package network
+
+import (
+	"bytes"
+	"net"
+	"sync"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/metrics"
+)
+
+// outPacket is one datagram of a batch, stored in the batch's arena
+type outPacket struct {
+	addr       *net.UDPAddr
+	kind       ServerPacketType // what it carries, for metrics
+	start, end int
+	sent       int // bytes written, 0 if the send failed
+}
+
+type sendBatch struct {
+	arena   []byte
+	packets []outPacket
+	scratch batchScratch // per platform, reused between flushes
+}
+
+var (
+	batchPool  = sync.Pool{New: func() interface{} { return new(sendBatch) }}
+	bufferPool = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+)
+
+func getBatch() *sendBatch {
+	return batchPool.Get().(*sendBatch)
+}
+
+func putBatch(b *sendBatch) {
+	for i := range b.packets {
+		b.packets[i].addr = nil
+	}
+	b.arena = b.arena[:0]
+	b.packets = b.packets[:0]
+	batchPool.Put(b)
+}
+
+func getBuffer() *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	bufferPool.Put(buf)
+}
+
+// Add queues an encoded packet for a session, sealed if it has a key
+func (b *sendBatch) Add(sess *ClientSession, data []byte) {
+	start := len(b.arena)
+	if sess.crypt != nil {
+		b.arena = sess.crypt.Seal(b.arena, data)
+	} else {
+		b.arena = append(b.arena, data...)
+	}
+	b.packets = append(b.packets, outPacket{
+		addr:  sess.Addr,
+		kind:  ServerPacketType(data[0]),
+		start: start,
+		end:   len(b.arena),
+	})
+}
+
+func (b *sendBatch) data(p *outPacket) []byte {
+	return b.arena[p.start:p.end]
+}
+
+// flush writes a batch and counts what was sent
+func (s *Server) flush(b *sendBatch) {
+	if len(b.packets) == 0 {
+		return
+	}
+	writeBatch(s.conn, b)
+
+	// Counted per run of one packet type; a broadcast is a single run
+	kind, count, bytes := b.packets[0].kind, 0, 0
+	for _, p := range b.packets {
+		if p.kind != kind {
+			metrics.PacketsOut.With(kind.String()).Add(count)
+			metrics.BytesOut.With(kind.String()).Add(bytes)
+			kind, count, bytes = p.kind, 0, 0
+		}
+		if p.sent > 0 {
+			count++
+			bytes += p.sent
+		}
+	}
+	metrics.PacketsOut.With(kind.String()).Add(count)
+	metrics.BytesOut.With(kind.String()).Add(bytes)
+}
+
+// writeOne sends packet i of a batch on its own
+func writeOne(conn gamePort, b *sendBatch, i int) {
+	p := &b.packets[i]
+	if n, err := conn.WriteToUDP(b.data(p), p.addr); err == nil {
+		p.sent = n
+	}
+}
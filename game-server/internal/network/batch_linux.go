@@ -0,0 +1,146 @@
+//go:build linux && (amd64 || arm64)
+
+package network
+
+import (
+	"net"
+	"syscall"
+	"unsafe"
+)
+
+// maxBatch is the kernel's UIO_MAXIOV, the most messages per sendmmsg
+const maxBatch = 1024
+
+// mmsghdr is struct mmsghdr on 64-bit Linux
+type mmsghdr struct {
+	hdr syscall.Msghdr
+	len uint32
+	_   [4]byte
+}
+
+type batchScratch struct {
+	conn   *net.UDPConn
+	rc     syscall.RawConn
+	family int // the socket's address family
+
+	hdrs  []mmsghdr
+	iovs  []syscall.Iovec
+	names []syscall.RawSockaddrInet6 // big enough for either family
+	index []int                      // packet behind each header
+	slow  []int                      // packets sendmmsg cannot address
+}
+
+func (sc *batchScratch) bind(conn *net.UDPConn) bool {
+	if sc.conn == conn {
+		return sc.rc != nil
+	}
+	sc.conn, sc.rc = conn, nil
+	rc, err := conn.SyscallConn()
+	if err != nil {
+		return false
+	}
+	var sa syscall.Sockaddr
+	rc.Control(func(fd uintptr) { sa, err = syscall.Getsockname(int(fd)) })
+	switch sa.(type) {
+	case *syscall.SockaddrInet4:
+		sc.family = syscall.AF_INET
+	case *syscall.SockaddrInet6:
+		sc.family = syscall.AF_INET6
+	default:
+		return false
+	}
+	sc.rc = rc
+	return err == nil
+}
+
+// sockaddr fills name with addr for the socket's family, or returns false
+func (sc *batchScratch) sockaddr(name *syscall.RawSockaddrInet6, addr *net.UDPAddr) (uint32, bool) {
+	if addr.Zone != "" {
+		return 0, false
+	}
+	port := (*[2]byte)(unsafe.Pointer(&name.Port)) // network byte order
+	port[0], port[1] = byte(addr.Port>>8), byte(addr.Port)
+	if sc.family == syscall.AF_INET {
+		ip4 := addr.IP.To4()
+		if ip4 == nil {
+			return 0, false
+		}
+		sa := (*syscall.RawSockaddrInet4)(unsafe.Pointer(name))
+		sa.Family = syscall.AF_INET
+		copy(sa.Addr[:], ip4)
+		return syscall.SizeofSockaddrInet4, true
+	}
+	ip16 := addr.IP.To16()
+	if ip16 == nil {
+		return 0, false
+	}
+	name.Family = syscall.AF_INET6
+	name.Flowinfo, name.Scope_id = 0, 0
+	copy(name.Addr[:], ip16) // IPv4 comes out v4-mapped, as a dual-stack socket wants
+	return syscall.SizeofSockaddrInet6, true
+}
+
+func writeBatch(conn gamePort, b *sendBatch) {
+	sc := &b.scratch
+	udp, ok := conn.(*net.UDPConn) // not when simulating a network
+	if !ok || !sc.bind(udp) {
+		for i := range b.packets {
+			writeOne(conn, b, i)
+		}
+		return
+	}
+
+	n := len(b.packets)
+	if cap(sc.hdrs) < n {
+		sc.hdrs = make([]mmsghdr, n)
+		sc.iovs = make([]syscall.Iovec, n)
+		sc.names = make([]syscall.RawSockaddrInet6, n)
+		sc.index = make([]int, n)
+	}
+	hdrs, iovs, names, index := sc.hdrs[:0], sc.iovs[:n], sc.names[:n], sc.index[:0]
+	slow := sc.slow[:0]
+	for i := range b.packets {
+		p := &b.packets[i]
+		k := len(hdrs)
+		namelen, ok := sc.sockaddr(&names[k], p.addr)
+		if !ok {
+			slow = append(slow, i)
+			continue
+		}
+		data := b.data(p)
+		iovs[k].Base = &data[0]
+		iovs[k].SetLen(len(data))
+		hdrs = append(hdrs, mmsghdr{hdr: syscall.Msghdr{
+			Name:    (*byte)(unsafe.Pointer(&names[k])),
+			Namelen: namelen,
+			Iov:     &iovs[k],
+			Iovlen:  1,
+		}})
+		index = append(index, i)
+	}
+
+	sent := 0
+	sc.rc.Write(func(fd uintptr) bool {
+		for sent < len(hdrs) {
+			count := min(len(hdrs)-sent, maxBatch)
+			r, _, errno := syscall.Syscall6(sysSendmmsg, fd, uintptr(unsafe.Pointer(&hdrs[sent])), uintptr(count), 0, 0, 0)
+			switch errno {
+			case 0:
+				sent += int(r)
+			case syscall.EAGAIN:
+				return false // wait until the socket is writable
+			case syscall.EINTR:
+			default:
+				sent++ // only the first message failed; skip it
+			}
+		}
+		return true
+	})
+	for k, i := range index {
+		b.packets[i].sent = int(hdrs[k].len)
+	}
+	for _, i := range slow {
+		writeOne(conn, b, i)
+	}
+	sc.slow = slow[:0]
+}
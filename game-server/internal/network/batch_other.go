@@ -0,0 +1,11 @@
+//go:build !linux || !(amd64 || arm64)
+
+package network
+
+type batchScratch struct{}
+
+func writeBatch(conn gamePort, b *sendBatch) {
+	for i := range b.packets {
+		writeOne(conn, b, i)
+	}
+}
@@ -0,0 +1,209 @@
+package network
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/config"
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/game"
+)
+
+// session returns the server's session for a test client
+func (c *testClient) session(s *Server) *ClientSession {
+	c.t.Helper()
+	sess, ok := s.sessions.Load(c.conn.LocalAddr().String())
+	if !ok {
+		c.t.Fatal("no session")
+	}
+	return sess.(*ClientSession)
+}
+
+func TestBroadcastReachesOnlyTheRoom(t *testing.T) {
+	s := startServer(t)
+
+	full, other := dial(t, s), dial(t, s)
+	full.auth("device-a")
+	other.auth("device-b")
+	// A protocol 3 client without the actions capability, in the clear
+	legacy := dial(t, s)
+	legacy.send(AuthPacket{Token: "device-c", Version: 3, Caps: uint32(CapInventory), Cookie: legacy.connect()})
+	legacy.expect(PacketAuthAck, &AuthAckPacket{})
+
+	s.enterRoom(full.session(s), "room-a", 0)
+	s.enterRoom(legacy.session(s), "room-a", 0)
+	s.enterRoom(other.session(s), "room-b", 0)
+
+	s.broadcastState("room-a", WorldStatePacket{
+		Tick:   42,
+		Events: []game.MatchEvent{{Type: "KILL"}, {Type: "MELEE"}},
+	})
+
+	var got WorldStatePacket
+	full.expect(PacketWorldState, &got)
+	if got.Tick != 42 || len(got.Events) != 2 {
+		t.Fatalf("full client got %+v", got)
+	}
+	got = WorldStatePacket{}
+	legacy.expect(PacketWorldState, &got)
+	if got.Tick != 42 || len(got.Events) != 1 {
+		t.Fatalf("legacy client got %+v", got)
+	}
+	other.expectNothing(PacketWorldState, 100*time.Millisecond)
+
+	// Moving rooms moves the broadcasts
+	s.enterRoom(full.session(s), "room-b", 0)
+	s.broadcastState("room-a", WorldStatePacket{Tick: 43})
+	full.expectNothing(PacketWorldState, 100*time.Millisecond)
+	legacy.expect(PacketWorldState, &got)
+}
+
+func TestRecipientIndex(t *testing.T) {
+	x := newRecipientIndex()
+	a, b := &ClientSession{}, &ClientSession{}
+	x.Add("r", a)
+	x.Add("r", a)
+	x.Add("r", b)
+	held := x.Recipients("r")
+	if len(held) != 2 {
+		t.Fatalf("recipients %v", held)
+	}
+
+	x.Remove("r", a)
+	if got := x.Recipients("r"); len(got) != 1 || got[0] != b {
+		t.Fatalf("after remove %v", got)
+	}
+	if held[0] != a || held[1] != b {
+		t.Fatal("a list already handed out was modified")
+	}
+
+	x.Add("gone", a)
+	x.Prune(func(roomID string) bool { return roomID == "r" })
+	if x.Recipients("gone") != nil || x.Recipients("r") == nil {
+		t.Fatal("prune")
+	}
+	x.Remove("r", b)
+	if _, ok := x.rooms["r"]; ok {
+		t.Fatal("empty room kept")
+	}
+}
+
+// A server listening on all addresses has a dual-stack IPv6 socket where
+// the platform allows it, and IPv4 clients need v4-mapped addresses
+func TestBatchFromWildcardSocket(t *testing.T) {
+	s := NewServer(&config.Config{})
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	s.conn = conn
+
+	sink, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	sess := &ClientSession{Addr: sink.LocalAddr().(*net.UDPAddr)}
+
+	b := getBatch()
+	defer putBatch(b)
+	b.Add(sess, []byte{byte(PacketPong)})
+	b.Add(sess, []byte{byte(PacketPong), 1})
+	s.flush(b)
+
+	buf := make([]byte, 16)
+	for want := 1; want <= 2; want++ {
+		sink.SetReadDeadline(time.Now().Add(time.Second))
+		n, err := sink.Read(buf)
+		if err != nil || n != want || ServerPacketType(buf[0]) != PacketPong {
+			t.Fatalf("read %x (%v), want a %d byte pong", buf[:n], err, want)
+		}
+	}
+}
+
+// A server with rooms×perRoom sealed sessions pointed at sink sockets that
+// are never read, and the world state each room sends
+func benchmarkServer(b *testing.B, rooms, perRoom int) (*Server, WorldStatePacket) {
+	s := NewServer(&config.Config{})
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		b.Fatal(err)
+	}
+	s.conn = conn
+	b.Cleanup(func() { conn.Close() })
+
+	for r := 0; r < rooms; r++ {
+		for p := 0; p < perRoom; p++ {
+			sink, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.Cleanup(func() { sink.Close() })
+			_, crypt := keyPair(b)
+			sess := &ClientSession{
+				Addr:    sink.LocalAddr().(*net.UDPAddr),
+				Caps:    CapInventory | CapActions,
+				Version: ProtocolVersion,
+				crypt:   crypt,
+			}
+			s.sessions.Store(sess.Addr.String(), sess)
+			s.enterRoom(sess, fmt.Sprintf("room-%d", r), p+1)
+		}
+	}
+
+	state := WorldStatePacket{Tick: 1000}
+	for i := 0; i < perRoom; i++ {
+		state.Players = append(state.Players, game.PlayerState{ID: i, DisplayName: "Player", Position: game.Vec2{X: 12.5, Y: 40.25}, Health: 100})
+	}
+	for i := 0; i < 8; i++ {
+		state.Pickups = append(state.Pickups, game.Pickup{ID: i, Position: game.Vec2{X: float32(i), Y: 3}})
+	}
+	state.Events = []game.MatchEvent{{Type: "KILL"}}
+	return s, state
+}
+
+// legacyBroadcast is the send path before the recipient index and
+// batching: every session on the server visited for each room, the state
+// encoded and sealed into fresh buffers and written per recipient
+func legacyBroadcast(s *Server, roomID string, state WorldStatePacket) {
+	s.sessions.Range(func(key, value interface{}) bool {
+		sess := value.(*ClientSession)
+		if in, _ := sess.seat(); in == roomID {
+			s.sendSession(sess, state.For(sess.Caps))
+		}
+		return true
+	})
+}
+
+// One op is one tick of 50 rooms × 10 players; tick-budget-% is the share
+// of a 30 TPS tick the sends take on one core
+func BenchmarkBroadcast(b *testing.B) {
+	const rooms, perRoom, tickRate = 50, 10, 30
+	paths := []struct {
+		name string
+		send func(s *Server, roomID string, state WorldStatePacket)
+	}{
+		{"per_session_encode", legacyBroadcast},
+		{"encode_once_batched", (*Server).broadcastState},
+	}
+	for _, path := range paths {
+		b.Run(path.name, func(b *testing.B) {
+			s, state := benchmarkServer(b, rooms, perRoom)
+			ids := make([]string, rooms)
+			for r := range ids {
+				ids[r] = fmt.Sprintf("room-%d", r)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for _, id := range ids {
+					path.send(s, id, state)
+				}
+			}
+			perTick := float64(b.Elapsed().Nanoseconds()) / float64(b.N)
+			b.ReportMetric(perTick/float64(time.Second/tickRate)*100, "tick-budget-%")
+		})
+	}
+}
@@ -0,0 +1,157 @@
+// SKYBATTLE — Go Client
+// The client side of the handshake and session encryption, for tools that
+// play against a server from Go: the load test, and anything else that needs
+// real sessions rather than the server's own test helpers. It speaks the
+// current protocol version with every capability.
+package network
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// connectPadding makes Connect as large as the Challenge it asks for
+const connectPadding = 48
+
+// handshakeRetry is how long the client waits for an answer before resending
+const handshakeRetry = 500 * time.Millisecond
+
+type Client struct {
+	conn  *net.UDPConn
+	crypt *sessionCrypto // set by Authenticate
+	buf   []byte
+}
+
+// Dial opens a socket to a server. local may be nil to let the system pick.
+func Dial(local, server *net.UDPAddr) (*Client, error) {
+	conn, err := net.DialUDP("udp", local, server)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn, buf: make([]byte, 64*1024)}, nil
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// LocalAddr is the client's side of the connection, as the server sees it
+func (c *Client) LocalAddr() *net.UDPAddr {
+	return c.conn.LocalAddr().(*net.UDPAddr)
+}
+
+// Authenticate runs the connect handshake and key exchange, resending lost
+// packets until timeout. Everything sent afterwards is sealed.
+func (c *Client) Authenticate(token string, timeout time.Duration) (AuthAckPacket, error) {
+	deadline := time.Now().Add(timeout)
+
+	var ch ChallengePacket
+	if err := c.request(ConnectPacket{Version: ProtocolVersion, Padding: make([]byte, connectPadding)}, PacketChallenge, &ch, deadline); err != nil {
+		return AuthAckPacket{}, fmt.Errorf("connect: %w", err)
+	}
+
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return AuthAckPacket{}, err
+	}
+	pub := priv.PublicKey().Bytes()
+	auth := AuthPacket{
+		Token:     token,
+		Version:   ProtocolVersion,
+		Caps:      uint32(CapInventory | CapActions),
+		Cookie:    ch.Cookie,
+		PublicKey: pub,
+	}
+	var ack AuthAckPacket
+	if err := c.request(auth, PacketAuthAck, &ack, deadline); err != nil {
+		return ack, fmt.Errorf("auth: %w", err)
+	}
+	if !ack.Success {
+		return ack, fmt.Errorf("auth rejected: %s (%s)", ack.Message, ack.Reason)
+	}
+	if c.crypt, err = deriveSession(priv, ack.PublicKey, pub, ack.PublicKey, ch.Cookie, token, false); err != nil {
+		return ack, err
+	}
+	return ack, nil
+}
+
+// request sends p until a packet of type want arrives or deadline passes
+func (c *Client) request(p ClientPacket, want ServerPacketType, body interface{ Decode([]byte) error }, deadline time.Time) error {
+	for time.Now().Before(deadline) {
+		if err := c.Send(p); err != nil {
+			return err
+		}
+		retry := time.Now().Add(handshakeRetry)
+		if retry.After(deadline) {
+			retry = deadline
+		}
+		for {
+			data, err := c.Read(retry)
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			if ServerPacketType(data[0]) == want {
+				return body.Decode(data[1:])
+			}
+		}
+	}
+	return fmt.Errorf("no %s from the server", want)
+}
+
+// Send encodes a packet, sealed once the session has a key
+func (c *Client) Send(p ClientPacket) error {
+	data, err := p.Encode()
+	if err != nil {
+		return err
+	}
+	return c.send(data)
+}
+
+func (c *Client) send(data []byte) error {
+	if c.crypt != nil {
+		data = c.crypt.Seal(make([]byte, 0, len(data)+secureOverhead), data)
+	}
+	_, err := c.conn.Write(data)
+	return err
+}
+
+// Ping asks for a Pong, which has no body to match it by: keep one in
+// flight to time round trips
+func (c *Client) Ping() error {
+	return c.send([]byte{byte(PacketPing)})
+}
+
+// Read returns the next packet from the server, opened if it was sealed.
+// Sealed packets that fail to open are skipped. The packet is only valid
+// until the next Read.
+func (c *Client) Read(deadline time.Time) ([]byte, error) {
+	c.conn.SetReadDeadline(deadline)
+	for {
+		n, err := c.conn.Read(c.buf)
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			continue
+		}
+		if ServerPacketType(c.buf[0]) != PacketServerSecure {
+			return c.buf[:n], nil
+		}
+		if c.crypt == nil {
+			continue
+		}
+		// Opened in place, over the ciphertext
+		data, err := c.crypt.Open(c.buf[secureHeaderSize:secureHeaderSize], c.buf[:n])
+		if err == nil && len(data) > 0 {
+			return data, nil
+		}
+	}
+}
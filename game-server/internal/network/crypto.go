@@ -0,0 +1,240 @@
+// SKYBATTLE — Session Encryption
+// Auth carries the client's X25519 public key and AuthAck the server's. Both
+// sides derive two AES-256-GCM keys, one per direction, from the shared
+// secret with HKDF. The salt is a hash of the handshake transcript: both
+// public keys, the challenge cookie and the access token the server
+// verified. From then on every packet in either direction is wrapped:
+//
+//	[Secure type][counter, 8 bytes big-endian][AES-GCM(inner packet)][tag, 16 bytes]
+//
+// The counter is the GCM nonce (never reused under a key) and, with the type
+// byte, the additional data. Receivers keep a sliding window of counters
+// seen, so a captured packet cannot be replayed, and a packet from another
+// address or session fails authentication.
+//
+// The key exchange is not authenticated against a server identity: it stops
+// passive reading (position ESP over shared Wi-Fi) and injection by anyone
+// who was not in the path during the handshake. Protocol 3 clients have no
+// key and stay in the clear until that version is dropped.
+package network
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/metrics"
+)
+
+// secureProtocolVersion is the first protocol version that encrypts sessions
+const secureProtocolVersion uint16 = 4
+
+const (
+	secureHeaderSize = 1 + 8
+	secureOverhead   = secureHeaderSize + 16 // header plus GCM tag
+	replayWindowSize = 64
+)
+
+var (
+	errShortPacket = errors.New("secure packet too short")
+	errReplayed    = errors.New("counter replayed or too old")
+	errBadKey      = errors.New("invalid X25519 public key")
+)
+
+// sessionCrypto holds one side's keys for a session
+type sessionCrypto struct {
+	sendType byte
+	send     cipher.AEAD
+	recv     cipher.AEAD
+	sent     atomic.Uint64 // last counter sealed
+
+	mu     sync.Mutex
+	window replayWindow
+}
+
+// serverKeyExchange completes the server side of the exchange for a client
+// public key, returning the server's public key and the session's crypto
+func serverKeyExchange(clientPub, cookie []byte, token string) ([]byte, *sessionCrypto, error) {
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serverPub := priv.PublicKey().Bytes()
+	c, err := deriveSession(priv, clientPub, clientPub, serverPub, cookie, token, true)
+	return serverPub, c, err
+}
+
+// deriveSession computes the direction keys from our private key and the
+// peer's public key. Both sides pass the transcript in the same order.
+func deriveSession(priv *ecdh.PrivateKey, peerPub, clientPub, serverPub, cookie []byte, token string, server bool) (*sessionCrypto, error) {
+	peer, err := ecdh.X25519().NewPublicKey(peerPub)
+	if err != nil {
+		return nil, errBadKey
+	}
+	shared, err := priv.ECDH(peer) // fails on low-order points
+	if err != nil {
+		return nil, errBadKey
+	}
+
+	transcript := sha256.New()
+	transcript.Write([]byte("skybattle-session-v1"))
+	transcript.Write(clientPub)
+	transcript.Write(serverPub)
+	transcript.Write(cookie)
+	transcript.Write([]byte(token))
+	prk := hkdfExtract(transcript.Sum(nil), shared)
+
+	c2s, err := newGCM(hkdfExpand(prk, "c2s"))
+	if err != nil {
+		return nil, err
+	}
+	s2c, err := newGCM(hkdfExpand(prk, "s2c"))
+	if err != nil {
+		return nil, err
+	}
+	if server {
+		return &sessionCrypto{sendType: byte(PacketServerSecure), send: s2c, recv: c2s}, nil
+	}
+	return &sessionCrypto{sendType: byte(PacketSecure), send: c2s, recv: s2c}, nil
+}
+
+// hkdfExtract and hkdfExpand are RFC 5869 HKDF-SHA256, expanded to one block
+func hkdfExtract(salt, ikm []byte) []byte {
+	mac := hmac.New(sha256.New, salt)
+	mac.Write(ikm)
+	return mac.Sum(nil)
+}
+
+func hkdfExpand(prk []byte, info string) []byte {
+	mac := hmac.New(sha256.New, prk)
+	mac.Write([]byte(info))
+	mac.Write([]byte{1})
+	return mac.Sum(nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Seal wraps a plaintext packet, appending to dst
+func (c *sessionCrypto) Seal(dst, packet []byte) []byte {
+	n := c.sent.Add(1)
+	start := len(dst)
+	dst = append(dst, c.sendType)
+	dst = binary.BigEndian.AppendUint64(dst, n)
+	header := dst[start:]
+	nonce := getNonce(n)
+	defer nonces.Put(nonce)
+	return c.send.Seal(dst, nonce[:], packet, header)
+}
+
+// Open authenticates and unwraps a secure packet, including its type byte,
+// and rejects counters already seen
+func (c *sessionCrypto) Open(dst, data []byte) ([]byte, error) {
+	if len(data) < secureOverhead {
+		return nil, errShortPacket
+	}
+	n := binary.BigEndian.Uint64(data[1:secureHeaderSize])
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.window.fresh(n) {
+		return nil, errReplayed
+	}
+	nonce := getNonce(n)
+	defer nonces.Put(nonce)
+	out, err := c.recv.Open(dst, nonce[:], data[secureHeaderSize:], data[:secureHeaderSize])
+	if err != nil {
+		return nil, err
+	}
+	c.window.mark(n)
+	return out, nil
+}
+
+// handleSecure opens a sealed packet from a session and handles what it
+// carries. Packets that fail to open are dropped unanswered.
+func (s *Server) handleSecure(addr *net.UDPAddr, data []byte) {
+	val, ok := s.sessions.Load(addr.String())
+	if !ok {
+		// Kicked, or the server restarted: a sealed packet is always bigger
+		// than this answer
+		s.sendError(addr, PacketSecure, len(data), ReasonNotAuthenticated, "Not authenticated, reconnect.")
+		return
+	}
+	sess := val.(*ClientSession)
+	if sess.crypt == nil {
+		metrics.PacketsDropped.With("secure_without_key").Inc()
+		return
+	}
+	buf := datagrams.Get().(*[maxDatagram]byte)
+	defer datagrams.Put(buf)
+	inner, err := sess.crypt.Open(buf[:0], data)
+	if err != nil || len(inner) == 0 {
+		reason := "bad_seal"
+		if errors.Is(err, errReplayed) {
+			reason = "replayed"
+		}
+		metrics.PacketsDropped.With(reason).Inc()
+		return
+	}
+	packetType := PacketType(inner[0])
+	metrics.PacketsIn.With(packetType.String()).Inc()
+	metrics.BytesIn.With(packetType.String()).Add(len(data))
+	s.handleSessionPacket(addr, packetType, inner[1:])
+}
+
+// nonces are pooled: the AEAD interface makes a stack nonce escape, and
+// that would be an allocation per packet
+var nonces = sync.Pool{New: func() interface{} { return new([12]byte) }}
+
+// getNonce returns the GCM nonce for counter n: four zero bytes, then n
+func getNonce(n uint64) *[12]byte {
+	b := nonces.Get().(*[12]byte)
+	binary.BigEndian.PutUint64(b[4:], n)
+	return b
+}
+
+// replayWindow tracks the highest counter seen and which of the
+// replayWindowSize below it have arrived, so reordered packets are still
+// accepted once
+type replayWindow struct {
+	max  uint64
+	seen uint64 // bit i set: counter max-i has been accepted
+}
+
+func (w *replayWindow) fresh(n uint64) bool {
+	switch {
+	case n == 0:
+		return false
+	case n > w.max:
+		return true
+	case w.max-n >= replayWindowSize:
+		return false
+	}
+	return w.seen&(1<<(w.max-n)) == 0
+}
+
+func (w *replayWindow) mark(n uint64) {
+	if n > w.max {
+		shift := n - w.max
+		if shift >= replayWindowSize {
+			w.seen = 0
+		} else {
+			w.seen <<= shift
+		}
+		w.max = n
+	}
+	w.seen |= 1 << (w.max - n)
+}
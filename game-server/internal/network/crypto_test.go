@@ -0,0 +1,195 @@
+package network
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"testing"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/game"
+)
+
+// keyPair runs both sides of the exchange and returns the client's and the
+// server's crypto
+func keyPair(t testing.TB) (*sessionCrypto, *sessionCrypto) {
+	t.Helper()
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientPub := priv.PublicKey().Bytes()
+	cookie := make([]byte, cookieSize)
+	serverPub, server, err := serverKeyExchange(clientPub, cookie, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := deriveSession(priv, serverPub, clientPub, serverPub, cookie, "token", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, server
+}
+
+func TestSealOpen(t *testing.T) {
+	client, server := keyPair(t)
+
+	input, _ := InputPacket{Sequence: 7, Horizontal: 1}.Encode()
+	sealed := client.Seal(nil, input)
+	if PacketType(sealed[0]) != PacketSecure || len(sealed) != len(input)+secureOverhead {
+		t.Fatalf("sealed %d bytes with type %d", len(sealed), sealed[0])
+	}
+	if bytes.Contains(sealed, input[1:]) {
+		t.Fatal("plaintext visible in sealed packet")
+	}
+	opened, err := server.Open(nil, sealed)
+	if err != nil || !bytes.Equal(opened, input) {
+		t.Fatalf("opened %x (%v), want %x", opened, err, input)
+	}
+
+	pong := server.Seal(nil, []byte{byte(PacketPong)})
+	if ServerPacketType(pong[0]) != PacketServerSecure {
+		t.Fatalf("server sealed with type %d", pong[0])
+	}
+	if opened, err := client.Open(nil, pong); err != nil || !bytes.Equal(opened, []byte{byte(PacketPong)}) {
+		t.Fatalf("opened %x (%v)", opened, err)
+	}
+
+	// Each direction has its own key, so a packet cannot be reflected back
+	if _, err := client.Open(nil, client.Seal(nil, input)); err == nil {
+		t.Fatal("client opened its own packet")
+	}
+}
+
+func TestOpenRejectsTampering(t *testing.T) {
+	client, server := keyPair(t)
+	other, _ := keyPair(t)
+	input, _ := InputPacket{Sequence: 1}.Encode()
+
+	for _, i := range []int{0, 1, secureHeaderSize, secureHeaderSize + 2, -1} {
+		sealed := client.Seal(nil, input)
+		if i < 0 {
+			i = len(sealed) - 1
+		}
+		sealed[i] ^= 0x40
+		if _, err := server.Open(nil, sealed); err == nil {
+			t.Errorf("opened a packet with byte %d flipped", i)
+		}
+	}
+	if _, err := server.Open(nil, other.Seal(nil, input)); err == nil {
+		t.Error("opened a packet from another session")
+	}
+	if _, err := server.Open(nil, make([]byte, secureOverhead-1)); err != errShortPacket {
+		t.Errorf("short packet: %v", err)
+	}
+}
+
+func TestOpenRejectsReplays(t *testing.T) {
+	client, server := keyPair(t)
+	input, _ := InputPacket{}.Encode()
+
+	packets := make([][]byte, replayWindowSize+10)
+	for i := range packets {
+		packets[i] = client.Seal(nil, input)
+	}
+	open := func(i int) error {
+		_, err := server.Open(nil, packets[i])
+		return err
+	}
+
+	if err := open(5); err != nil {
+		t.Fatal(err)
+	}
+	if err := open(5); err != errReplayed {
+		t.Fatalf("replay: %v", err)
+	}
+	// Reordered packets inside the window arrive once
+	if err := open(2); err != nil {
+		t.Fatalf("late packet: %v", err)
+	}
+	if err := open(2); err != errReplayed {
+		t.Fatalf("late replay: %v", err)
+	}
+	// A forged packet does not move the window
+	forged := append([]byte(nil), packets[len(packets)-1]...)
+	forged[len(forged)-1] ^= 1
+	if _, err := server.Open(nil, forged); err == nil {
+		t.Fatal("forged packet opened")
+	}
+	if err := open(3); err != nil {
+		t.Fatalf("window moved by a forged packet: %v", err)
+	}
+	// Past the window, old packets are refused even if never seen
+	if err := open(len(packets) - 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := open(4); err != errReplayed {
+		t.Fatalf("packet older than the window: %v", err)
+	}
+}
+
+func TestKeyExchangeRejectsBadKeys(t *testing.T) {
+	for name, pub := range map[string][]byte{
+		"missing":   nil,
+		"short":     make([]byte, 16),
+		"low order": make([]byte, 32), // all zeros gives an all-zero secret
+	} {
+		if _, _, err := serverKeyExchange(pub, nil, "token"); err != errBadKey {
+			t.Errorf("%s key: %v", name, err)
+		}
+	}
+}
+
+// benchmarkPackets are an input packet and a 10 player world state, the
+// bulk of what each side sends
+func benchmarkPackets(b *testing.B) map[string][]byte {
+	input, err := InputPacket{Sequence: 1 << 20, Horizontal: 0.7, Vertical: -0.3, AimAngleDeg: 135, Firing: true}.Encode()
+	if err != nil {
+		b.Fatal(err)
+	}
+	state := WorldStatePacket{Tick: 1 << 16}
+	for i := 0; i < 10; i++ {
+		state.Players = append(state.Players, game.PlayerState{ID: i, DisplayName: "Player", Position: game.Vec2{X: 12.5, Y: 40.25}, Health: 100})
+	}
+	world, err := state.Encode()
+	if err != nil {
+		b.Fatal(err)
+	}
+	return map[string][]byte{"input": input, "world_state": world}
+}
+
+func BenchmarkSeal(b *testing.B) {
+	_, server := keyPair(b)
+	for name, packet := range benchmarkPackets(b) {
+		b.Run(name, func(b *testing.B) {
+			buf := make([]byte, 0, len(packet)+secureOverhead)
+			b.SetBytes(int64(len(packet)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				buf = server.Seal(buf[:0], packet)
+			}
+			b.ReportMetric(float64(len(packet)), "plain-bytes")
+			b.ReportMetric(float64(len(buf)-len(packet)), "overhead-bytes")
+		})
+	}
+}
+
+func BenchmarkOpen(b *testing.B) {
+	for name, packet := range benchmarkPackets(b) {
+		b.Run(name, func(b *testing.B) {
+			client, server := keyPair(b)
+			sealed := make([][]byte, b.N)
+			for i := range sealed {
+				sealed[i] = client.Seal(nil, packet)
+			}
+			buf := make([]byte, 0, len(packet))
+			b.SetBytes(int64(len(packet)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := server.Open(buf[:0], sealed[i]); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+// SKYBATTLE — Connect Handshake
+// A session is only created for an address that has proved it can receive
+// packets there. The client sends Connect; the server answers with a
+// challenge cookie, an HMAC over the client's address and the time under a
+// key derived from ServerSecret, and keeps no state. The client echoes the
+// cookie in Auth.
+//
+// Until an address has a session, nothing the server sends it is larger
+// than the packet that prompted it, so a spoofed source cannot use the
+// server to amplify traffic, and Ping is only answered for sessions.
+package network
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"net"
+	"time"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/metrics"
+)
+
+const (
+	cookieTTL     = 30 * time.Second
+	cookieMACSize = 16
+	cookieSize    = 8 + cookieMACSize // issue time (unix seconds), then the MAC
+)
+
+// cookieKey derives the cookie HMAC key from the server secret, so a
+// cookie stays good across a restart and on every process sharing the port
+// with SO_REUSEPORT. The secret itself is never the key: cookies are sent
+// to anyone who asks. Without a secret the key is random, and a client
+// caught by a restart mid-handshake just connects again.
+func cookieKey(serverSecret string) []byte {
+	if serverSecret != "" {
+		mac := hmac.New(sha256.New, []byte(serverSecret))
+		mac.Write([]byte("skybattle-cookie-v1"))
+		return mac.Sum(nil)
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic("network: generating cookie key: " + err.Error())
+	}
+	return key
+}
+
+func (s *Server) cookieMAC(addr *net.UDPAddr, issued uint64) []byte {
+	mac := hmac.New(sha256.New, s.cookieKey)
+	var buf [8 + 16 + 2]byte
+	binary.BigEndian.PutUint64(buf[0:8], issued)
+	copy(buf[8:24], addr.IP.To16())
+	binary.BigEndian.PutUint16(buf[24:26], uint16(addr.Port))
+	mac.Write(buf[:])
+	return mac.Sum(nil)[:cookieMACSize]
+}
+
+// issueCookie binds a cookie to addr and the current time
+func (s *Server) issueCookie(addr *net.UDPAddr, now time.Time) []byte {
+	cookie := make([]byte, 8, cookieSize)
+	issued := uint64(now.Unix())
+	binary.BigEndian.PutUint64(cookie, issued)
+	return append(cookie, s.cookieMAC(addr, issued)...)
+}
+
+// validCookie checks a cookie was issued to addr within cookieTTL
+func (s *Server) validCookie(addr *net.UDPAddr, cookie []byte, now time.Time) bool {
+	if len(cookie) != cookieSize {
+		return false
+	}
+	issued := binary.BigEndian.Uint64(cookie[:8])
+	age := now.Unix() - int64(issued)
+	if age < 0 || age > int64(cookieTTL/time.Second) {
+		return false
+	}
+	return hmac.Equal(cookie[8:], s.cookieMAC(addr, issued))
+}
+
+func (s *Server) handleConnect(addr *net.UDPAddr, payload []byte) {
+	var p ConnectPacket
+	if err := p.Decode(payload); err != nil {
+		metrics.DecodeErrors.With(PacketConnect.String()).Inc()
+		return
+	}
+	s.sendUnverified(addr, len(payload)+1, ChallengePacket{Cookie: s.issueCookie(addr, time.Now())})
+}
+
+// sendUnverified replies to an address without a session, but only if the
+// reply is no larger than the request it answers
+func (s *Server) sendUnverified(addr *net.UDPAddr, requestLen int, p ServerPacket) {
+	data, err := p.Encode()
+	if err != nil {
+		return
+	}
+	if len(data) > requestLen {
+		metrics.PacketsDropped.With("oversized_reply").Inc()
+		return
+	}
+	s.sendTo(addr, data)
+}
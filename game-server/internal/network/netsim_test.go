@@ -0,0 +1,65 @@
+package network
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/auth"
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/config"
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/room"
+)
+
+// A client behind a simulated slow network still gets through the
+// handshake into its match, and its round trips show the latency
+func TestPlayingThroughSimulatedNetwork(t *testing.T) {
+	const latency, jitter = 40 * time.Millisecond, 10 * time.Millisecond
+
+	s := NewServer(&config.Config{Profile: config.ProfileCloud, TickRate: 30, MaxRoomsPerServer: 2, NetSim: "latency=40ms,jitter=10ms", JWTAccessSecret: testJWTSecret})
+	if err := s.listen(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	run(t, s)
+	res := room.Reservation{MatchID: "match-1", GameMode: "FFA", MapID: "outpost", UserIDs: []string{"slow"}}
+	if _, _, err := s.manager.Reserve(res); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Dial(nil, &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: s.conn.LocalAddr().(*net.UDPAddr).Port})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if _, err := c.Authenticate(auth.Issue([]byte(testJWTSecret), auth.Claims{UserID: "slow"}), 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Send(JoinPacket{MatchID: "match-1"}); err != nil {
+		t.Fatal(err)
+	}
+	readUntil(t, c, PacketMatchInit)
+
+	start := time.Now()
+	if err := c.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	readUntil(t, c, PacketPong)
+	if rtt := time.Since(start); rtt < 2*(latency-jitter) {
+		t.Errorf("round trip %s, want at least %s", rtt, 2*(latency-jitter))
+	}
+	readUntil(t, c, PacketWorldState)
+}
+
+func readUntil(t *testing.T, c *Client, want ServerPacketType) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		data, err := c.Read(deadline)
+		if err != nil {
+			t.Fatalf("no %s: %v", want, err)
+		}
+		if ServerPacketType(data[0]) == want {
+			return
+		}
+	}
+}
@@ -0,0 +1,340 @@
+// Code generated by protogen from protocol.json. DO NOT EDIT.
+
+package network
+
+import (
+	"bytes"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/game"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// ProtocolVersion is bumped whenever a packet changes shape in a way an
+// older client would misparse
+const ProtocolVersion uint16 = 4
+
+// Capabilities are optional protocol features, negotiated per session
+type Capabilities uint32
+
+const (
+	CapInventory Capabilities = 1 << 0 // weapon slots, switching and dropped weapon pickups
+	CapActions   Capabilities = 1 << 1 // melee, grenades and mines, and their events
+)
+
+const (
+	ReasonAuthFailed       = "AUTH_FAILED"         // the token was rejected
+	ReasonTokenExpired     = "TOKEN_EXPIRED"       // the token has expired; refresh it and authenticate again
+	ReasonClientOutdated   = "CLIENT_OUTDATED"     // the client must update to keep playing
+	ReasonServerOutdated   = "SERVER_OUTDATED"     // the client is newer than the server
+	ReasonBadCookie        = "BAD_COOKIE"          // the challenge cookie is missing, expired or for another address; Connect again
+	ReasonKeyExchange      = "KEY_EXCHANGE_FAILED" // the auth packet had no usable X25519 public key
+	ReasonRoomNotFound     = "ROOM_NOT_FOUND"      // no room has the requested match id
+	ReasonNoOpenRoom       = "NO_OPEN_ROOM"        // every room is reserved for a matched game
+	ReasonRoomFull         = "ROOM_FULL"           // the room has no free slot
+	ReasonMatchInProgress  = "MATCH_IN_PROGRESS"   // the match started without you
+	ReasonNotOnRoster      = "NOT_ON_ROSTER"       // the match is reserved for other players
+	ReasonAlreadyInMatch   = "ALREADY_IN_MATCH"    // the account already holds a seat in the match
+	ReasonDraining         = "SERVER_DRAINING"     // the server takes no new players before shutting down
+	ReasonJoinFailed       = "JOIN_FAILED"         // any other join failure
+	ReasonKicked           = "KICKED"              // removed by an admin
+	ReasonShutdown         = "SERVER_SHUTDOWN"     // the server is going away
+	ReasonMalformedPacket  = "MALFORMED_PACKET"    // the packet body did not decode
+	ReasonNotAuthenticated = "NOT_AUTHENTICATED"   // send Auth first, or again after a kick
+	ReasonNotInMatch       = "NOT_IN_MATCH"        // input before a successful join
+	ReasonMatchEnded       = "MATCH_ENDED"         // the room the session was in is gone
+)
+
+// ── Client to Server Packets ──────────────────────────────────────────────────
+
+type PacketType uint8
+
+const (
+	PacketAuth        PacketType = 1
+	PacketInput       PacketType = 2
+	PacketPing        PacketType = 3
+	PacketRequestJoin PacketType = 4
+	PacketLobbyReady  PacketType = 5
+	PacketConnect     PacketType = 6
+	PacketSecure      PacketType = 7
+)
+
+func (t PacketType) String() string {
+	switch t {
+	case PacketAuth:
+		return "auth"
+	case PacketInput:
+		return "input"
+	case PacketPing:
+		return "ping"
+	case PacketRequestJoin:
+		return "join"
+	case PacketLobbyReady:
+		return "lobby_ready"
+	case PacketConnect:
+		return "connect"
+	case PacketSecure:
+		return "secure"
+	}
+	return "unknown"
+}
+
+// ── Server to Client Packets ──────────────────────────────────────────────────
+
+type ServerPacketType uint8
+
+const (
+	PacketWorldState   ServerPacketType = 10
+	PacketAuthAck      ServerPacketType = 11
+	PacketMatchInit    ServerPacketType = 12
+	PacketPong         ServerPacketType = 13
+	PacketLobbyState   ServerPacketType = 14
+	PacketJoinReject   ServerPacketType = 15
+	PacketKick         ServerPacketType = 16
+	PacketShutdown     ServerPacketType = 17
+	PacketError        ServerPacketType = 18
+	PacketChallenge    ServerPacketType = 19
+	PacketServerSecure ServerPacketType = 20
+)
+
+func (t ServerPacketType) String() string {
+	switch t {
+	case PacketWorldState:
+		return "world_state"
+	case PacketAuthAck:
+		return "auth_ack"
+	case PacketMatchInit:
+		return "match_init"
+	case PacketPong:
+		return "pong"
+	case PacketLobbyState:
+		return "lobby_state"
+	case PacketJoinReject:
+		return "join_reject"
+	case PacketKick:
+		return "kick"
+	case PacketShutdown:
+		return "shutdown"
+	case PacketError:
+		return "error"
+	case PacketChallenge:
+		return "challenge"
+	case PacketServerSecure:
+		return "secure"
+	}
+	return "unknown"
+}
+
+// ── Packet Bodies ────────────────────────────────────────────────────────────
+
+type AuthPacket struct {
+	Token     string `msgpack:"token"`
+	Version   uint16 `msgpack:"ver"`    // ProtocolVersion the client was built against; 0 from clients older than versioning
+	Caps      uint32 `msgpack:"caps"`   // Capabilities the client supports
+	Cookie    []byte `msgpack:"cookie"` // echoed from ChallengePacket
+	PublicKey []byte `msgpack:"pub"`    // client's X25519 public key for the session
+}
+
+func (p AuthPacket) Encode() ([]byte, error) { return encodePacket(byte(PacketAuth), p) }
+
+func (p AuthPacket) EncodeTo(buf *bytes.Buffer) error { return writePacket(buf, byte(PacketAuth), p) }
+
+func (p *AuthPacket) Decode(payload []byte) error { return msgpack.Unmarshal(payload, p) }
+
+type ConnectPacket struct {
+	Version uint16 `msgpack:"ver"`
+	Padding []byte `msgpack:"pad"` // zeros; the challenge is only sent if it is no larger than this packet, 64 bytes is enough
+}
+
+func (p ConnectPacket) Encode() ([]byte, error) { return encodePacket(byte(PacketConnect), p) }
+
+func (p ConnectPacket) EncodeTo(buf *bytes.Buffer) error {
+	return writePacket(buf, byte(PacketConnect), p)
+}
+
+func (p *ConnectPacket) Decode(payload []byte) error { return msgpack.Unmarshal(payload, p) }
+
+type JoinPacket struct {
+	MatchID string `msgpack:"mid"`
+}
+
+func (p JoinPacket) Encode() ([]byte, error) { return encodePacket(byte(PacketRequestJoin), p) }
+
+func (p JoinPacket) EncodeTo(buf *bytes.Buffer) error {
+	return writePacket(buf, byte(PacketRequestJoin), p)
+}
+
+func (p *JoinPacket) Decode(payload []byte) error { return msgpack.Unmarshal(payload, p) }
+
+type InputPacket struct {
+	Sequence    uint32  `msgpack:"seq"`
+	Horizontal  float32 `msgpack:"h"`
+	Vertical    float32 `msgpack:"v"`
+	AimAngleDeg float32 `msgpack:"aim"`
+	IsFlying    bool    `msgpack:"fly"`
+	Firing      bool    `msgpack:"fire"`
+	WeaponID    uint8   `msgpack:"wpn"`  // active weapon as the client sees it; the server owns the inventory
+	Switch      bool    `msgpack:"sw"`   // switch primary/secondary
+	Interact    bool    `msgpack:"use"`  // swap the active weapon for the one on the ground
+	Melee       bool    `msgpack:"mel"`  // melee, independent of the held gun
+	Throw       bool    `msgpack:"thr"`  // throw a grenade
+	PlaceMine   bool    `msgpack:"mine"` // drop a proximity mine
+}
+
+func (p InputPacket) Encode() ([]byte, error) { return encodePacket(byte(PacketInput), p) }
+
+func (p InputPacket) EncodeTo(buf *bytes.Buffer) error { return writePacket(buf, byte(PacketInput), p) }
+
+func (p *InputPacket) Decode(payload []byte) error { return msgpack.Unmarshal(payload, p) }
+
+type WorldStatePacket struct {
+	Tick    int                `msgpack:"tick"`
+	Players []game.PlayerState `msgpack:"players"`
+	Pickups []game.Pickup      `msgpack:"pickups"`
+	Events  []game.MatchEvent  `msgpack:"events"` // events since the previous world state
+}
+
+func (p WorldStatePacket) Encode() ([]byte, error) { return encodePacket(byte(PacketWorldState), p) }
+
+func (p WorldStatePacket) EncodeTo(buf *bytes.Buffer) error {
+	return writePacket(buf, byte(PacketWorldState), p)
+}
+
+func (p *WorldStatePacket) Decode(payload []byte) error { return msgpack.Unmarshal(payload, p) }
+
+type AuthAckPacket struct {
+	Success    bool   `msgpack:"ok"`
+	PlayerID   int    `msgpack:"id"`
+	Message    string `msgpack:"msg"`
+	Reason     string `msgpack:"reason"` // machine-readable rejection reason, e.g. CLIENT_OUTDATED
+	Version    uint16 `msgpack:"ver"`    // version the session speaks, or the server's own on rejection
+	MinVersion uint16 `msgpack:"minVer"` // oldest version the server still accepts
+	Caps       uint32 `msgpack:"caps"`   // capabilities negotiated for the session
+	PublicKey  []byte `msgpack:"pub"`    // server's X25519 public key for the session
+}
+
+func (p AuthAckPacket) Encode() ([]byte, error) { return encodePacket(byte(PacketAuthAck), p) }
+
+func (p AuthAckPacket) EncodeTo(buf *bytes.Buffer) error {
+	return writePacket(buf, byte(PacketAuthAck), p)
+}
+
+func (p *AuthAckPacket) Decode(payload []byte) error { return msgpack.Unmarshal(payload, p) }
+
+type MatchInitPacket struct {
+	MatchID  string      `msgpack:"mid"`
+	MapID    string      `msgpack:"map"`
+	TickRate int         `msgpack:"rate"`
+	Spawns   []game.Vec2 `msgpack:"spawns"`
+}
+
+func (p MatchInitPacket) Encode() ([]byte, error) { return encodePacket(byte(PacketMatchInit), p) }
+
+func (p MatchInitPacket) EncodeTo(buf *bytes.Buffer) error {
+	return writePacket(buf, byte(PacketMatchInit), p)
+}
+
+func (p *MatchInitPacket) Decode(payload []byte) error { return msgpack.Unmarshal(payload, p) }
+
+type LobbyStatePacket struct {
+	MatchID  string            `msgpack:"mid"`
+	MapID    string            `msgpack:"map"`
+	Players  []LobbyPlayerData `msgpack:"players"`
+	AllReady bool              `msgpack:"allReady"`
+}
+
+func (p LobbyStatePacket) Encode() ([]byte, error) { return encodePacket(byte(PacketLobbyState), p) }
+
+func (p LobbyStatePacket) EncodeTo(buf *bytes.Buffer) error {
+	return writePacket(buf, byte(PacketLobbyState), p)
+}
+
+func (p *LobbyStatePacket) Decode(payload []byte) error { return msgpack.Unmarshal(payload, p) }
+
+type LobbyPlayerData struct {
+	ID    int    `msgpack:"id"`
+	Name  string `msgpack:"name"`
+	Ready bool   `msgpack:"ready"`
+	Team  int    `msgpack:"team"` // 0=RED, 1=BLUE
+}
+
+type JoinRejectPacket struct {
+	MatchID string `msgpack:"mid"` // the match asked for, empty for any open room
+	Reason  string `msgpack:"reason"`
+	Message string `msgpack:"msg"`
+}
+
+func (p JoinRejectPacket) Encode() ([]byte, error) { return encodePacket(byte(PacketJoinReject), p) }
+
+func (p JoinRejectPacket) EncodeTo(buf *bytes.Buffer) error {
+	return writePacket(buf, byte(PacketJoinReject), p)
+}
+
+func (p *JoinRejectPacket) Decode(payload []byte) error { return msgpack.Unmarshal(payload, p) }
+
+type KickPacket struct {
+	Reason  string `msgpack:"reason"`
+	Message string `msgpack:"msg"`
+}
+
+func (p KickPacket) Encode() ([]byte, error) { return encodePacket(byte(PacketKick), p) }
+
+func (p KickPacket) EncodeTo(buf *bytes.Buffer) error { return writePacket(buf, byte(PacketKick), p) }
+
+func (p *KickPacket) Decode(payload []byte) error { return msgpack.Unmarshal(payload, p) }
+
+type ShutdownPacket struct {
+	Reason   string `msgpack:"reason"`
+	Message  string `msgpack:"msg"`
+	GraceSec int    `msgpack:"grace"` // how long running matches may still play on
+}
+
+func (p ShutdownPacket) Encode() ([]byte, error) { return encodePacket(byte(PacketShutdown), p) }
+
+func (p ShutdownPacket) EncodeTo(buf *bytes.Buffer) error {
+	return writePacket(buf, byte(PacketShutdown), p)
+}
+
+func (p *ShutdownPacket) Decode(payload []byte) error { return msgpack.Unmarshal(payload, p) }
+
+type ErrorPacket struct {
+	Packet  uint8  `msgpack:"pkt"` // type of the client packet that failed
+	Reason  string `msgpack:"reason"`
+	Message string `msgpack:"msg"`
+}
+
+func (p ErrorPacket) Encode() ([]byte, error) { return encodePacket(byte(PacketError), p) }
+
+func (p ErrorPacket) EncodeTo(buf *bytes.Buffer) error { return writePacket(buf, byte(PacketError), p) }
+
+func (p *ErrorPacket) Decode(payload []byte) error { return msgpack.Unmarshal(payload, p) }
+
+type ChallengePacket struct {
+	Cookie []byte `msgpack:"cookie"` // echo in AuthPacket within 30 seconds
+}
+
+func (p ChallengePacket) Encode() ([]byte, error) { return encodePacket(byte(PacketChallenge), p) }
+
+func (p ChallengePacket) EncodeTo(buf *bytes.Buffer) error {
+	return writePacket(buf, byte(PacketChallenge), p)
+}
+
+func (p *ChallengePacket) Decode(payload []byte) error { return msgpack.Unmarshal(payload, p) }
+
+// encodePacket prefixes a msgpack body with its packet type
+func encodePacket(t byte, body interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writePacket(&buf, t, body); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writePacket appends a packet to buf with a pooled encoder, so callers
+// that reuse buf encode without allocating
+func writePacket(buf *bytes.Buffer, t byte, body interface{}) error {
+	buf.WriteByte(t)
+	enc := msgpack.GetEncoder()
+	defer msgpack.PutEncoder(enc)
+	enc.Reset(buf)
+	return enc.Encode(body)
+}
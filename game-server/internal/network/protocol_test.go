@@ -0,0 +1,109 @@
+package network
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/game"
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/protogen"
+)
+
+const clientProtocolPath = "../../../unity-client/Assets/Scripts/Networking/NetworkProtocol.cs"
+
+func loadSchema(t *testing.T) *protogen.Schema {
+	t.Helper()
+	s, err := protogen.Load("protocol.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestGeneratedProtocolIsCurrent(t *testing.T) {
+	s := loadSchema(t)
+	goSrc, err := protogen.Go(s, "protocol.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string][]byte{
+		"protocol_gen.go":  goSrc,
+		clientProtocolPath: protogen.CSharp(s, "protocol.json"),
+	} {
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s does not match protocol.json; run go generate ./internal/network", path)
+		}
+	}
+}
+
+// The game types sent inside packets are hand-written, so check their
+// msgpack keys and kinds against the schema the client is generated from
+func TestGameTypesMatchSchema(t *testing.T) {
+	types := map[string]reflect.Type{
+		"game.Vec2":        reflect.TypeOf(game.Vec2{}),
+		"game.PlayerState": reflect.TypeOf(game.PlayerState{}),
+		"game.Pickup":      reflect.TypeOf(game.Pickup{}),
+		"game.MatchEvent":  reflect.TypeOf(game.MatchEvent{}),
+	}
+	kinds := map[string]reflect.Kind{
+		"bool": reflect.Bool, "string": reflect.String, "int": reflect.Int,
+		"uint8": reflect.Uint8, "uint16": reflect.Uint16, "uint32": reflect.Uint32, "float32": reflect.Float32,
+	}
+
+	s := loadSchema(t)
+	for _, st := range s.Structs {
+		if !strings.Contains(st.Go, ".") {
+			continue
+		}
+		typ, ok := types[st.Go]
+		if !ok {
+			t.Errorf("%s: no reflect.Type registered for %s", st.Name, st.Go)
+			continue
+		}
+		sent := map[string]reflect.StructField{}
+		for i := 0; i < typ.NumField(); i++ {
+			f := typ.Field(i)
+			if key := f.Tag.Get("msgpack"); f.IsExported() && key != "-" {
+				if key == "" {
+					key = f.Name
+				}
+				sent[key] = f
+			}
+		}
+		for _, f := range st.Fields {
+			gf, ok := sent[f.Key]
+			if !ok {
+				t.Errorf("%s: schema field %q is not sent by %s", st.Name, f.Key, st.Go)
+				continue
+			}
+			delete(sent, f.Key)
+			if kind, ok := kinds[f.Type]; ok && gf.Type.Kind() != kind {
+				t.Errorf("%s.%s: %s in Go, %s in the schema", st.Name, f.Key, gf.Type.Kind(), f.Type)
+			}
+		}
+		for key := range sent {
+			t.Errorf("%s: %s sends %q, which is not in the schema", st.Name, st.Go, key)
+		}
+	}
+}
+
+func TestPacketRoundTrip(t *testing.T) {
+	in := AuthAckPacket{Success: true, PlayerID: 3, Version: ProtocolVersion, Caps: uint32(CapActions), PublicKey: []byte{1, 2, 3}}
+	data, err := in.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ServerPacketType(data[0]) != PacketAuthAck {
+		t.Fatalf("type byte %d", data[0])
+	}
+	var out AuthAckPacket
+	if err := out.Decode(data[1:]); err != nil || !reflect.DeepEqual(out, in) {
+		t.Fatalf("decoded %+v (%v), want %+v", out, err, in)
+	}
+}
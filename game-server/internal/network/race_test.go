@@ -0,0 +1,141 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/auth"
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/config"
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/room"
+)
+
+// Clients joining two matches at once, playing through several readers and
+// getting world state while the admin side kicks and inspects, with every
+// room ticking on its own goroutine. Meant for go test -race.
+func TestConcurrentJoinsInputsAndBroadcasts(t *testing.T) {
+	const matches, perMatch, inputs = 2, 6, 40
+
+	s := NewServer(&config.Config{Profile: config.ProfileCloud, TickRate: 60, MaxRoomsPerServer: 4, UDPReaders: 4, JWTAccessSecret: testJWTSecret})
+	// Every client shares the loopback address
+	s.handshakeLimit = newIPLimiter(1000, 1000)
+	s.packetLimit = newIPLimiter(100000, 100000)
+	if err := s.listen(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	run(t, s)
+	server := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: s.conn.LocalAddr().(*net.UDPAddr).Port}
+
+	type player struct{ matchID, userID string }
+	var players []player
+	for m := 0; m < matches; m++ {
+		res := room.Reservation{MatchID: fmt.Sprintf("match-%d", m), GameMode: "FFA", MapID: "outpost"}
+		for p := 0; p < perMatch; p++ {
+			userID := fmt.Sprintf("user-%d-%d", m, p)
+			res.UserIDs = append(res.UserIDs, userID)
+			players = append(players, player{res.MatchID, userID})
+		}
+		if _, _, err := s.manager.Reserve(res); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(players))
+	for i, p := range players {
+		wg.Add(1)
+		go func(kicked bool, p player) {
+			defer wg.Done()
+			if err := playRace(server, p.matchID, p.userID, inputs, kicked); err != nil {
+				errs <- fmt.Errorf("%s: %w", p.userID, err)
+			}
+		}(i == 0, p)
+	}
+
+	// The admin API meanwhile: kick the first player once it is in, and
+	// keep reading every room
+	stop := make(chan struct{})
+	adminDone := make(chan struct{})
+	go func() {
+		defer close(adminDone)
+		kicked := false
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			for _, r := range s.manager.ListRooms() {
+				r.Info()
+				for _, ps := range r.PlayerSummaries() {
+					if ps.UserID == players[0].userID && !kicked {
+						kicked = s.KickPlayer(r.ID, ps.ID, "race test")
+					}
+				}
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	wg.Wait()
+	close(stop)
+	<-adminDone
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+// playRace joins a match and sends inputs until world state shows the last
+// one applied, or, for the kicked player, until the kick arrives
+func playRace(server *net.UDPAddr, matchID, userID string, inputs uint32, kicked bool) error {
+	c, err := Dial(nil, server)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if _, err := c.Authenticate(auth.Issue([]byte(testJWTSecret), auth.Claims{UserID: userID}), 5*time.Second); err != nil {
+		return err
+	}
+	if err := c.Send(JoinPacket{MatchID: matchID}); err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	seq := uint32(0)
+	for {
+		data, err := c.Read(deadline)
+		if err != nil {
+			return fmt.Errorf("after input %d: %w", seq, err)
+		}
+		switch ServerPacketType(data[0]) {
+		case PacketJoinReject:
+			return fmt.Errorf("join rejected")
+		case PacketKick:
+			if kicked {
+				return nil
+			}
+			return fmt.Errorf("kicked")
+		case PacketWorldState:
+			var state WorldStatePacket
+			if err := state.Decode(data[1:]); err != nil {
+				return err
+			}
+			for i := range state.Players {
+				if p := &state.Players[i]; p.UserID == userID && p.LastInputSeq == inputs && !kicked {
+					return nil
+				}
+			}
+			// One input per world state until the last is in
+			if seq < inputs {
+				seq++
+				if err := c.Send(InputPacket{Sequence: seq, Horizontal: 1, Firing: seq%4 == 0}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// SKYBATTLE — Per-IP Rate Limits
+// Token buckets keyed by source IP. Several players can share an IP behind
+// NAT, so the packet limit leaves room for a household at full input rate;
+// the handshake limit is much tighter since a client connects once.
+package network
+
+import (
+	"net/netip"
+	"sync"
+	"time"
+)
+
+const (
+	packetRatePerIP     = 300 // packets/s, about five clients sending 60 Hz input
+	packetBurstPerIP    = 600
+	handshakeRatePerIP  = 4 // Connect and Auth packets/s
+	handshakeBurstPerIP = 10
+)
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type ipLimiter struct {
+	mu      sync.Mutex
+	rate    float64
+	burst   float64
+	buckets map[netip.Addr]*bucket
+}
+
+func newIPLimiter(rate, burst float64) *ipLimiter {
+	return &ipLimiter{rate: rate, burst: burst, buckets: map[netip.Addr]*bucket{}}
+}
+
+// Allow takes a token from ip's bucket if it has one
+func (l *ipLimiter) Allow(ip netip.Addr, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.buckets[ip]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[ip] = b
+	}
+	b.tokens += now.Sub(b.last).Seconds() * l.rate
+	if b.tokens > l.burst {
+		b.tokens = l.burst
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Prune forgets buckets that have refilled, so idle IPs cost nothing
+func (l *ipLimiter) Prune(now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for ip, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, ip)
+		}
+	}
+}
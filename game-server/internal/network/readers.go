@@ -0,0 +1,166 @@
+// SKYBATTLE — Packet Readers
+// Several goroutines read the game port so one slow packet (a join waiting
+// on a room's lock, a key exchange) does not hold up everyone else's. On
+// Linux each reader has its own socket bound with SO_REUSEPORT and the
+// kernel keeps a client on one of them, so a client's packets are handled
+// in order; elsewhere the readers share one socket.
+//
+// With a simulated network (cfg.NetSim, see package netsim) there is one
+// socket, wrapped so packets are delayed and dropped on their way through.
+//
+// Handlers finish with a packet before its reader reads the next one:
+// inputs are decoded and queued on their room by value (see
+// room.QueueInput), and sealed packets are opened into pooled buffers, so
+// nothing a reader receives is kept past its handler. Received datagrams
+// are therefore not copied into pooled buffers: each reader reuses its own
+// buffer. A handler that hands a packet to another goroutine would need
+// that copy.
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/netsim"
+)
+
+const (
+	maxDatagram       = 2048    // larger than any client packet
+	socketReceiveSize = 4 << 20 // per socket; the kernel caps it at net.core.rmem_max
+
+	// A reader waits after a failed read, doubling up to the max while
+	// reads keep failing, so a broken socket can't spin a core or flood the log
+	readBackoff    = time.Millisecond
+	readBackoffMax = time.Second
+)
+
+// gamePort is the game socket as the server uses it: a *net.UDPConn, or a
+// netsim.Conn wrapping one
+type gamePort interface {
+	ReadFromUDP(b []byte) (int, *net.UDPAddr, error)
+	WriteToUDP(b []byte, addr *net.UDPAddr) (int, error)
+	LocalAddr() net.Addr
+	Close() error
+}
+
+// datagrams holds buffers for opened packets
+var datagrams = sync.Pool{New: func() interface{} { return new([maxDatagram]byte) }}
+
+// readers is how many goroutines read the game port
+func (s *Server) readers() int {
+	if s.cfg.UDPReaders > 0 {
+		return s.cfg.UDPReaders
+	}
+	return runtime.NumCPU()
+}
+
+// listen opens the game port, one socket per reader where the platform
+// balances between them
+func (s *Server) listen(ctx context.Context) error {
+	var policy netsim.Policy
+	if s.cfg.NetSim != "" {
+		var err error
+		if policy, err = netsim.ParsePolicy(s.cfg.NetSim); err != nil {
+			return fmt.Errorf("netsim: %w", err)
+		}
+	}
+
+	n := 1
+	if reusePortSupported && s.cfg.NetSim == "" {
+		n = s.readers()
+	}
+	lc := net.ListenConfig{}
+	if n > 1 {
+		lc.Control = reusePort
+	}
+
+	port := s.cfg.Port
+	for i := 0; i < n; i++ {
+		pc, err := lc.ListenPacket(ctx, "udp", fmt.Sprintf(":%d", port))
+		if err != nil {
+			for _, conn := range s.conns {
+				conn.Close()
+			}
+			s.conns = nil
+			return err
+		}
+		conn := pc.(*net.UDPConn)
+		if err := conn.SetReadBuffer(socketReceiveSize); err != nil {
+			log.Printf("UDP receive buffer: %v", err)
+		}
+		s.conns = append(s.conns, conn)
+		port = conn.LocalAddr().(*net.UDPAddr).Port // the one picked for port 0
+	}
+	if s.cfg.NetSim != "" {
+		s.conns[0] = netsim.Wrap(s.conns[0].(*net.UDPConn), policy)
+		log.Printf("🐢 Simulating network conditions: %s", policy)
+	}
+	s.conn = s.conns[0] // replies all go out through the first
+	return nil
+}
+
+// serve runs the readers until ctx is cancelled, then closes the sockets
+func (s *Server) serve(ctx context.Context) error {
+	conns := s.conns
+	if len(conns) == 0 {
+		conns = []gamePort{s.conn}
+	}
+	closeAll := func() {
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}
+	defer closeAll()
+
+	// Unblock ReadFromUDP on cancellation
+	stop := context.AfterFunc(ctx, closeAll)
+	defer stop()
+
+	readers := max(s.readers(), len(conns))
+	var wg sync.WaitGroup
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func(conn gamePort) {
+			defer wg.Done()
+			s.read(ctx, conn)
+		}(conns[i%len(conns)])
+	}
+	wg.Wait()
+	log.Printf("UDP listener closed")
+	return nil
+}
+
+// read handles packets from conn until ctx is cancelled or conn is closed
+func (s *Server) read(ctx context.Context, conn gamePort) {
+	buf := make([]byte, maxDatagram)
+	backoff := readBackoff
+	for {
+		n, clientAddr, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			if errors.Is(err, net.ErrClosed) {
+				log.Printf("UDP socket closed: %v", err)
+				return
+			}
+			log.Printf("Error reading from UDP (retrying in %s): %v", backoff, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(2*backoff, readBackoffMax)
+			continue
+		}
+		backoff = readBackoff
+
+		s.handlePacket(clientAddr, buf[:n])
+	}
+}
@@ -0,0 +1,120 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/auth"
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/config"
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/room"
+)
+
+// Clients playing through several readers reach the room's simulation: the
+// last input each sent shows up as its player's sequence in world state
+func TestInputsThroughConcurrentReaders(t *testing.T) {
+	const readers, clients, inputs = 4, 5, 50 // 5 clients keeps one IP under the handshake limit
+
+	s := NewServer(&config.Config{Profile: config.ProfileCloud, TickRate: 30, MaxRoomsPerServer: 4, UDPReaders: readers, JWTAccessSecret: testJWTSecret})
+	if err := s.listen(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if reusePortSupported && len(s.conns) != readers {
+		t.Fatalf("%d sockets for %d readers", len(s.conns), readers)
+	}
+	run(t, s)
+
+	// A matched room, so players arriving after the first can still join
+	tokens := make([]string, clients)
+	var res room.Reservation
+	for i := range tokens {
+		userID := fmt.Sprintf("user-%d", i)
+		tokens[i] = auth.Issue([]byte(testJWTSecret), auth.Claims{UserID: userID})
+		res.UserIDs = append(res.UserIDs, userID)
+	}
+	res.MatchID, res.GameMode, res.MapID, res.TTL = "match-1", "FFA", "outpost", time.Minute
+	if _, _, err := s.manager.Reserve(res); err != nil {
+		t.Fatal(err)
+	}
+
+	cs := make([]*testClient, clients)
+	ids := make([]int, clients)
+	for i := range cs {
+		cs[i] = dial(t, s)
+		cs[i].auth(tokens[i])
+		cs[i].send(JoinPacket{MatchID: "match-1"})
+		cs[i].expect(PacketMatchInit, &MatchInitPacket{})
+		_, ids[i] = cs[i].session(s).seat()
+	}
+
+	var wg sync.WaitGroup
+	for _, c := range cs {
+		wg.Add(1)
+		go func(c *testClient) {
+			defer wg.Done()
+			for seq := uint32(1); seq <= inputs; seq++ {
+				c.send(InputPacket{Sequence: seq})
+				time.Sleep(time.Millisecond)
+			}
+		}(c)
+	}
+	wg.Wait()
+
+	for i, c := range cs {
+		deadline := time.Now().Add(2 * time.Second)
+		for seen := false; !seen; {
+			data, err := c.read(deadline)
+			if err != nil {
+				t.Fatalf("client %d: input %d never applied: %v", i, inputs, err)
+			}
+			var state WorldStatePacket
+			if ServerPacketType(data[0]) != PacketWorldState || state.Decode(data[1:]) != nil {
+				continue
+			}
+			for j := range state.Players {
+				p := &state.Players[j]
+				seen = seen || (p.ID == ids[i] && p.LastInputSeq == inputs)
+			}
+		}
+	}
+}
+
+// failingPort fails every read, then reports itself closed after closeAfter
+type failingPort struct {
+	gamePort
+	reads      int
+	closeAfter int
+}
+
+func (p *failingPort) ReadFromUDP([]byte) (int, *net.UDPAddr, error) {
+	p.reads++
+	if p.reads > p.closeAfter {
+		return 0, nil, net.ErrClosed
+	}
+	return 0, nil, errors.New("read failed")
+}
+
+// A reader backs off while reads fail and stops once the socket is closed
+func TestReaderBacksOffOnErrors(t *testing.T) {
+	s := NewServer(&config.Config{Profile: config.ProfileCloud})
+	port := &failingPort{closeAfter: 5}
+	done := make(chan struct{})
+	start := time.Now()
+	go func() {
+		s.read(context.Background(), port)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("reader still running on a closed socket")
+	}
+	// 1+2+4+8+16ms between the five failures
+	if d := time.Since(start); d < 31*time.Millisecond || port.reads != 6 {
+		t.Fatalf("%d reads in %s", port.reads, d)
+	}
+}
@@ -0,0 +1,117 @@
+// SKYBATTLE — Room Recipient Index
+// Sessions by room, so a room's broadcast visits its own players instead
+// of every session on the server. Each room's list is replaced, never
+// modified, when someone joins or leaves, so a broadcast reads it under a
+// read lock and keeps using it after the lock is released.
+package network
+
+import (
+	"log"
+	"sync"
+)
+
+type recipientIndex struct {
+	mu    sync.RWMutex
+	rooms map[string][]*ClientSession
+}
+
+func newRecipientIndex() *recipientIndex {
+	return &recipientIndex{rooms: map[string][]*ClientSession{}}
+}
+
+// Recipients returns the sessions in a room. The slice must not be modified.
+func (x *recipientIndex) Recipients(roomID string) []*ClientSession {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return x.rooms[roomID]
+}
+
+func (x *recipientIndex) Add(roomID string, sess *ClientSession) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	old := x.rooms[roomID]
+	for _, s := range old {
+		if s == sess {
+			return
+		}
+	}
+	list := make([]*ClientSession, len(old), len(old)+1)
+	copy(list, old)
+	x.rooms[roomID] = append(list, sess)
+}
+
+func (x *recipientIndex) Remove(roomID string, sess *ClientSession) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	old := x.rooms[roomID]
+	list := make([]*ClientSession, 0, len(old))
+	for _, s := range old {
+		if s != sess {
+			list = append(list, s)
+		}
+	}
+	if len(list) == 0 {
+		delete(x.rooms, roomID)
+		return
+	}
+	x.rooms[roomID] = list
+}
+
+// Prune forgets rooms that no longer exist
+func (x *recipientIndex) Prune(exists func(roomID string) bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for roomID := range x.rooms {
+		if !exists(roomID) {
+			delete(x.rooms, roomID)
+		}
+	}
+}
+
+// enterRoom seats a session as a player in a room and moves it into the
+// room's broadcasts
+func (s *Server) enterRoom(sess *ClientSession, roomID string, playerID int) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if sess.RoomID != "" && sess.RoomID != roomID {
+		s.recipients.Remove(sess.RoomID, sess)
+	}
+	sess.RoomID, sess.PlayerID = roomID, playerID
+	s.recipients.Add(roomID, sess)
+}
+
+// leaveRoom takes a session out of its room's broadcasts and returns the
+// seat it held, if any
+func (s *Server) leaveRoom(sess *ClientSession) (roomID string, playerID int) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if sess.RoomID == "" {
+		return "", 0
+	}
+	s.recipients.Remove(sess.RoomID, sess)
+	roomID, playerID = sess.RoomID, sess.PlayerID
+	sess.RoomID = ""
+	return roomID, playerID
+}
+
+// seatHolder returns the session playing a seat, if one is
+func (s *Server) seatHolder(roomID string, playerID int) *ClientSession {
+	for _, sess := range s.recipients.Recipients(roomID) {
+		if r, id := sess.seat(); r == roomID && id == playerID {
+			return sess
+		}
+	}
+	return nil
+}
+
+// dropSeat removes the player a departed session left behind, unless
+// another session has taken the seat over since
+func (s *Server) dropSeat(roomID string, playerID int) {
+	if roomID == "" || s.seatHolder(roomID, playerID) != nil {
+		return
+	}
+	if r, ok := s.manager.GetRoom(roomID); ok {
+		r.RemovePlayer(playerID)
+		log.Printf("Room %s: player %d dropped with its session", roomID, playerID)
+	}
+}
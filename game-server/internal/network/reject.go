@@ -0,0 +1,96 @@
+// SKYBATTLE — Rejections, Kicks and Errors
+// Every request the server turns down gets an answer with a reason code the
+// client can act on and a message it can show. Errors are sent at most once
+// per errorInterval to an address, so a client streaming input into a room
+// that has gone, or a spoofed flood, gets one reply rather than one per
+// packet, and to an address without a session only when they fit in the
+// request (see handshake.go).
+package network
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"time"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/auth"
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/room"
+)
+
+const errorInterval = time.Second
+
+// sendError answers a failed client packet of requestLen bytes, throttled
+// per address
+func (s *Server) sendError(addr *net.UDPAddr, pkt PacketType, requestLen int, reason, msg string) {
+	now := time.Now()
+	if last, ok := s.errorsSent.Load(addr.String()); ok && now.Sub(last.(time.Time)) < errorInterval {
+		return
+	}
+	s.errorsSent.Store(addr.String(), now)
+	e := ErrorPacket{Packet: uint8(pkt), Reason: reason, Message: msg}
+	val, ok := s.sessions.Load(addr.String())
+	if !ok {
+		s.sendUnverified(addr, requestLen, e)
+		return
+	}
+	s.sendSession(val.(*ClientSession), e)
+}
+
+// pruneErrorLimits forgets addresses that can be sent an error again
+func (s *Server) pruneErrorLimits(now time.Time) {
+	s.errorsSent.Range(func(key, value interface{}) bool {
+		if now.Sub(value.(time.Time)) >= errorInterval {
+			s.errorsSent.Delete(key)
+		}
+		return true
+	})
+}
+
+func (s *Server) rejectJoin(sess *ClientSession, matchID, reason, msg string) {
+	s.sendSession(sess, JoinRejectPacket{MatchID: matchID, Reason: reason, Message: msg})
+}
+
+// joinRejectReason maps a Room.AddPlayer error to its reason code and message
+func joinRejectReason(err error) (string, string) {
+	switch {
+	case errors.Is(err, room.ErrRoomFull):
+		return ReasonRoomFull, "That match is full."
+	case errors.Is(err, room.ErrMatchInProgress):
+		return ReasonMatchInProgress, "That match has already started."
+	case errors.Is(err, room.ErrNotOnRoster):
+		return ReasonNotOnRoster, "That match is reserved for other players."
+	case errors.Is(err, room.ErrAlreadyInRoom):
+		return ReasonAlreadyInMatch, "Your account is already playing in that match."
+	}
+	return ReasonJoinFailed, "Could not join that match."
+}
+
+// authRejectReason maps a token verification error to its reason code and
+// message; what exactly was wrong with the token stays in the server log
+func authRejectReason(err error) (string, string) {
+	if errors.Is(err, auth.ErrExpired) {
+		return ReasonTokenExpired, "Your session has expired, sign in again."
+	}
+	return ReasonAuthFailed, "Could not verify your account, sign in again."
+}
+
+// notifyShutdown tells every session the server is going away; running
+// matches may play on for grace
+func (s *Server) notifyShutdown(grace time.Duration) {
+	msg := "The server is shutting down."
+	if grace > 0 {
+		msg = fmt.Sprintf("The server is shutting down. Running matches may continue for up to %s.", grace.Round(time.Second))
+	}
+	count := 0
+	s.sessions.Range(func(key, value interface{}) bool {
+		s.sendSession(value.(*ClientSession), ShutdownPacket{
+			Reason:   ReasonShutdown,
+			Message:  msg,
+			GraceSec: int(grace.Seconds()),
+		})
+		count++
+		return true
+	})
+	log.Printf("Shutdown notice sent to %d sessions", count)
+}
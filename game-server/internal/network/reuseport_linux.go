@@ -0,0 +1,24 @@
+//go:build linux && !(mips || mipsle || mips64 || mips64le)
+
+package network
+
+import "syscall"
+
+// reusePortSupported: Linux spreads a port's datagrams across the sockets
+// bound to it with SO_REUSEPORT, by a hash of the source address
+const reusePortSupported = true
+
+// soReusePort is SO_REUSEPORT, which package syscall predates; MIPS numbers
+// it differently and uses reuseport_other.go
+const soReusePort = 0xf
+
+func reusePort(network, address string, c syscall.RawConn) error {
+	var sockErr error
+	err := c.Control(func(fd uintptr) {
+		sockErr = syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, soReusePort, 1)
+	})
+	if err != nil {
+		return err
+	}
+	return sockErr
+}
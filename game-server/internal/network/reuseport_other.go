@@ -0,0 +1,13 @@
+//go:build !linux || mips || mipsle || mips64 || mips64le
+
+package network
+
+import "syscall"
+
+// reusePortSupported is false where SO_REUSEPORT is missing or does not
+// balance datagrams between sockets (the BSDs deliver to the last bound)
+const reusePortSupported = false
+
+func reusePort(network, address string, c syscall.RawConn) error {
+	return nil
+}
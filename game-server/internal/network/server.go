@@ -3,64 +3,175 @@ package network
 
 import (
 	"bytes"
-	"fmt"
+	"context"
 	"log"
 	"net"
 	"sync"
 	"time"
 
-	"github.com/vmihailenco/msgpack/v5"
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/auth"
 	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/config"
 	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/game"
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/metrics"
 	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/room"
 )
 
 type ClientSession struct {
-	Addr     *net.UDPAddr
-	PlayerID int
-	RoomID   string
-	LastSeen time.Time
+	Addr        *net.UDPAddr
+	UserID      string
+	DisplayName string
+	PlayerID    int
+	RoomID      string
+	LastSeen    time.Time
+	Version     uint16       // negotiated protocol version
+	Caps        Capabilities // negotiated optional features
+
+	crypt *sessionCrypto // nil for sessions from before encryption
+
+	// mu guards PlayerID, RoomID and LastSeen, which packets handled by
+	// different readers update
+	mu sync.Mutex
 }
 
+// touch records that the session's client was heard from
+func (sess *ClientSession) touch(now time.Time) {
+	sess.mu.Lock()
+	sess.LastSeen = now
+	sess.mu.Unlock()
+}
+
+// seat returns the room the session is playing in and its player there
+func (sess *ClientSession) seat() (roomID string, playerID int) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	return sess.RoomID, sess.PlayerID
+}
+
+// sessionTimeout is how long a session may stay silent before it is
+// forgotten and its player leaves the match. Players send input every tick,
+// so only a client that is gone goes quiet for this long.
+const sessionTimeout = 60 * time.Second
+
 type Server struct {
 	cfg      *config.Config
-	conn     *net.UDPConn
+	conn     gamePort   // sends
+	conns    []gamePort // one per reader where SO_REUSEPORT balances them, see readers.go
 	manager  *room.Manager
+	verifier *auth.Verifier
 	sessions sync.Map // map[string]*ClientSession (key: addr.String())
+
+	recipients *recipientIndex
+
+	errorsSent sync.Map // map[string]time.Time: last ErrorPacket per address
+
+	cookieKey      []byte
+	packetLimit    *ipLimiter
+	handshakeLimit *ipLimiter
 }
 
 func NewServer(cfg *config.Config) *Server {
+	manager := room.NewManager(cfg.MaxRoomsPerServer, cfg.TickRate)
+	manager.SetReplayDir(cfg.ReplayDir)
+	verifier := auth.NewVerifier(cfg.JWTAccessSecret)
+	if cfg.Offline() {
+		verifier = auth.NewHostVerifier(cfg.HostTokenSecret)
+	}
 	return &Server{
-		cfg:     cfg,
-		manager: room.NewManager(cfg.MaxRoomsPerServer, cfg.TickRate),
+		cfg:            cfg,
+		manager:        manager,
+		verifier:       verifier,
+		recipients:     newRecipientIndex(),
+		cookieKey:      cookieKey(cfg.ServerSecret),
+		packetLimit:    newIPLimiter(packetRatePerIP, packetBurstPerIP),
+		handshakeLimit: newIPLimiter(handshakeRatePerIP, handshakeBurstPerIP),
 	}
 }
 
-func (s *Server) Start() error {
-	addr, err := net.ResolveUDPAddr("udp", fmt.Sprintf(":%d", s.cfg.Port))
-	if err != nil {
-		return err
+// Manager exposes the room manager for the admin API
+func (s *Server) Manager() *room.Manager {
+	return s.manager
+}
+
+// KickPlayer removes a player from a room and drops any session bound to it.
+// Returns false if the player was not in the room.
+func (s *Server) KickPlayer(roomID string, playerID int, reason string) bool {
+	r, ok := s.manager.GetRoom(roomID)
+	if !ok || !r.HasPlayer(playerID) {
+		return false
 	}
 
-	conn, err := net.ListenUDP("udp", addr)
-	if err != nil {
+	if sess := s.seatHolder(roomID, playerID); sess != nil {
+		s.sendSession(sess, KickPacket{Reason: ReasonKicked, Message: reason})
+		s.leaveRoom(sess)
+		if s.sessions.CompareAndDelete(sess.Addr.String(), sess) {
+			metrics.ActiveSessions.With().Dec()
+		}
+	}
+	r.RemovePlayer(playerID)
+
+	log.Printf("Room %s: player %d kicked (%s)", roomID, playerID, reason)
+	return true
+}
+
+// Start serves UDP until ctx is cancelled, then closes the socket and returns nil.
+func (s *Server) Start(ctx context.Context) error {
+	if err := s.listen(ctx); err != nil {
 		return err
 	}
-	s.conn = conn
-	defer s.conn.Close()
 
 	// Initial room for Phase 1 testing
 	_, _ = s.manager.CreateRoom("FFA", "outpost")
 
-	buf := make([]byte, 2048)
-	for {
-		n, clientAddr, err := s.conn.ReadFromUDP(buf)
-		if err != nil {
-			log.Printf("Error reading from UDP: %v", err)
-			continue
+	go s.housekeep(ctx)
+	return s.serve(ctx)
+}
+
+// Drain puts the server in drain mode: no new rooms or joins, running matches
+// play on until they finish or ctx expires, then every match is force-ended
+// (which emits its report) and every room goroutine is stopped.
+func (s *Server) Drain(ctx context.Context) {
+	s.manager.Drain()
+	var grace time.Duration
+	if deadline, ok := ctx.Deadline(); ok {
+		grace = time.Until(deadline)
+	}
+	s.notifyShutdown(grace)
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+wait:
+	for s.manager.ActiveMatches() > 0 {
+		select {
+		case <-ctx.Done():
+			log.Printf("Drain deadline reached with %d matches running, ending them", s.manager.ActiveMatches())
+			s.manager.EndAll("server shutdown")
+			break wait
+		case <-ticker.C:
 		}
+	}
+	s.manager.StopAll()
+}
 
-		s.handlePacket(clientAddr, buf[:n])
+// housekeep expires stale reservations, silent sessions, error throttles
+// and rate limit buckets
+func (s *Server) housekeep(ctx context.Context) {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.manager.ExpireReservations(now)
+			s.recipients.Prune(func(roomID string) bool {
+				_, ok := s.manager.GetRoom(roomID)
+				return ok
+			})
+			s.reapSessions(now)
+			s.pruneErrorLimits(now)
+			s.packetLimit.Prune(now)
+			s.handshakeLimit.Prune(now)
+		}
 	}
 }
 
@@ -71,165 +182,402 @@ func (s *Server) handlePacket(addr *net.UDPAddr, data []byte) {
 
 	packetType := PacketType(data[0])
 	payload := data[1:]
+	if packetType != PacketSecure { // counted as what they carry once opened
+		metrics.PacketsIn.With(packetType.String()).Inc()
+		metrics.BytesIn.With(packetType.String()).Add(len(data))
+	}
+
+	now := time.Now()
+	ip := addr.AddrPort().Addr().Unmap()
+	if !s.packetLimit.Allow(ip, now) {
+		metrics.PacketsDropped.With("rate_limit").Inc()
+		return
+	}
+	if (packetType == PacketConnect || packetType == PacketAuth) && !s.handshakeLimit.Allow(ip, now) {
+		metrics.PacketsDropped.With("handshake_rate_limit").Inc()
+		return
+	}
 
 	switch packetType {
+	case PacketConnect:
+		s.handleConnect(addr, payload)
 	case PacketAuth:
 		s.handleAuth(addr, payload)
+	case PacketSecure:
+		s.handleSecure(addr, data)
+	default:
+		// Anyone can send from a keyed session's address in the clear
+		if val, ok := s.sessions.Load(addr.String()); ok && val.(*ClientSession).crypt != nil {
+			metrics.PacketsDropped.With("plaintext_after_handshake").Inc()
+			return
+		}
+		s.handleSessionPacket(addr, packetType, payload)
+	}
+}
+
+// handleSessionPacket handles the packets sent after authentication,
+// unwrapped if they came sealed
+func (s *Server) handleSessionPacket(addr *net.UDPAddr, packetType PacketType, payload []byte) {
+	switch packetType {
 	case PacketRequestJoin:
 		s.handleJoin(addr, payload)
 	case PacketInput:
 		s.handleInput(addr, payload)
 	case PacketPing:
-		s.sendTo(addr, []byte{byte(PacketPong)})
+		// Only sessions get a pong; anyone else could be a spoofed reflection target
+		if val, ok := s.sessions.Load(addr.String()); ok {
+			val.(*ClientSession).touch(time.Now())
+			s.send(val.(*ClientSession), []byte{byte(PacketPong)})
+		}
 	}
 }
 
 func (s *Server) handleAuth(addr *net.UDPAddr, payload []byte) {
 	var p AuthPacket
-	if err := msgpack.Unmarshal(payload, &p); err != nil {
+	if err := p.Decode(payload); err != nil {
+		metrics.DecodeErrors.With(PacketAuth.String()).Inc()
+		s.sendError(addr, PacketAuth, len(payload)+1, ReasonMalformedPacket, "Could not read the auth packet.")
+		return
+	}
+
+	// Checked before the cookie so that clients too old to know the
+	// handshake still hear they need to update; a token makes their
+	// request bigger than the answer.
+	version, caps, reason, msg := negotiate(p.Version, p.Caps)
+	if reason != "" {
+		log.Printf("Auth rejected from %s: protocol %d (%s)", addr, version, reason)
+		s.sendUnverified(addr, len(payload)+1, AuthAckPacket{
+			Message:    msg,
+			Reason:     reason,
+			Version:    ProtocolVersion,
+			MinVersion: minProtocolVersion(),
+		})
 		return
 	}
 
-	// In Phase 1, we trust the token or simplified auth for local dev
-	// Real auth logic would verify JWT here
-	
+	if !s.validCookie(addr, p.Cookie, time.Now()) {
+		metrics.PacketsDropped.With("bad_cookie").Inc()
+		s.sendError(addr, PacketAuth, len(payload)+1, ReasonBadCookie, "Connection expired, reconnecting.")
+		return
+	}
+
+	claims, err := s.verifier.Verify(p.Token)
+	if err != nil {
+		log.Printf("Auth rejected from %s: %v", addr, err)
+		reason, msg := authRejectReason(err)
+		s.sendPacket(addr, AuthAckPacket{Success: false, Message: msg, Reason: reason})
+		return
+	}
+
+	// From protocol 4 the client's key is required; the ack carries ours
+	var serverPub []byte
+	var crypt *sessionCrypto
+	if version >= secureProtocolVersion {
+		serverPub, crypt, err = serverKeyExchange(p.PublicKey, p.Cookie, p.Token)
+		if err != nil {
+			log.Printf("Auth rejected from %s: %v", addr, err)
+			s.sendPacket(addr, AuthAckPacket{Message: "Could not set up an encrypted session.", Reason: ReasonKeyExchange, Version: version})
+			return
+		}
+	}
+
 	session := &ClientSession{
-		Addr:     addr,
-		LastSeen: time.Now(),
+		Addr:        addr,
+		UserID:      claims.UserID,
+		DisplayName: claims.DisplayName,
+		LastSeen:    time.Now(),
+		Version:     version,
+		Caps:        caps,
+		crypt:       crypt,
+	}
+	// Authenticating again from the same address replaces the session; the
+	// same account keeps its seat, anyone else's is given up
+	if old, existed := s.sessions.Swap(addr.String(), session); existed {
+		roomID, playerID := s.leaveRoom(old.(*ClientSession))
+		if roomID != "" && old.(*ClientSession).UserID == session.UserID {
+			s.enterRoom(session, roomID, playerID)
+		} else {
+			s.dropSeat(roomID, playerID)
+		}
+	} else {
+		metrics.ActiveSessions.With().Inc()
 	}
-	s.sessions.Store(addr.String(), session)
 
-	ack := AuthAckPacket{Success: true, Message: "Authenticated"}
-	s.sendPacket(addr, PacketAuthAck, ack)
+	ack := AuthAckPacket{
+		Success:    true,
+		Message:    "Authenticated",
+		Version:    version,
+		MinVersion: minProtocolVersion(),
+		Caps:       uint32(caps),
+		PublicKey:  serverPub,
+	}
+	s.sendPacket(addr, ack)
 }
 
 func (s *Server) handleJoin(addr *net.UDPAddr, payload []byte) {
 	var p JoinPacket
-	if err := msgpack.Unmarshal(payload, &p); err != nil {
+	if err := p.Decode(payload); err != nil {
+		metrics.DecodeErrors.With(PacketRequestJoin.String()).Inc()
+		s.sendError(addr, PacketRequestJoin, len(payload)+1, ReasonMalformedPacket, "Could not read the join request.")
 		return
 	}
 
 	val, ok := s.sessions.Load(addr.String())
 	if !ok {
+		s.sendError(addr, PacketRequestJoin, len(payload)+1, ReasonNotAuthenticated, "Authenticate before joining a match.")
 		return
 	}
 	session := val.(*ClientSession)
+	session.touch(time.Now())
 
-	// In Phase 1, just join the first available room if none specified
-	rooms := s.manager.ListRooms() // Need to implement ListRooms in manager
+	// A client back on a new address takes over the seat it left, even
+	// while draining since its match is still being played
+	if r, id, ok := s.findSeat(session.UserID, p.MatchID); ok {
+		s.rebindSeat(session, r, id)
+		return
+	}
+
+	if s.manager.IsDraining() {
+		s.rejectJoin(session, p.MatchID, ReasonDraining, "The server is shutting down and not accepting new players.")
+		return
+	}
+
+	// Matched players name their match; otherwise join the first open room
 	var targetRoom *room.Room
 	if p.MatchID != "" {
 		targetRoom, _ = s.manager.GetRoom(p.MatchID)
-	} else if len(rooms) > 0 {
-		targetRoom = rooms[0]
+	} else {
+		for _, r := range s.manager.ListRooms() {
+			if !r.IsReserved() && !r.IsFinished() {
+				targetRoom = r
+				break
+			}
+		}
 	}
 
 	if targetRoom == nil {
+		if p.MatchID != "" {
+			s.rejectJoin(session, p.MatchID, ReasonRoomNotFound, "That match does not exist or has ended.")
+		} else {
+			s.rejectJoin(session, "", ReasonNoOpenRoom, "There is no open match on this server.")
+		}
 		return
 	}
 
-	player, err := targetRoom.AddPlayer("temp_uid", "Player")
+	player, err := targetRoom.AddPlayer(session.UserID, session.DisplayName)
 	if err != nil {
+		log.Printf("Join %s rejected for %s: %v", targetRoom.ID, session.UserID, err)
+		reason, msg := joinRejectReason(err)
+		s.rejectJoin(session, p.MatchID, reason, msg)
 		return
 	}
 
-	session.PlayerID = player.ID
-	session.RoomID = targetRoom.ID
+	s.enterRoom(session, targetRoom.ID, player.ID)
+	s.sendMatchInit(session, targetRoom)
 
-	init := MatchInitPacket{
-		MatchID:  targetRoom.ID,
-		MapID:    targetRoom.MapID,
-		TickRate: targetRoom.TickRate,
-		Spawns:   targetRoom.SpawnPoints,
+	// Set broadcast callback
+	targetRoom.SetBroadcastFunc(s.broadcastToRoom)
+
+	// Start the room loop; only the first join's call does anything
+	go targetRoom.Start()
+}
+
+// findSeat returns the player an account already holds in the named match,
+// or in any match when none is named
+func (s *Server) findSeat(userID, matchID string) (*room.Room, int, bool) {
+	if matchID != "" {
+		if r, ok := s.manager.GetRoom(matchID); ok {
+			if id, ok := r.SeatOf(userID); ok {
+				return r, id, true
+			}
+		}
+		return nil, 0, false
+	}
+	for _, r := range s.manager.ListRooms() {
+		if id, ok := r.SeatOf(userID); ok {
+			return r, id, true
+		}
 	}
-	s.sendPacket(addr, PacketMatchInit, init)
+	return nil, 0, false
+}
 
-	// Set broadcast callback
-	targetRoom.SetBroadcastFunc(func(roomID string, tick int, players []*game.Player, pickups []*game.Pickup, events []game.MatchEvent) {
-		s.broadcastToRoom(roomID, tick, players, pickups, events)
+// rebindSeat moves a player over to a new session of the same account,
+// taking it from the session that held it
+func (s *Server) rebindSeat(session *ClientSession, r *room.Room, playerID int) {
+	if old := s.seatHolder(r.ID, playerID); old != nil && old != session {
+		s.leaveRoom(old)
+	}
+	s.enterRoom(session, r.ID, playerID)
+	log.Printf("Room %s: player %d reconnected from %s", r.ID, playerID, session.Addr)
+	s.sendMatchInit(session, r)
+}
+
+func (s *Server) sendMatchInit(session *ClientSession, r *room.Room) {
+	s.sendSession(session, MatchInitPacket{
+		MatchID:  r.ID,
+		MapID:    r.MapID,
+		TickRate: r.TickRate,
+		Spawns:   r.SpawnPoints,
 	})
+}
 
-	// Start room loop if not already started
-	if targetRoom.State == room.StateWaiting {
-		go targetRoom.Start()
-	}
+// reapSessions forgets sessions that have sent nothing for sessionTimeout
+// and removes the players they left in their matches
+func (s *Server) reapSessions(now time.Time) {
+	s.sessions.Range(func(key, value interface{}) bool {
+		sess := value.(*ClientSession)
+		sess.mu.Lock()
+		idle := now.Sub(sess.LastSeen)
+		sess.mu.Unlock()
+		if idle < sessionTimeout || !s.sessions.CompareAndDelete(key, sess) {
+			return true
+		}
+		metrics.ActiveSessions.With().Dec()
+		s.dropSeat(s.leaveRoom(sess))
+		return true
+	})
 }
 
 func (s *Server) handleInput(addr *net.UDPAddr, payload []byte) {
 	var p InputPacket
-	if err := msgpack.Unmarshal(payload, &p); err != nil {
+	if err := p.Decode(payload); err != nil {
+		metrics.DecodeErrors.With(PacketInput.String()).Inc()
+		s.sendError(addr, PacketInput, len(payload)+1, ReasonMalformedPacket, "Could not read the input packet.")
 		return
 	}
 
 	val, ok := s.sessions.Load(addr.String())
 	if !ok {
+		// Kept short so it fits in the reply budget of an input packet
+		s.sendError(addr, PacketInput, len(payload)+1, ReasonNotAuthenticated, "Not authenticated, reconnect.")
 		return
 	}
 	session := val.(*ClientSession)
+	session.mu.Lock()
 	session.LastSeen = time.Now()
+	roomID, playerID := session.RoomID, session.PlayerID
+	session.mu.Unlock()
 
-	if session.RoomID == "" {
+	if roomID == "" {
+		s.sendError(addr, PacketInput, len(payload)+1, ReasonNotInMatch, "Join a match before sending input.")
 		return
 	}
 
-	r, ok := s.manager.GetRoom(session.RoomID)
+	r, ok := s.manager.GetRoom(roomID)
 	if !ok {
+		s.leaveRoom(session)
+		s.sendError(addr, PacketInput, len(payload)+1, ReasonMatchEnded, "Your match has ended.")
 		return
 	}
 
-	// Update player state in room (authoritative logic)
-	r.HandlePlayerInput(session.PlayerID, game.PlayerInput{
+	// Applied on the room's next tick; a full queue drops it (counted per room)
+	r.QueueInput(playerID, game.PlayerInput{
 		Horizontal: p.Horizontal,
 		Vertical:   p.Vertical,
 		AimAngle:   p.AimAngleDeg,
 		IsFlying:   p.IsFlying,
 		Firing:     p.Firing,
 		WeaponID:   p.WeaponID,
+		Switch:     p.Switch,
+		Interact:   p.Interact,
+		Melee:      p.Melee,
+		Throw:      p.Throw,
+		PlaceMine:  p.PlaceMine,
 		Sequence:   p.Sequence,
 	})
 }
 
-func (s *Server) broadcastToRoom(roomID string, tick int, players []*game.Player, pickups []*game.Pickup, events []game.MatchEvent) {
-	// 1. Construct WorldStatePacket
-	state := WorldStatePacket{
-		Tick:    tick,
-		Players: make([]game.Player, len(players)),
-		Pickups: make([]game.Pickup, len(pickups)),
-		Events:  make([]game.MatchEvent, len(events)),
-	}
-	for i, p := range players {
-		state.Players[i] = *p
-	}
-	for i, p := range pickups {
-		state.Pickups[i] = *p
+// broadcastToRoom sends a room's snapshot to its sessions. The snapshot is
+// never modified, so the packet uses its slices as they are.
+func (s *Server) broadcastToRoom(snap room.Snapshot) {
+	s.broadcastState(snap.RoomID, WorldStatePacket{
+		Tick:    snap.Tick,
+		Players: snap.Players,
+		Pickups: snap.Pickups,
+		Events:  snap.Events,
+	})
+}
+
+// broadcastState sends a room's sessions the world state in one batch;
+// older clients get it with what they can't parse stripped out. Encoded
+// once per capability set into pooled buffers, sealed per session.
+func (s *Server) broadcastState(roomID string, state WorldStatePacket) {
+	encoded := make([]stateEncoding, 0, 4)
+	b := getBatch()
+	for _, sess := range s.recipients.Recipients(roomID) {
+		var enc *stateEncoding
+		for i := range encoded {
+			if encoded[i].caps == sess.Caps {
+				enc = &encoded[i]
+			}
+		}
+		if enc == nil {
+			buf := getBuffer()
+			err := state.For(sess.Caps).EncodeTo(buf)
+			if err != nil {
+				log.Printf("Error encoding packet: %v", err)
+			}
+			encoded = append(encoded, stateEncoding{sess.Caps, buf, err == nil})
+			enc = &encoded[len(encoded)-1]
+		}
+		// Sessions whose encoding failed miss this state; the rest get it
+		if enc.ok {
+			b.Add(sess, enc.buf.Bytes())
+		}
 	}
-	for i, event := range events {
-		state.Events[i] = event
+	s.flush(b)
+
+	putBatch(b)
+	for _, e := range encoded {
+		putBuffer(e.buf)
 	}
+}
 
-	// 2. Find all sessions in this room
-	s.sessions.Range(func(key, value interface{}) bool {
-		sess := value.(*ClientSession)
-		if sess.RoomID == roomID {
-			s.sendPacket(sess.Addr, PacketWorldState, state)
-		}
-		return true
-	})
+type stateEncoding struct {
+	caps Capabilities
+	buf  *bytes.Buffer
+	ok   bool // false if encoding failed
+}
+
+func (s *Server) sendPacket(addr *net.UDPAddr, p ServerPacket) {
+	data, err := p.Encode()
+	if err != nil {
+		log.Printf("Error encoding packet: %v", err)
+		return
+	}
+	s.sendTo(addr, data)
 }
 
-func (s *Server) sendPacket(addr *net.UDPAddr, t ServerPacketType, payload interface{}) {
-	var buf bytes.Buffer
-	buf.WriteByte(byte(t))
-	
-	enc := msgpack.NewEncoder(&buf)
-	if err := enc.Encode(payload); err != nil {
+// sendSession encodes a packet for a session, sealed if it has a key
+func (s *Server) sendSession(sess *ClientSession, p ServerPacket) {
+	data, err := p.Encode()
+	if err != nil {
 		log.Printf("Error encoding packet: %v", err)
 		return
 	}
-	
-	s.sendTo(addr, buf.Bytes())
+	s.send(sess, data)
+}
+
+// send delivers an encoded packet to a session, sealed if it has a key. It
+// is counted under the type it carries.
+func (s *Server) send(sess *ClientSession, data []byte) {
+	if sess.crypt == nil {
+		s.sendTo(sess.Addr, data)
+		return
+	}
+	sealed := sess.crypt.Seal(make([]byte, 0, len(data)+secureOverhead), data)
+	s.write(sess.Addr, ServerPacketType(data[0]), sealed)
 }
 
 func (s *Server) sendTo(addr *net.UDPAddr, data []byte) {
-	_, _ = s.conn.WriteToUDP(data, addr)
+	s.write(addr, ServerPacketType(data[0]), data)
+}
+
+func (s *Server) write(addr *net.UDPAddr, t ServerPacketType, data []byte) {
+	n, err := s.conn.WriteToUDP(data, addr)
+	if err != nil || n == 0 {
+		return
+	}
+	metrics.PacketsOut.With(t.String()).Inc()
+	metrics.BytesOut.With(t.String()).Add(n)
 }
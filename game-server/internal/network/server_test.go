@@ -0,0 +1,550 @@
+package network
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/auth"
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/config"
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/room"
+)
+
+// startServer serves on a loopback port. With no JWT secret any token is
+// accepted as a guest, so clients need no signing key.
+// testJWTSecret signs the tokens of tests whose players need their own
+// identities; without a secret every token is a guest
+const testJWTSecret = "test_access_secret"
+
+func startServer(t *testing.T) *Server {
+	t.Helper()
+	return startServerWith(t, &config.Config{Profile: config.ProfileCloud, TickRate: 30, MaxRoomsPerServer: 4})
+}
+
+func startServerWith(t *testing.T, cfg *config.Config) *Server {
+	t.Helper()
+	s := NewServer(cfg)
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.conn = conn
+	run(t, s)
+	return s
+}
+
+// run serves until the test ends
+func run(t *testing.T, s *Server) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.serve(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+		s.manager.StopAll()
+	})
+}
+
+type testClient struct {
+	t     *testing.T
+	conn  *net.UDPConn
+	crypt *sessionCrypto // set by auth
+}
+
+func dial(t *testing.T, s *Server) *testClient {
+	t.Helper()
+	// The server may listen on all addresses; clients use loopback
+	server := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: s.conn.LocalAddr().(*net.UDPAddr).Port}
+	conn, err := net.DialUDP("udp", nil, server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &testClient{t: t, conn: conn}
+}
+
+// send encodes a packet, sealed once the client is authenticated
+func (c *testClient) send(p interface{ Encode() ([]byte, error) }) {
+	c.t.Helper()
+	data, err := p.Encode()
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if c.crypt != nil {
+		data = c.crypt.Seal(nil, data)
+	}
+	c.sendRaw(data)
+}
+
+func (c *testClient) sendRaw(data []byte) {
+	c.t.Helper()
+	if _, err := c.conn.Write(data); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// read returns the next packet, opened if it was sealed
+func (c *testClient) read(deadline time.Time) ([]byte, error) {
+	c.t.Helper()
+	buf := make([]byte, 64*1024)
+	c.conn.SetReadDeadline(deadline)
+	for {
+		n, err := c.conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			continue
+		}
+		if ServerPacketType(buf[0]) != PacketServerSecure {
+			return buf[:n], nil
+		}
+		if c.crypt == nil {
+			c.t.Fatal("sealed packet before the key exchange")
+		}
+		data, err := c.crypt.Open(nil, buf[:n])
+		if err != nil {
+			c.t.Fatalf("opening packet: %v", err)
+		}
+		return data, nil
+	}
+}
+
+// expect reads until a packet of type want arrives, skipping world state
+// and anything else in between, and decodes it into body
+func (c *testClient) expect(want ServerPacketType, body interface{ Decode([]byte) error }) {
+	c.t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		data, err := c.read(deadline)
+		if err != nil {
+			c.t.Fatalf("waiting for %s: %v", want, err)
+		}
+		if ServerPacketType(data[0]) == want {
+			if err := body.Decode(data[1:]); err != nil {
+				c.t.Fatalf("decoding %s: %v", want, err)
+			}
+			return
+		}
+	}
+}
+
+// decodeNothing stands in for the body of a bare packet
+type decodeNothing struct{}
+
+func (decodeNothing) Decode([]byte) error { return nil }
+
+// expectNothing fails if a packet of type unwanted (0 for any) arrives
+// within wait
+func (c *testClient) expectNothing(unwanted ServerPacketType, wait time.Duration) {
+	c.t.Helper()
+	deadline := time.Now().Add(wait)
+	for {
+		data, err := c.read(deadline)
+		if err != nil {
+			return
+		}
+		if unwanted == 0 || ServerPacketType(data[0]) == unwanted {
+			c.t.Fatalf("unexpected %s", ServerPacketType(data[0]))
+		}
+	}
+}
+
+// connect runs the handshake and returns the cookie
+func (c *testClient) connect() []byte {
+	c.t.Helper()
+	c.send(ConnectPacket{Version: ProtocolVersion, Padding: make([]byte, 48)})
+	var ch ChallengePacket
+	c.expect(PacketChallenge, &ch)
+	return ch.Cookie
+}
+
+// auth runs the handshake and key exchange; everything after is sealed
+func (c *testClient) auth(token string) AuthAckPacket {
+	c.t.Helper()
+	cookie := c.connect()
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	pub := priv.PublicKey().Bytes()
+	c.send(AuthPacket{Token: token, Version: ProtocolVersion, Caps: uint32(CapInventory | CapActions), Cookie: cookie, PublicKey: pub})
+	var ack AuthAckPacket
+	c.expect(PacketAuthAck, &ack)
+	if !ack.Success {
+		c.t.Fatalf("auth failed: %+v", ack)
+	}
+	if c.crypt, err = deriveSession(priv, ack.PublicKey, pub, ack.PublicKey, cookie, token, false); err != nil {
+		c.t.Fatal(err)
+	}
+	return ack
+}
+
+func (c *testClient) expectError(pkt PacketType, reason string) {
+	c.t.Helper()
+	var e ErrorPacket
+	c.expect(PacketError, &e)
+	if PacketType(e.Packet) != pkt || e.Reason != reason || e.Message == "" {
+		c.t.Fatalf("error = %+v, want %s for %s", e, reason, pkt)
+	}
+}
+
+func (c *testClient) expectJoinReject(reason string) {
+	c.t.Helper()
+	var rej JoinRejectPacket
+	c.expect(PacketJoinReject, &rej)
+	if rej.Reason != reason || rej.Message == "" {
+		c.t.Fatalf("join reject = %+v, want %s", rej, reason)
+	}
+}
+
+func TestPacketsBeforeAuthAreAnswered(t *testing.T) {
+	s := startServer(t)
+
+	c := dial(t, s)
+	c.send(JoinPacket{MatchID: strings.Repeat("m", 100)})
+	c.expectError(PacketRequestJoin, ReasonNotAuthenticated)
+
+	c = dial(t, s)
+	c.send(InputPacket{Sequence: 1})
+	c.expectError(PacketInput, ReasonNotAuthenticated)
+
+	c = dial(t, s)
+	c.send(AuthPacket{Token: strings.Repeat("t", 100), Version: ProtocolVersion})
+	c.expectError(PacketAuth, ReasonBadCookie)
+}
+
+// Until the handshake completes nothing is sent back that is bigger than
+// what was received, and pings go unanswered
+func TestNoAmplificationBeforeHandshake(t *testing.T) {
+	s := startServer(t)
+
+	c := dial(t, s)
+	c.sendRaw([]byte{byte(PacketPing)})
+	c.send(JoinPacket{})
+	c.sendRaw([]byte{byte(PacketAuth), 0xc1}) // 0xc1 is never valid msgpack
+	c.send(ConnectPacket{Version: ProtocolVersion})
+	c.send(AuthPacket{Token: "t", Version: ProtocolVersion, Cookie: make([]byte, cookieSize)})
+	c.expectNothing(0, 200*time.Millisecond)
+
+	// Once authenticated, pings are answered
+	c.auth("device-a")
+	c.sendRaw(c.crypt.Seal(nil, []byte{byte(PacketPing)}))
+	c.expect(PacketPong, decodeNothing{})
+}
+
+func TestCookieIsBoundToAddressAndTime(t *testing.T) {
+	s := NewServer(&config.Config{})
+	a := &net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 5000}
+	b := &net.UDPAddr{IP: net.IPv4(10, 0, 0, 2), Port: 5000}
+	now := time.Now()
+
+	cookie := s.issueCookie(a, now)
+	if !s.validCookie(a, cookie, now.Add(cookieTTL-time.Second)) {
+		t.Fatal("fresh cookie rejected")
+	}
+	if s.validCookie(b, cookie, now) {
+		t.Fatal("cookie accepted from another address")
+	}
+	if s.validCookie(a, cookie, now.Add(cookieTTL+2*time.Second)) {
+		t.Fatal("expired cookie accepted")
+	}
+	if other := NewServer(&config.Config{}); other.validCookie(a, cookie, now) {
+		t.Fatal("cookie accepted by another server")
+	}
+	// A restart, or another process on the port, shares the secret and so
+	// the cookies
+	keyed := NewServer(&config.Config{ServerSecret: "secret"}).issueCookie(a, now)
+	if !NewServer(&config.Config{ServerSecret: "secret"}).validCookie(a, keyed, now) {
+		t.Fatal("cookie rejected by a server with the same secret")
+	}
+	if NewServer(&config.Config{ServerSecret: "other"}).validCookie(a, keyed, now) {
+		t.Fatal("cookie accepted under another secret")
+	}
+	cookie[len(cookie)-1] ^= 1
+	if s.validCookie(a, cookie, now) {
+		t.Fatal("tampered cookie accepted")
+	}
+}
+
+func TestHandshakeRateLimit(t *testing.T) {
+	s := startServer(t)
+	c := dial(t, s)
+	for i := 0; i < handshakeBurstPerIP; i++ {
+		c.connect()
+	}
+	c.send(ConnectPacket{Version: ProtocolVersion, Padding: make([]byte, 48)})
+	c.expectNothing(PacketChallenge, 100*time.Millisecond)
+}
+
+func TestOutdatedClientIsToldToUpdate(t *testing.T) {
+	s := startServer(t)
+
+	// A client from before versioning sends only its token, which is
+	// enough room for the answer
+	c := dial(t, s)
+	c.send(AuthPacket{Token: strings.Repeat("t", 300)})
+	var ack AuthAckPacket
+	c.expect(PacketAuthAck, &ack)
+	if ack.Success || ack.Reason != ReasonClientOutdated || ack.MinVersion != minProtocolVersion() {
+		t.Fatalf("ack = %+v", ack)
+	}
+}
+
+func TestJoinRejections(t *testing.T) {
+	s := startServer(t)
+	c := dial(t, s)
+	c.auth("device-a")
+
+	c.send(InputPacket{Sequence: 1})
+	c.expectError(PacketInput, ReasonNotInMatch)
+
+	c.send(JoinPacket{MatchID: "no-such-match"})
+	c.expectJoinReject(ReasonRoomNotFound)
+
+	c.send(JoinPacket{})
+	c.expectJoinReject(ReasonNoOpenRoom)
+
+	r, err := s.manager.CreateRoom("FFA", "outpost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.MaxPlayers = 1
+	if _, err := r.AddPlayer("u-other", "Other"); err != nil {
+		t.Fatal(err)
+	}
+	c.send(JoinPacket{MatchID: r.ID})
+	c.expectJoinReject(ReasonRoomFull)
+
+	if _, _, err := s.manager.Reserve(room.Reservation{MatchID: "ranked-1", UserIDs: []string{"u-1"}}); err != nil {
+		t.Fatal(err)
+	}
+	c.send(JoinPacket{MatchID: "ranked-1"})
+	c.expectJoinReject(ReasonNotOnRoster)
+
+	s.manager.Drain()
+	c.send(JoinPacket{MatchID: r.ID})
+	c.expectJoinReject(ReasonDraining)
+}
+
+func TestJoinAfterMatchStarted(t *testing.T) {
+	s := startServer(t)
+	r, _ := s.manager.CreateRoom("FFA", "outpost")
+	r.State = room.StateInProgress // started without a tick loop, nothing else touches it
+
+	c := dial(t, s)
+	c.auth("device-a")
+	c.send(JoinPacket{MatchID: r.ID})
+	c.expectJoinReject(ReasonMatchInProgress)
+}
+
+func TestInputAfterMatchRemoved(t *testing.T) {
+	s := startServer(t)
+	r, _ := s.manager.CreateRoom("FFA", "outpost")
+
+	c := dial(t, s)
+	c.auth("device-a")
+	c.send(JoinPacket{MatchID: r.ID})
+	c.expect(PacketMatchInit, &MatchInitPacket{})
+
+	s.manager.RemoveRoom(r.ID)
+	c.send(InputPacket{Sequence: 1})
+	c.expectError(PacketInput, ReasonMatchEnded)
+
+	// Further input is throttled, then answered again
+	c.send(InputPacket{Sequence: 2})
+	c.expectNothing(PacketError, 200*time.Millisecond)
+	s.pruneErrorLimits(time.Now().Add(errorInterval))
+	c.send(InputPacket{Sequence: 3})
+	c.expectError(PacketInput, ReasonNotInMatch)
+}
+
+func TestKickAndShutdownReachClients(t *testing.T) {
+	s := startServer(t)
+	r, _ := s.manager.CreateRoom("FFA", "outpost")
+
+	kicked, other := dial(t, s), dial(t, s)
+	kicked.auth("device-a")
+	other.auth("device-b")
+	var init MatchInitPacket
+	kicked.send(JoinPacket{MatchID: r.ID})
+	kicked.expect(PacketMatchInit, &init)
+
+	_, playerID := kicked.session(s).seat()
+	if !s.KickPlayer(r.ID, playerID, "teamkilling") {
+		t.Fatal("kick failed")
+	}
+	var kick KickPacket
+	kicked.expect(PacketKick, &kick)
+	if kick.Reason != ReasonKicked || kick.Message != "teamkilling" {
+		t.Fatalf("kick = %+v", kick)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	s.Drain(ctx)
+	// Every session hears about the shutdown, in a match or not
+	var bye ShutdownPacket
+	other.expect(PacketShutdown, &bye)
+	if bye.Reason != ReasonShutdown || bye.Message == "" {
+		t.Fatalf("shutdown = %+v", bye)
+	}
+}
+
+// A match still running at the drain deadline is ended, reporting its
+// result once, and the server is left with no rooms
+func TestDrainEndsMatchAtDeadline(t *testing.T) {
+	s := startServer(t)
+	results := make(chan room.MatchResult, 2)
+	s.manager.SetFinishHandler(func(res room.MatchResult) { results <- res })
+	r, _ := s.manager.CreateRoom("FFA", "outpost")
+
+	c := dial(t, s)
+	c.auth("device-a")
+	c.send(JoinPacket{MatchID: r.ID})
+	c.expect(PacketMatchInit, &MatchInitPacket{})
+	for !r.IsActive() {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	s.Drain(ctx)
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("drain took %s with a 100ms deadline", d)
+	}
+
+	var bye ShutdownPacket
+	c.expect(PacketShutdown, &bye)
+	if res := <-results; res.MatchID != r.ID || len(res.Players) != 1 {
+		t.Fatalf("result = %+v", res)
+	}
+	if len(results) != 0 {
+		t.Fatal("match reported twice")
+	}
+	if n := len(s.manager.ListRooms()); n != 0 {
+		t.Fatalf("%d rooms left after draining", n)
+	}
+}
+
+func TestSessionTrafficIsSealed(t *testing.T) {
+	s := startServer(t)
+	r, _ := s.manager.CreateRoom("FFA", "outpost")
+
+	c := dial(t, s)
+	c.auth("device-a")
+	join, _ := JoinPacket{MatchID: r.ID}.Encode()
+	sealedJoin := c.crypt.Seal(nil, join)
+
+	// Someone on the path spoofing the client's address in the clear, or
+	// replaying what it sent, is ignored
+	c.sendRaw(join)
+	c.expectNothing(0, 100*time.Millisecond)
+
+	c.sendRaw(sealedJoin)
+	buf := make([]byte, 2048)
+	c.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, err := c.conn.Read(buf)
+	if err != nil || ServerPacketType(buf[0]) != PacketServerSecure {
+		t.Fatalf("reply %x (%v), want it sealed", buf[:n], err)
+	}
+	if init, err := c.crypt.Open(nil, buf[:n]); err != nil || ServerPacketType(init[0]) != PacketMatchInit {
+		t.Fatalf("opened %x (%v), want match init", init, err)
+	}
+
+	c.sendRaw(sealedJoin)
+	c.expectNothing(PacketJoinReject, 100*time.Millisecond)
+	forged := c.crypt.Seal(nil, join)
+	forged[secureHeaderSize] ^= 1
+	c.sendRaw(forged)
+	c.expectNothing(PacketJoinReject, 100*time.Millisecond)
+
+	c.send(JoinPacket{MatchID: r.ID}) // sealed afresh, so heard
+	c.expect(PacketMatchInit, &MatchInitPacket{})
+}
+
+// A client back from a new address takes its seat over, and only a session
+// that still holds a seat takes the player with it when it goes silent
+func TestReconnectTakesSeatBack(t *testing.T) {
+	s := startServer(t)
+	r, _ := s.manager.CreateRoom("FFA", "outpost")
+
+	first := dial(t, s)
+	first.auth("device-a")
+	first.send(JoinPacket{MatchID: r.ID})
+	first.expect(PacketMatchInit, &MatchInitPacket{})
+
+	second := dial(t, s)
+	second.auth("device-a")
+	second.send(JoinPacket{}) // no match named, its own is found
+	second.expect(PacketMatchInit, &MatchInitPacket{})
+	if n := len(r.PlayerSummaries()); n != 1 {
+		t.Fatalf("%d players after reconnecting, want 1", n)
+	}
+	players := r.PlayerSummaries()
+
+	val, _ := s.sessions.Load(first.conn.LocalAddr().String())
+	val.(*ClientSession).touch(time.Now().Add(-sessionTimeout))
+	s.reapSessions(time.Now())
+	if _, ok := s.sessions.Load(first.conn.LocalAddr().String()); ok || !r.HasPlayer(players[0].ID) {
+		t.Fatal("reaping the replaced session should forget it and keep the player")
+	}
+
+	s.reapSessions(time.Now().Add(sessionTimeout))
+	if r.HasPlayer(players[0].ID) {
+		t.Fatal("player still seated after its session went silent")
+	}
+	second.send(InputPacket{Sequence: 1})
+	second.expectError(PacketSecure, ReasonNotAuthenticated) // its key is gone with it
+}
+
+func TestKeyExchangeRequired(t *testing.T) {
+	s := startServer(t)
+	c := dial(t, s)
+	c.send(AuthPacket{Token: "device-a", Version: ProtocolVersion, Cookie: c.connect()})
+	var ack AuthAckPacket
+	c.expect(PacketAuthAck, &ack)
+	if ack.Success || ack.Reason != ReasonKeyExchange {
+		t.Fatalf("ack = %+v", ack)
+	}
+}
+
+// A rejected token gets a reason code and a message for players, not the
+// verifier's error
+func TestAuthRejectionReasons(t *testing.T) {
+	s := startServerWith(t, &config.Config{Profile: config.ProfileCloud, TickRate: 30, MaxRoomsPerServer: 4, JWTAccessSecret: testJWTSecret})
+	expired := auth.Issue([]byte(testJWTSecret), auth.Claims{UserID: "u1", ExpiresAt: time.Now().Add(-time.Minute).Unix()})
+	forged := auth.Issue([]byte("not the secret"), auth.Claims{UserID: "u1"})
+
+	for token, want := range map[string]string{expired: ReasonTokenExpired, forged: ReasonAuthFailed, "junk": ReasonAuthFailed} {
+		c := dial(t, s)
+		priv, _ := ecdh.X25519().GenerateKey(rand.Reader)
+		c.send(AuthPacket{Token: token, Version: ProtocolVersion, Cookie: c.connect(), PublicKey: priv.PublicKey().Bytes()})
+		var ack AuthAckPacket
+		c.expect(PacketAuthAck, &ack)
+		if ack.Success || ack.Reason != want || ack.Message == "" || strings.Contains(ack.Message, "token") {
+			t.Errorf("ack = %+v, want %s", ack, want)
+		}
+	}
+}
+
+func TestPreviousVersionStaysPlaintext(t *testing.T) {
+	s := startServer(t)
+	c := dial(t, s)
+	c.send(AuthPacket{Token: "device-a", Version: 3, Cookie: c.connect()})
+	var ack AuthAckPacket
+	c.expect(PacketAuthAck, &ack)
+	if !ack.Success || ack.Version != 3 || ack.PublicKey != nil {
+		t.Fatalf("ack = %+v", ack)
+	}
+	c.sendRaw([]byte{byte(PacketPing)})
+	c.expect(PacketPong, decodeNothing{})
+}
@@ -0,0 +1,4 @@
+package network
+
+// sendmmsg(2), which package syscall does not define for amd64
+const sysSendmmsg = 307
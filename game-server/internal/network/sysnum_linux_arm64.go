@@ -0,0 +1,5 @@
+package network
+
+import "syscall"
+
+const sysSendmmsg = syscall.SYS_SENDMMSG
@@ -0,0 +1,87 @@
+// SKYBATTLE — Protocol Versioning
+// Clients send their protocol version and capability flags (declared in
+// protocol.json) in AuthPacket. The server normally accepts its own version
+// and the one before it, so players can keep playing while an update rolls
+// out, and strips anything an older client cannot parse out of the packets
+// it sends that client.
+package network
+
+import (
+	"fmt"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/game"
+)
+
+// unversionedProtocol is what a client that sends no version speaks: the
+// protocol as it shipped before the handshake carried one.
+const unversionedProtocol uint16 = 1
+
+// protocolCompat lists the versions the server still serves and the most
+// each can be sent. When bumping ProtocolVersion keep the previous entry
+// for the rollout and drop the one before it. Version 3 added the connect
+// handshake, which older clients cannot complete, so it has no predecessor.
+// Version 4 encrypts sessions (crypto.go); version 3 sessions stay in the
+// clear until it is dropped.
+var protocolCompat = map[uint16]Capabilities{
+	ProtocolVersion: CapInventory | CapActions,
+	3:               CapInventory | CapActions,
+}
+
+// minProtocolVersion is the oldest version in protocolCompat
+func minProtocolVersion() uint16 {
+	min := ProtocolVersion
+	for v := range protocolCompat {
+		if v < min {
+			min = v
+		}
+	}
+	return min
+}
+
+// negotiate settles the version and capabilities for a client. On failure
+// it returns the rejection reason and a message to show the player.
+func negotiate(version uint16, caps uint32) (uint16, Capabilities, string, string) {
+	if version == 0 {
+		version = unversionedProtocol
+	}
+	allowed, ok := protocolCompat[version]
+	switch {
+	case ok:
+		return version, allowed & Capabilities(caps), "", ""
+	case version > ProtocolVersion:
+		return version, 0, ReasonServerOutdated,
+			fmt.Sprintf("This server runs protocol %d and your game uses %d. Please try again once the servers have updated.", ProtocolVersion, version)
+	}
+	return version, 0, ReasonClientOutdated,
+		fmt.Sprintf("Your game is out of date (protocol %d, server needs %d or newer). Please update to keep playing.", version, minProtocolVersion())
+}
+
+// Has reports whether every capability in want was negotiated
+func (c Capabilities) Has(want Capabilities) bool {
+	return c&want == want
+}
+
+// For returns the world state as a client with caps can parse it: events
+// and pickups it does not know about are left out. The original is not
+// modified; unchanged slices are shared.
+func (w WorldStatePacket) For(caps Capabilities) WorldStatePacket {
+	if !caps.Has(CapActions) {
+		events := make([]game.MatchEvent, 0, len(w.Events))
+		for _, ev := range w.Events {
+			if ev.Type != "MELEE" && ev.Type != "THROW" {
+				events = append(events, ev)
+			}
+		}
+		w.Events = events
+	}
+	if !caps.Has(CapInventory) {
+		pickups := make([]game.Pickup, 0, len(w.Pickups))
+		for _, pk := range w.Pickups {
+			if !pk.Dropped {
+				pickups = append(pickups, pk)
+			}
+		}
+		w.Pickups = pickups
+	}
+	return w
+}
@@ -0,0 +1,69 @@
+package network
+
+import (
+	"testing"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/game"
+)
+
+func TestNegotiate(t *testing.T) {
+	all := uint32(CapInventory | CapActions)
+	cases := []struct {
+		name    string
+		version uint16
+		caps    uint32
+		want    Capabilities
+		reason  string
+	}{
+		{"current", ProtocolVersion, all, CapInventory | CapActions, ""},
+		{"current, partial caps", ProtocolVersion, uint32(CapInventory), CapInventory, ""},
+		{"unknown caps ignored", ProtocolVersion, all | 1<<31, CapInventory | CapActions, ""},
+		{"unversioned client", 0, 0, 0, ReasonClientOutdated},
+		{"unencrypted, during rollout", 3, all, CapInventory | CapActions, ""},
+		{"before the handshake", 2, all, 0, ReasonClientOutdated},
+		{"too new", ProtocolVersion + 1, all, 0, ReasonServerOutdated},
+	}
+	for _, c := range cases {
+		_, caps, reason, msg := negotiate(c.version, c.caps)
+		if caps != c.want || reason != c.reason || (reason != "" && msg == "") {
+			t.Errorf("%s: caps %b reason %q, want %b %q", c.name, caps, reason, c.want, c.reason)
+		}
+	}
+	if minProtocolVersion() != 3 {
+		t.Fatalf("min version %d", minProtocolVersion())
+	}
+}
+
+func TestNegotiateDuringRollout(t *testing.T) {
+	// The previous version stays in the table, limited to what it understood
+	prev := ProtocolVersion - 1
+	saved := protocolCompat[prev]
+	protocolCompat[prev] = CapInventory
+	defer func() { protocolCompat[prev] = saved }()
+
+	version, caps, reason, _ := negotiate(prev, uint32(CapInventory|CapActions))
+	if version != prev || caps != CapInventory || reason != "" {
+		t.Fatalf("previous client: version %d caps %b reason %q", version, caps, reason)
+	}
+	if minProtocolVersion() != prev {
+		t.Fatalf("min version %d, want %d", minProtocolVersion(), prev)
+	}
+}
+
+func TestWorldStateForOlderClients(t *testing.T) {
+	state := WorldStatePacket{
+		Pickups: []game.Pickup{{ID: 1}, {ID: 10001, Dropped: true}},
+		Events:  []game.MatchEvent{{Type: "KILL"}, {Type: "MELEE"}, {Type: "THROW"}},
+	}
+
+	legacy := state.For(0)
+	if len(legacy.Pickups) != 1 || len(legacy.Events) != 1 || legacy.Events[0].Type != "KILL" {
+		t.Fatalf("legacy state = %+v", legacy)
+	}
+	if full := state.For(CapInventory | CapActions); len(full.Pickups) != 2 || len(full.Events) != 3 {
+		t.Fatalf("full state = %+v", full)
+	}
+	if len(state.Events) != 3 || len(state.Pickups) != 2 {
+		t.Fatal("For modified the original")
+	}
+}
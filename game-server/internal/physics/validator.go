@@ -23,10 +23,9 @@ func ValidateMove(oldPos, newPos game.Vec2, oldVel, newVel game.Vec2, deltaTime
 }
 
 // ValidateFireRate checks a player hasn't fired faster than their weapon allows.
-// Returns true if fire is valid.
-func ValidateFireRate(weaponID game.WeaponID, lastFireTime time.Time) bool {
-	spec, ok := game.Weapons[weaponID]
-	if !ok || spec.FireRatePerSec <= 0 {
+// Returns true if fire is valid. spec is the room's spec for the weapon (zero if unknown).
+func ValidateFireRate(spec game.WeaponSpec, lastFireTime time.Time) bool {
+	if spec.ID == 0 || spec.FireRatePerSec <= 0 {
 		return true // unknown weapon or non-firing weapon — pass
 	}
 	if lastFireTime.IsZero() {
@@ -49,9 +48,8 @@ func ValidateAimAngle(deg float32) bool {
 }
 
 // ValidateDamage checks that claimed damage matches weapon spec.
-func ValidateDamage(weaponID game.WeaponID, claimedDamage int) bool {
-	spec, ok := game.Weapons[weaponID]
-	if !ok { return false }
+func ValidateDamage(spec game.WeaponSpec, claimedDamage int) bool {
+	if spec.ID == 0 { return false }
 	maxDamage := int(spec.DamagePerShot * 1.1) // allow 10% float tolerance
 	return claimedDamage > 0 && claimedDamage <= maxDamage
 }
@@ -0,0 +1,112 @@
+package protogen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// CSharp renders NetworkProtocol.cs for the Unity client: ProtocolInfo with
+// the version and constants, the Capabilities flags, both packet type
+// enums and a [Serializable] struct per packet body and nested type.
+func CSharp(s *Schema, source string) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, header, source)
+	b.WriteString("using System;\nusing UnityEngine;\n\n")
+	fmt.Fprintf(&b, "namespace %s\n{\n", s.CSNamespace)
+
+	b.WriteString("    // ── Protocol Version ───────────────────────────────────────────────────────\n\n")
+	b.WriteString("    public static class ProtocolInfo\n    {\n")
+	fmt.Fprintf(&b, "        public const ushort Version = %d;\n", s.Version)
+	supported := "Capabilities.None"
+	if len(s.Capabilities) > 0 {
+		names := make([]string, len(s.Capabilities))
+		for i, c := range s.Capabilities {
+			names[i] = "Capabilities." + c.Name
+		}
+		supported = strings.Join(names, " | ")
+	}
+	fmt.Fprintf(&b, "        public const Capabilities Supported = %s;\n", supported)
+	if len(s.Constants) > 0 {
+		b.WriteString("\n")
+	}
+	var lines []csLine
+	for _, c := range s.Constants {
+		lines = append(lines, csLine{fmt.Sprintf("public const string %s = %q;", c.Name, c.Value), c.Doc})
+	}
+	writeCSLines(&b, lines)
+	b.WriteString("    }\n\n")
+
+	b.WriteString("    [Flags]\n    public enum Capabilities : uint\n    {\n")
+	lines = []csLine{{"None = 0,", ""}}
+	for i, c := range s.Capabilities {
+		lines = append(lines, csLine{fmt.Sprintf("%s = 1 << %d,", c.Name, i), c.Doc})
+	}
+	writeCSLines(&b, lines)
+	b.WriteString("    }\n\n")
+
+	b.WriteString("    // ── Client to Server Packet Types ──────────────────────────────────────────\n\n")
+	csPacketEnum(&b, "PacketType", s.Client)
+	b.WriteString("    // ── Server to Client Packet Types ──────────────────────────────────────────\n\n")
+	csPacketEnum(&b, "ServerPacketType", s.Server)
+
+	b.WriteString("    // ── Packet Bodies ──────────────────────────────────────────────────────────\n")
+	for _, st := range s.Structs {
+		if st.CSBuiltin {
+			continue
+		}
+		b.WriteString("\n")
+		if st.Doc != "" {
+			fmt.Fprintf(&b, "    // %s %s\n", st.csName(), st.Doc)
+		}
+		fmt.Fprintf(&b, "    [Serializable]\n    public struct %s\n    {\n", st.csName())
+		lines := make([]csLine, len(st.Fields))
+		for i, f := range st.Fields {
+			lines[i] = csLine{fmt.Sprintf("public %s %s;", s.csType(f.Type), f.Key), f.Doc}
+		}
+		writeCSLines(&b, lines)
+		b.WriteString("    }\n")
+	}
+	b.WriteString("}\n")
+	return b.Bytes()
+}
+
+func csPacketEnum(b *bytes.Buffer, typ string, packets []Packet) {
+	fmt.Fprintf(b, "    public enum %s : byte\n    {\n", typ)
+	for _, p := range packets {
+		fmt.Fprintf(b, "        %s = %d,\n", p.Name, p.ID)
+	}
+	b.WriteString("    }\n\n")
+}
+
+func (s *Schema) csType(t string) string {
+	if elem, ok := strings.CutPrefix(t, "[]"); ok {
+		return s.csType(elem) + "[]"
+	}
+	if p, ok := primitives[t]; ok {
+		return p[1]
+	}
+	return s.byName[t].csName()
+}
+
+// csLine is a member declaration and its trailing comment
+type csLine struct {
+	code, doc string
+}
+
+// writeCSLines indents a block of members and lines up their comments
+func writeCSLines(b *bytes.Buffer, lines []csLine) {
+	width := 0
+	for _, l := range lines {
+		if l.doc != "" && len(l.code) > width {
+			width = len(l.code)
+		}
+	}
+	for _, l := range lines {
+		if l.doc == "" {
+			fmt.Fprintf(b, "        %s\n", l.code)
+			continue
+		}
+		fmt.Fprintf(b, "        %-*s // %s\n", width, l.code, l.doc)
+	}
+}
@@ -0,0 +1,144 @@
+package protogen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+)
+
+const header = "// Code generated by protogen from %s. DO NOT EDIT.\n"
+
+// Go renders the packet code for the server: version, capabilities and
+// constants, the packet type enums, and each packet body with Encode and
+// EncodeTo (type byte plus msgpack) and a Decode method. source names the
+// schema file in the header.
+func Go(s *Schema, source string) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, header+"\n", source)
+	fmt.Fprintf(&b, "package %s\n\n", s.GoPackage)
+
+	imports := []string{`"bytes"`, ``, `"github.com/vmihailenco/msgpack/v5"`}
+	used := map[string]bool{}
+	for _, st := range s.Structs {
+		for _, f := range st.Fields {
+			if ref := s.byName[strings.TrimPrefix(f.Type, "[]")]; ref != nil && ref.external() && !st.external() {
+				pkg, _, _ := strings.Cut(ref.Go, ".")
+				used[pkg] = true
+			}
+		}
+	}
+	var pkgs []string
+	for pkg := range used {
+		pkgs = append(pkgs, fmt.Sprintf("%q", s.GoImports[pkg]))
+	}
+	sort.Strings(pkgs)
+	imports = append(imports, pkgs...)
+	fmt.Fprintf(&b, "import (\n%s\n)\n\n", strings.Join(imports, "\n"))
+
+	b.WriteString("// ProtocolVersion is bumped whenever a packet changes shape in a way an\n// older client would misparse\n")
+	fmt.Fprintf(&b, "const ProtocolVersion uint16 = %d\n\n", s.Version)
+
+	b.WriteString("// Capabilities are optional protocol features, negotiated per session\ntype Capabilities uint32\n\n")
+	if len(s.Capabilities) > 0 {
+		b.WriteString("const (\n")
+		for i, c := range s.Capabilities {
+			fmt.Fprintf(&b, "Cap%s Capabilities = 1 << %d%s\n", c.Name, i, goComment(c.Doc))
+		}
+		b.WriteString(")\n\n")
+	}
+	if len(s.Constants) > 0 {
+		b.WriteString("const (\n")
+		for _, c := range s.Constants {
+			fmt.Fprintf(&b, "%s = %q%s\n", c.Name, c.Value, goComment(c.Doc))
+		}
+		b.WriteString(")\n\n")
+	}
+
+	b.WriteString("// ── Client to Server Packets ──────────────────────────────────────────────────\n\n")
+	goPacketEnum(&b, "PacketType", s.Client)
+	b.WriteString("// ── Server to Client Packets ──────────────────────────────────────────────────\n\n")
+	goPacketEnum(&b, "ServerPacketType", s.Server)
+
+	b.WriteString("// ── Packet Bodies ────────────────────────────────────────────────────────────\n\n")
+	packetOf := map[string]string{}
+	for _, p := range s.Client {
+		packetOf[p.Body] = "Packet" + p.Name
+	}
+	for _, p := range s.Server {
+		packetOf[p.Body] = "Packet" + p.Name
+	}
+	for _, st := range s.Structs {
+		if st.external() {
+			continue
+		}
+		if st.Doc != "" {
+			fmt.Fprintf(&b, "// %s %s\n", st.goName(), st.Doc)
+		}
+		fmt.Fprintf(&b, "type %s struct {\n", st.goName())
+		for _, f := range st.Fields {
+			fmt.Fprintf(&b, "%s %s `msgpack:%q`%s\n", f.Name, s.goType(f.Type), f.Key, goComment(f.Doc))
+		}
+		b.WriteString("}\n\n")
+		if pt, ok := packetOf[st.Name]; ok {
+			fmt.Fprintf(&b, "func (p %s) Encode() ([]byte, error) { return encodePacket(byte(%s), p) }\n\n", st.goName(), pt)
+			fmt.Fprintf(&b, "func (p %s) EncodeTo(buf *bytes.Buffer) error { return writePacket(buf, byte(%s), p) }\n\n", st.goName(), pt)
+			fmt.Fprintf(&b, "func (p *%s) Decode(payload []byte) error { return msgpack.Unmarshal(payload, p) }\n\n", st.goName())
+		}
+	}
+
+	b.WriteString(`// encodePacket prefixes a msgpack body with its packet type
+func encodePacket(t byte, body interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writePacket(&buf, t, body); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writePacket appends a packet to buf with a pooled encoder, so callers
+// that reuse buf encode without allocating
+func writePacket(buf *bytes.Buffer, t byte, body interface{}) error {
+	buf.WriteByte(t)
+	enc := msgpack.GetEncoder()
+	defer msgpack.PutEncoder(enc)
+	enc.Reset(buf)
+	return enc.Encode(body)
+}
+`)
+	return format.Source(b.Bytes())
+}
+
+func goPacketEnum(b *bytes.Buffer, typ string, packets []Packet) {
+	fmt.Fprintf(b, "type %s uint8\n\n", typ)
+	if len(packets) > 0 {
+		b.WriteString("const (\n")
+		for _, p := range packets {
+			fmt.Fprintf(b, "Packet%s %s = %d\n", p.Name, typ, p.ID)
+		}
+		b.WriteString(")\n\n")
+	}
+	fmt.Fprintf(b, "func (t %s) String() string {\nswitch t {\n", typ)
+	for _, p := range packets {
+		fmt.Fprintf(b, "case Packet%s:\nreturn %q\n", p.Name, p.Label)
+	}
+	b.WriteString("}\nreturn \"unknown\"\n}\n\n")
+}
+
+func (s *Schema) goType(t string) string {
+	if elem, ok := strings.CutPrefix(t, "[]"); ok {
+		return "[]" + s.goType(elem)
+	}
+	if p, ok := primitives[t]; ok {
+		return p[0]
+	}
+	return s.byName[t].goName()
+}
+
+func goComment(doc string) string {
+	if doc == "" {
+		return ""
+	}
+	return " // " + doc
+}
@@ -0,0 +1,81 @@
+package protogen
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files")
+
+func TestGolden(t *testing.T) {
+	s, err := Load("testdata/example.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	goSrc, err := Go(s, "example.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "testdata/example.go.golden", goSrc)
+	golden(t, "testdata/example.cs.golden", CSharp(s, "example.json"))
+}
+
+func golden(t *testing.T, path string, got []byte) {
+	t.Helper()
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s is stale; run go test ./internal/protogen -update and review the diff\n%s", path, got)
+	}
+}
+
+func TestParseRejectsBadSchemas(t *testing.T) {
+	base := `"go_package": "p", "cs_namespace": "N", "version": 1`
+	for name, body := range map[string]string{
+		"no version":     `"go_package": "p", "cs_namespace": "N"`,
+		"duplicate id":   base + `, "client_packets": [{"name": "A", "id": 1, "label": "a"}, {"name": "B", "id": 1, "label": "b"}]`,
+		"duplicate name": base + `, "client_packets": [{"name": "A", "id": 1, "label": "a"}], "server_packets": [{"name": "A", "id": 10, "label": "a"}]`,
+		"id range":       base + `, "server_packets": [{"name": "A", "id": 256, "label": "a"}]`,
+		"no label":       base + `, "server_packets": [{"name": "A", "id": 10}]`,
+		"missing body":   base + `, "server_packets": [{"name": "A", "id": 10, "label": "a", "body": "Nope"}]`,
+		"external body": base + `, "go_imports": {"x": "x"}, "structs": [{"name": "S", "go": "x.S"}],
+			"server_packets": [{"name": "A", "id": 10, "label": "a", "body": "S"}]`,
+		"no import":     base + `, "structs": [{"name": "S", "go": "x.S"}]`,
+		"duplicate key": base + `, "structs": [{"name": "S", "fields": [{"name": "A", "key": "a", "type": "int"}, {"name": "B", "key": "a", "type": "int"}]}]`,
+		"unknown type":  base + `, "structs": [{"name": "S", "fields": [{"name": "A", "key": "a", "type": "[]int64"}]}]`,
+	} {
+		if _, err := Parse([]byte("{" + body + "}")); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
+
+func TestEveryFieldIsGenerated(t *testing.T) {
+	s, err := Load("testdata/example.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	goSrc, _ := Go(s, "example.json")
+	cs := string(CSharp(s, "example.json"))
+	for _, st := range s.Structs {
+		for _, f := range st.Fields {
+			if !st.external() && !strings.Contains(string(goSrc), `msgpack:"`+f.Key+`"`) {
+				t.Errorf("Go %s is missing %s", st.Name, f.Key)
+			}
+			if !st.CSBuiltin && !strings.Contains(cs, " "+f.Key+";") {
+				t.Errorf("C# %s is missing %s", st.Name, f.Key)
+			}
+		}
+	}
+}
@@ -0,0 +1,198 @@
+// SKYBATTLE — Protocol Schema
+// protocol.json is the single description of the wire protocol: packet type
+// ids, msgpack keys and field types. The Go packet code in internal/network
+// and the Unity client's NetworkProtocol.cs are both generated from it by
+// cmd/protogen, so the two sides cannot drift apart.
+package protogen
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+type Schema struct {
+	GoPackage    string            `json:"go_package"`
+	GoImports    map[string]string `json:"go_imports"` // package name -> import path, for external types
+	CSNamespace  string            `json:"cs_namespace"`
+	Version      uint16            `json:"version"`
+	Capabilities []Capability      `json:"capabilities"`
+	Constants    []Constant        `json:"constants"`
+	Client       []Packet          `json:"client_packets"`
+	Server       []Packet          `json:"server_packets"`
+	Structs      []Struct          `json:"structs"`
+
+	byName map[string]*Struct
+}
+
+// Capability is one bit of the negotiated feature set, in declaration order
+type Capability struct {
+	Name string `json:"name"`
+	Doc  string `json:"doc"`
+}
+
+// Constant is a string both sides agree on, e.g. a rejection reason
+type Constant struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	Doc   string `json:"doc"`
+}
+
+// Packet is a type byte; Body names its msgpack payload, empty for bare packets
+type Packet struct {
+	Name  string `json:"name"`
+	ID    int    `json:"id"`
+	Label string `json:"label"` // metrics label
+	Body  string `json:"body"`
+}
+
+// Struct is a packet body or a type nested in one. Go and CS rename it on
+// that side; a Go name with a package qualifier is an existing type that is
+// checked against the schema rather than generated, and CSBuiltin marks a
+// type the client already has (Unity's Vector2).
+type Struct struct {
+	Name      string  `json:"name"`
+	Doc       string  `json:"doc"`
+	Go        string  `json:"go"`
+	CS        string  `json:"cs"`
+	CSBuiltin bool    `json:"cs_builtin"`
+	Fields    []Field `json:"fields"`
+}
+
+type Field struct {
+	Name string `json:"name"` // Go field name
+	Key  string `json:"key"`  // msgpack key, also the C# field name
+	Type string `json:"type"` // a primitive or struct name, "[]" prefixed for arrays
+	Doc  string `json:"doc"`
+}
+
+// primitives maps schema types to their Go and C# spellings
+var primitives = map[string][2]string{
+	"bool":    {"bool", "bool"},
+	"string":  {"string", "string"},
+	"int":     {"int", "int"},
+	"uint8":   {"uint8", "byte"},
+	"uint16":  {"uint16", "ushort"},
+	"uint32":  {"uint32", "uint"},
+	"float32": {"float32", "float"},
+	"bytes":   {"[]byte", "byte[]"},
+}
+
+// Load reads and validates a schema file
+func Load(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse decodes and validates a schema
+func Parse(data []byte) (*Schema, error) {
+	var s Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (s *Schema) validate() error {
+	if s.GoPackage == "" || s.CSNamespace == "" {
+		return fmt.Errorf("go_package and cs_namespace are required")
+	}
+	if s.Version == 0 {
+		return fmt.Errorf("version must be at least 1")
+	}
+	if len(s.Capabilities) > 32 {
+		return fmt.Errorf("%d capabilities, at most 32 fit the flags", len(s.Capabilities))
+	}
+
+	s.byName = make(map[string]*Struct, len(s.Structs))
+	for i := range s.Structs {
+		st := &s.Structs[i]
+		if st.Name == "" {
+			return fmt.Errorf("struct %d has no name", i)
+		}
+		if s.byName[st.Name] != nil {
+			return fmt.Errorf("struct %s declared twice", st.Name)
+		}
+		if pkg, _, ok := strings.Cut(st.Go, "."); ok && s.GoImports[pkg] == "" {
+			return fmt.Errorf("struct %s: no go_imports entry for %s", st.Name, pkg)
+		}
+		s.byName[st.Name] = st
+	}
+	for _, st := range s.Structs {
+		names, keys := map[string]bool{}, map[string]bool{}
+		for _, f := range st.Fields {
+			if f.Name == "" || f.Key == "" {
+				return fmt.Errorf("struct %s: field needs a name and a key", st.Name)
+			}
+			if names[f.Name] || keys[f.Key] {
+				return fmt.Errorf("struct %s: duplicate field %s (%q)", st.Name, f.Name, f.Key)
+			}
+			names[f.Name], keys[f.Key] = true, true
+			elem := strings.TrimPrefix(f.Type, "[]")
+			if _, ok := primitives[elem]; !ok && s.byName[elem] == nil {
+				return fmt.Errorf("struct %s: field %s has unknown type %q", st.Name, f.Name, f.Type)
+			}
+		}
+	}
+
+	// Ids are per direction; names become Go constants in one package
+	bodies, names := map[string]bool{}, map[string]bool{}
+	for dir, packets := range map[string][]Packet{"client": s.Client, "server": s.Server} {
+		ids := map[int]bool{}
+		for _, p := range packets {
+			if p.ID < 1 || p.ID > 255 {
+				return fmt.Errorf("%s packet %s: id %d out of range", dir, p.Name, p.ID)
+			}
+			if ids[p.ID] || names[p.Name] {
+				return fmt.Errorf("%s packet %s (%d) declared twice", dir, p.Name, p.ID)
+			}
+			ids[p.ID], names[p.Name] = true, true
+			if p.Label == "" {
+				return fmt.Errorf("%s packet %s has no label", dir, p.Name)
+			}
+			if p.Body == "" {
+				continue
+			}
+			st := s.byName[p.Body]
+			if st == nil || st.external() {
+				return fmt.Errorf("%s packet %s: body %q must be a generated struct", dir, p.Name, p.Body)
+			}
+			if bodies[p.Body] {
+				return fmt.Errorf("%s packet %s: body %s is used by another packet", dir, p.Name, p.Body)
+			}
+			bodies[p.Body] = true
+		}
+	}
+	return nil
+}
+
+// Struct looks a struct up by schema name
+func (s *Schema) Struct(name string) *Struct {
+	return s.byName[name]
+}
+
+// external reports whether the Go type already exists elsewhere
+func (st *Struct) external() bool {
+	return strings.Contains(st.Go, ".")
+}
+
+func (st *Struct) goName() string {
+	if st.Go != "" {
+		return st.Go
+	}
+	return st.Name
+}
+
+func (st *Struct) csName() string {
+	if st.CS != "" {
+		return st.CS
+	}
+	return st.Name
+}
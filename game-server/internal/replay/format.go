@@ -0,0 +1,64 @@
+// SKYBATTLE — Replay File Format
+// A replay is a gzip stream holding a magic/version prefix, one msgpack Header,
+// then one msgpack Frame per simulated tick until the match ends.
+package replay
+
+import (
+	"time"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/game"
+)
+
+const (
+	Magic     = "SKYR"
+	Version   = 1
+	Extension = ".skyr"
+)
+
+type Header struct {
+	Version      int          `msgpack:"v"`
+	MatchID      string       `msgpack:"mid"`
+	GameMode     string       `msgpack:"mode"`
+	MapID        string       `msgpack:"map"`
+	TickRate     int          `msgpack:"rate"`
+	TimeLimitSec int          `msgpack:"tlim"`
+	KillLimit    int          `msgpack:"klim"`
+	StartedAt    time.Time    `msgpack:"start"`
+	Players      []PlayerInfo `msgpack:"players"` // roster at match start
+}
+
+type PlayerInfo struct {
+	ID     int    `msgpack:"id"`
+	UserID string `msgpack:"uid"`
+	Name   string `msgpack:"name"`
+	Team   string `msgpack:"team"`
+	IsBot  bool   `msgpack:"bot"`
+}
+
+type InputRecord struct {
+	PlayerID int              `msgpack:"pid"`
+	Input    game.PlayerInput `msgpack:"in"`
+}
+
+// PlayerSnapshot is the post-tick authoritative state of one player
+type PlayerSnapshot struct {
+	ID          int       `msgpack:"id"`
+	Position    game.Vec2 `msgpack:"pos"`
+	Velocity    game.Vec2 `msgpack:"vel"`
+	AimAngleDeg float32   `msgpack:"aim"`
+	Health      int       `msgpack:"hp"`
+	JetpackFuel float32   `msgpack:"fuel"`
+	IsFlying    bool      `msgpack:"fly"`
+	IsAlive     bool      `msgpack:"alive"`
+}
+
+// Frame holds everything that happened during a single tick: roster changes,
+// every input applied (humans and bots), events raised and resulting state.
+type Frame struct {
+	Tick    int               `msgpack:"t"`
+	Joined  []PlayerInfo      `msgpack:"join,omitempty"`
+	Left    []int             `msgpack:"left,omitempty"`
+	Inputs  []InputRecord     `msgpack:"in,omitempty"`
+	Events  []game.MatchEvent `msgpack:"ev,omitempty"`
+	Players []PlayerSnapshot  `msgpack:"p"`
+}
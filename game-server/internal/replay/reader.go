@@ -0,0 +1,185 @@
+// SKYBATTLE — Replay Reader & Match Reconstruction
+package replay
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/game"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type Reader struct {
+	Header Header
+
+	file *os.File
+	gz   *gzip.Reader
+	dec  *msgpack.Decoder
+}
+
+func Open(path string) (*Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	r.file = f
+	return r, nil
+}
+
+// NewReader reads the replay header from src; frames are then pulled with Next.
+func NewReader(src io.Reader) (*Reader, error) {
+	gz, err := gzip.NewReader(bufio.NewReader(src))
+	if err != nil {
+		return nil, fmt.Errorf("not a replay file: %w", err)
+	}
+
+	magic := make([]byte, len(Magic))
+	if _, err := io.ReadFull(gz, magic); err != nil || string(magic) != Magic {
+		return nil, fmt.Errorf("not a replay file: bad magic")
+	}
+
+	r := &Reader{gz: gz, dec: msgpack.NewDecoder(gz)}
+	if err := r.dec.Decode(&r.Header); err != nil {
+		return nil, fmt.Errorf("decode replay header: %w", err)
+	}
+	if r.Header.Version != Version {
+		return nil, fmt.Errorf("unsupported replay version %d", r.Header.Version)
+	}
+	return r, nil
+}
+
+// Next returns the next frame, or io.EOF once the replay is exhausted.
+// A file truncated by a server crash ends cleanly at the last whole frame.
+func (r *Reader) Next() (*Frame, error) {
+	var f Frame
+	if err := r.dec.Decode(&f); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+	return &f, nil
+}
+
+func (r *Reader) Close() error {
+	r.gz.Close()
+	if r.file != nil {
+		return r.file.Close()
+	}
+	return nil
+}
+
+// ── Reconstruction ────────────────────────────────────────────────────────────
+
+type PlayerStats struct {
+	PlayerInfo
+	Kills      int
+	Deaths     int
+	ShotsFired int
+	InputCount int
+	JoinedTick int
+	LeftTick   int // 0 while still connected at the end
+	Final      PlayerSnapshot
+}
+
+// Match is the state rebuilt by applying every frame of a replay in order.
+type Match struct {
+	Header    Header
+	LastTick  int
+	Players   map[int]*PlayerStats
+	Timeline  []game.MatchEvent
+	TeamKills map[string]int
+}
+
+// Apply folds one frame into the reconstructed match. Applying the same
+// frames in the same order always yields the same Match.
+func (m *Match) Apply(f *Frame) {
+	m.LastTick = f.Tick
+
+	for _, info := range f.Joined {
+		m.Players[info.ID] = &PlayerStats{PlayerInfo: info, JoinedTick: f.Tick}
+	}
+	for _, id := range f.Left {
+		if ps, ok := m.Players[id]; ok {
+			ps.LeftTick = f.Tick
+		}
+	}
+	for _, in := range f.Inputs {
+		if ps, ok := m.Players[in.PlayerID]; ok {
+			ps.InputCount++
+			if in.Input.Firing {
+				ps.ShotsFired++
+			}
+		}
+	}
+	for _, ev := range f.Events {
+		m.Timeline = append(m.Timeline, ev)
+		if ev.Type != "KILL" {
+			continue
+		}
+		if actor, ok := m.Players[ev.ActorID]; ok {
+			actor.Kills++
+			m.TeamKills[actor.Team]++
+		}
+		if target, ok := m.Players[ev.TargetID]; ok {
+			target.Deaths++
+		}
+	}
+	for _, snap := range f.Players {
+		if ps, ok := m.Players[snap.ID]; ok {
+			ps.Final = snap
+		}
+	}
+}
+
+// Reconstruct reads every remaining frame from r and returns the rebuilt match.
+func Reconstruct(r *Reader) (*Match, error) {
+	m := &Match{
+		Header:    r.Header,
+		Players:   make(map[int]*PlayerStats),
+		TeamKills: make(map[string]int),
+	}
+	for _, info := range r.Header.Players {
+		m.Players[info.ID] = &PlayerStats{PlayerInfo: info}
+	}
+
+	for {
+		f, err := r.Next()
+		if err == io.EOF {
+			return m, nil
+		}
+		if err != nil {
+			return m, err
+		}
+		m.Apply(f)
+	}
+}
+
+// Ranking returns players ordered by kills, then fewest deaths, then ID.
+func (m *Match) Ranking() []*PlayerStats {
+	list := make([]*PlayerStats, 0, len(m.Players))
+	for _, ps := range m.Players {
+		list = append(list, ps)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if a.Kills != b.Kills {
+			return a.Kills > b.Kills
+		}
+		if a.Deaths != b.Deaths {
+			return a.Deaths < b.Deaths
+		}
+		return a.ID < b.ID
+	})
+	return list
+}
@@ -0,0 +1,157 @@
+package replay
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/game"
+)
+
+func testHeader() Header {
+	return Header{
+		MatchID:   "m-1",
+		GameMode:  "TDM",
+		MapID:     "outpost",
+		TickRate:  2, // flush every two frames
+		StartedAt: time.Unix(1700000000, 0).UTC(),
+		Players: []PlayerInfo{
+			{ID: 1, UserID: "u1", Name: "Alice", Team: "red"},
+			{ID: 2, UserID: "u2", Name: "Bob", Team: "blue"},
+		},
+	}
+}
+
+// testFrame is tick t of a match where Alice fires every tick and kills Bob
+// on every third
+func testFrame(t int) *Frame {
+	f := &Frame{
+		Tick:   t,
+		Inputs: []InputRecord{{PlayerID: 1, Input: game.PlayerInput{Horizontal: 1, Firing: true}}},
+		Players: []PlayerSnapshot{
+			{ID: 1, Position: game.Vec2{X: float32(t)}, Health: 100, IsAlive: true},
+			{ID: 2, Health: 100, IsAlive: true},
+		},
+	}
+	if t%3 == 0 {
+		f.Events = []game.MatchEvent{{Tick: t, Type: "KILL", ActorID: 1, TargetID: 2}}
+	}
+	if t == 4 {
+		f.Joined = []PlayerInfo{{ID: 3, UserID: "u3", Name: "Carol", Team: "blue"}}
+	}
+	return f
+}
+
+func writeReplay(t *testing.T, path string, frames int) *Writer {
+	t.Helper()
+	w, err := Create(path, testHeader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= frames; i++ {
+		if err := w.WriteFrame(testFrame(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return w
+}
+
+func reconstruct(t *testing.T, path string) *Match {
+	t.Helper()
+	r, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	m, err := Reconstruct(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestWriteThenReconstruct(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "matches", "m-1"+Extension)
+	if err := writeReplay(t, path, 9).Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	m := reconstruct(t, path)
+	h := m.Header
+	if h.Version != Version || h.MatchID != "m-1" || h.TickRate != 2 || !h.StartedAt.Equal(testHeader().StartedAt) || len(h.Players) != 2 {
+		t.Fatalf("header = %+v", h)
+	}
+	if m.LastTick != 9 {
+		t.Fatalf("last tick %d, want 9", m.LastTick)
+	}
+	alice, bob, carol := m.Players[1], m.Players[2], m.Players[3]
+	if alice.Kills != 3 || alice.ShotsFired != 9 || alice.InputCount != 9 || alice.Final.Position.X != 9 {
+		t.Errorf("alice = %+v", alice)
+	}
+	if bob.Deaths != 3 || m.TeamKills["red"] != 3 {
+		t.Errorf("bob = %+v, team kills %v", bob, m.TeamKills)
+	}
+	if carol == nil || carol.JoinedTick != 4 {
+		t.Errorf("carol = %+v", carol)
+	}
+	if rank := m.Ranking(); rank[0].ID != 1 || rank[len(rank)-1].ID != 2 {
+		t.Errorf("ranking starts %d, ends %d", rank[0].ID, rank[len(rank)-1].ID)
+	}
+}
+
+// A server that dies mid-match leaves a file without its gzip trailer; it
+// reads back up to the last flushed frame
+func TestTruncatedReplayEndsAtLastFlush(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "m-1"+Extension)
+	w := writeReplay(t, path, 5) // flushed after frames 2 and 4
+	defer w.Close()
+
+	if m := reconstruct(t, path); m.LastTick != 4 || m.Players[1].Kills != 1 {
+		t.Fatalf("last tick %d with %d kills, want 4 and 1", m.LastTick, m.Players[1].Kills)
+	}
+
+	// Cut mid-frame, as a full disk would
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data[:len(data)-3], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if m := reconstruct(t, path); m.LastTick > 4 {
+		t.Fatalf("last tick %d from a cut file", m.LastTick)
+	}
+}
+
+func TestCorruptReplayRejected(t *testing.T) {
+	gzipped := func(s string) []byte {
+		var b bytes.Buffer
+		gz := gzip.NewWriter(&b)
+		gz.Write([]byte(s))
+		gz.Close()
+		return b.Bytes()
+	}
+	path := filepath.Join(t.TempDir(), "m-1"+Extension)
+	if err := writeReplay(t, path, 0).Close(); err != nil {
+		t.Fatal(err)
+	}
+	headerOnly, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, data := range map[string][]byte{
+		"empty":       nil,
+		"not gzip":    []byte("SKYR but not compressed"),
+		"wrong magic": gzipped("JUNK"),
+		"no header":   gzipped(Magic),
+		"cut header":  headerOnly[:len(headerOnly)/2],
+	} {
+		if _, err := NewReader(bytes.NewReader(data)); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
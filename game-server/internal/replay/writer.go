@@ -0,0 +1,83 @@
+// SKYBATTLE — Replay Writer
+package replay
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Writer appends frames to a replay file as the match is simulated.
+// It is not safe for concurrent use; the owning room serialises calls.
+type Writer struct {
+	file   *os.File
+	buf    *bufio.Writer
+	gz     *gzip.Writer
+	enc    *msgpack.Encoder
+	flushN int
+	frames int
+}
+
+// Create opens path for writing and emits the header. Frames are flushed to
+// disk roughly once per second of match time so a crash loses little.
+func Create(path string, h Header) (*Writer, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Writer{file: f, buf: bufio.NewWriter(f), flushN: h.TickRate}
+	w.gz = gzip.NewWriter(w.buf)
+	w.enc = msgpack.NewEncoder(w.gz)
+	if w.flushN <= 0 {
+		w.flushN = 30
+	}
+
+	h.Version = Version
+	if _, err := w.gz.Write([]byte(Magic)); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := w.enc.Encode(&h); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("encode replay header: %w", err)
+	}
+	return w, nil
+}
+
+func (w *Writer) WriteFrame(f *Frame) error {
+	if err := w.enc.Encode(f); err != nil {
+		return fmt.Errorf("encode replay frame %d: %w", f.Tick, err)
+	}
+	w.frames++
+	if w.frames%w.flushN == 0 {
+		return w.flush()
+	}
+	return nil
+}
+
+func (w *Writer) flush() error {
+	if err := w.gz.Flush(); err != nil {
+		return err
+	}
+	return w.buf.Flush()
+}
+
+func (w *Writer) Close() error {
+	if err := w.gz.Close(); err != nil {
+		w.file.Close()
+		return err
+	}
+	if err := w.buf.Flush(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
@@ -0,0 +1,258 @@
+// SKYBATTLE — Match Reporting
+// Delivers finished match results to the profile service (POST /v1/matches)
+// from a background queue so room tick loops never block on HTTP.
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/room"
+)
+
+// MatchReport mirrors the body accepted by the profile service's POST /v1/matches
+type MatchReport struct {
+	MatchID         string         `json:"match_id"`
+	GameMode        string         `json:"game_mode"`
+	MapID           string         `json:"map_id"`
+	ServerRegion    string         `json:"server_region,omitempty"`
+	StartedAt       time.Time      `json:"started_at"`
+	EndedAt         time.Time      `json:"ended_at"`
+	DurationSeconds int            `json:"duration_seconds"`
+	Players         []PlayerReport `json:"players"`
+}
+
+type PlayerReport struct {
+	UserID      string `json:"user_id"`
+	Team        string `json:"team"`
+	Kills       int    `json:"kills"`
+	Deaths      int    `json:"deaths"`
+	DamageDealt int    `json:"damage_dealt"`
+	XPEarned    int    `json:"xp_earned"`
+	CoinsEarned int    `json:"coins_earned"`
+	Won         bool   `json:"won"`
+}
+
+// Reward constants (Phase 1 placeholder economy)
+const (
+	baseXP        = 100
+	xpPerKill     = 10
+	winXP         = 50
+	baseCoins     = 10
+	coinsPerKill  = 2
+	winCoins      = 20
+	maxSendTries  = 3
+	queueCapacity = 256
+)
+
+// FromResult converts a room result into the profile service payload.
+// Bots have no account, so they are left out of the report, and kills on
+// bots are neither reported nor paid for.
+func FromResult(res room.MatchResult) MatchReport {
+	rep := MatchReport{
+		MatchID:         res.MatchID,
+		GameMode:        res.GameMode,
+		MapID:           res.MapID,
+		StartedAt:       res.StartedAt,
+		EndedAt:         res.EndedAt,
+		DurationSeconds: int(res.EndedAt.Sub(res.StartedAt).Seconds()),
+		Players:         []PlayerReport{},
+	}
+	for _, p := range res.Players {
+		if p.IsBot {
+			continue
+		}
+		humanKills := p.Kills - p.BotKills
+		pr := PlayerReport{
+			UserID:      p.UserID,
+			Team:        p.Team,
+			Kills:       humanKills,
+			Deaths:      p.Deaths,
+			DamageDealt: p.DamageDealt,
+			XPEarned:    baseXP + humanKills*xpPerKill,
+			CoinsEarned: baseCoins + humanKills*coinsPerKill,
+			Won:         p.Won,
+		}
+		if p.Won {
+			pr.XPEarned += winXP
+			pr.CoinsEarned += winCoins
+		}
+		rep.Players = append(rep.Players, pr)
+	}
+	return rep
+}
+
+// Sender delivers one report. Implementations must be safe to retry.
+type Sender interface {
+	Send(ctx context.Context, rep MatchReport) error
+}
+
+// ErrRejected wraps a refusal the profile service would repeat however
+// often the report is sent again: a 4xx about the report itself. A wrong
+// secret or URL, a timeout or rate limiting is this server's problem or
+// passes, so those are retried and spooled like an outage.
+var ErrRejected = errors.New("report rejected")
+
+// retryable4xx are the client errors that say nothing about the report
+var retryable4xx = map[int]bool{
+	http.StatusUnauthorized:    true,
+	http.StatusForbidden:       true,
+	http.StatusNotFound:        true,
+	http.StatusRequestTimeout:  true,
+	http.StatusTooManyRequests: true,
+}
+
+// HTTPSender posts reports to the profile service with the shared server secret
+type HTTPSender struct {
+	URL    string
+	Secret string
+	Client *http.Client
+}
+
+func NewHTTPSender(profileServiceURL, secret string) *HTTPSender {
+	return &HTTPSender{
+		URL:    profileServiceURL + "/v1/matches",
+		Secret: secret,
+		Client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (h *HTTPSender) Send(ctx context.Context, rep MatchReport) error {
+	body, err := json.Marshal(rep)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Server-Secret", h.Secret)
+
+	resp, err := h.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode/100 == 2:
+		return nil
+	case resp.StatusCode/100 == 4 && !retryable4xx[resp.StatusCode]:
+		return fmt.Errorf("%w: profile service returned %d", ErrRejected, resp.StatusCode)
+	default:
+		return fmt.Errorf("profile service returned %d", resp.StatusCode)
+	}
+}
+
+// Reporter queues match results and sends them on a single worker goroutine
+type Reporter struct {
+	sender     Sender
+	fallback   Sender // keeps what sender gave up on, nil drops it
+	retryDelay time.Duration
+	queue      chan MatchReport
+	done       chan struct{}
+
+	mu     sync.Mutex
+	closed bool
+}
+
+func NewReporter(sender Sender) *Reporter {
+	r := &Reporter{
+		sender:     sender,
+		retryDelay: time.Second,
+		queue:      make(chan MatchReport, queueCapacity),
+		done:       make(chan struct{}),
+	}
+	go r.run()
+	return r
+}
+
+// SpoolFailures keeps reports the sender gave up on in dir, for Upload to
+// deliver on a later start. Call it before the first Enqueue.
+func (r *Reporter) SpoolFailures(dir string) {
+	r.fallback = NewSpoolSender(dir)
+}
+
+// Enqueue schedules a match result for delivery without blocking.
+// Suitable as a room.Manager finish handler.
+func (r *Reporter) Enqueue(res room.MatchResult) {
+	rep := FromResult(res)
+	if len(rep.Players) == 0 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		log.Printf("Report %s: reporter closed, dropping", rep.MatchID)
+		return
+	}
+	select {
+	case r.queue <- rep:
+	default:
+		log.Printf("Report %s: queue full, dropping", rep.MatchID)
+	}
+}
+
+func (r *Reporter) run() {
+	defer close(r.done)
+	for rep := range r.queue {
+		r.deliver(rep)
+	}
+}
+
+func (r *Reporter) deliver(rep MatchReport) {
+	backoff := r.retryDelay
+	for attempt := 1; attempt <= maxSendTries; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		err := r.sender.Send(ctx, rep)
+		cancel()
+		if err == nil {
+			log.Printf("Report %s: delivered (%d players)", rep.MatchID, len(rep.Players))
+			return
+		}
+		log.Printf("Report %s: attempt %d failed: %v", rep.MatchID, attempt, err)
+		if errors.Is(err, ErrRejected) {
+			log.Printf("Report %s: rejected, dropping", rep.MatchID)
+			return
+		}
+		if attempt < maxSendTries {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+	log.Printf("Report %s: giving up after %d attempts", rep.MatchID, maxSendTries)
+	if r.fallback == nil {
+		return
+	}
+	if err := r.fallback.Send(context.Background(), rep); err != nil {
+		log.Printf("Report %s: spooling failed, dropping: %v", rep.MatchID, err)
+		return
+	}
+	log.Printf("Report %s: spooled for the next upload", rep.MatchID)
+}
+
+// Close stops accepting reports and waits for queued ones to be delivered,
+// or for ctx to expire.
+func (r *Reporter) Close(ctx context.Context) error {
+	r.mu.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.queue)
+	}
+	r.mu.Unlock()
+
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("flushing match reports: %w (%d still queued)", ctx.Err(), len(r.queue))
+	}
+}
@@ -0,0 +1,82 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/room"
+)
+
+func TestBotsEarnNothing(t *testing.T) {
+	rep := FromResult(room.MatchResult{
+		MatchID: "m-1",
+		Players: []room.PlayerResult{
+			{UserID: "u1", Kills: 5, BotKills: 3, Won: true},
+			{UserID: room.BotUserID, IsBot: true, Kills: 9},
+			{UserID: room.BotUserID, IsBot: true},
+		},
+	})
+	if len(rep.Players) != 1 || rep.Players[0].UserID != "u1" {
+		t.Fatalf("players %+v, want only u1", rep.Players)
+	}
+	if p := rep.Players[0]; p.Kills != 2 || p.XPEarned != baseXP+2*xpPerKill+winXP || p.CoinsEarned != baseCoins+2*coinsPerKill+winCoins {
+		t.Fatalf("report = %+v; only the 2 kills on humans should count", p)
+	}
+}
+
+func TestHTTPSenderPostsWithSecret(t *testing.T) {
+	var got MatchReport
+	status := http.StatusCreated
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/matches" || r.Header.Get("X-Server-Secret") != "secret" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	sender := NewHTTPSender(srv.URL, "secret")
+	if err := sender.Send(context.Background(), MatchReport{MatchID: "m-1"}); err != nil || got.MatchID != "m-1" {
+		t.Fatalf("send: %v, got %+v", err, got)
+	}
+	status = http.StatusInternalServerError
+	if err := sender.Send(context.Background(), MatchReport{MatchID: "m-2"}); err == nil {
+		t.Fatal("server error reported as delivered")
+	}
+	status = http.StatusBadRequest
+	if err := sender.Send(context.Background(), MatchReport{MatchID: "m-2"}); !errors.Is(err, ErrRejected) {
+		t.Fatalf("bad request: %v, want ErrRejected", err)
+	}
+	// A wrong secret is ours to fix, so the report is kept for later
+	if err := NewHTTPSender(srv.URL, "wrong").Send(context.Background(), MatchReport{MatchID: "m-3"}); err == nil || errors.Is(err, ErrRejected) {
+		t.Fatalf("wrong secret: %v, want a retryable error", err)
+	}
+}
+
+// Close delivers what is queued; afterwards, and for matches without
+// humans, nothing is sent
+func TestReporterFlushesOnClose(t *testing.T) {
+	sender := &recordingSender{}
+	r := NewReporter(sender)
+	human := []room.PlayerResult{{UserID: "u1"}}
+	r.Enqueue(room.MatchResult{MatchID: "m-1", Players: human})
+	r.Enqueue(room.MatchResult{MatchID: "bots-only", Players: []room.PlayerResult{{UserID: room.BotUserID, IsBot: true}}})
+	r.Enqueue(room.MatchResult{MatchID: "m-2", Players: human})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := r.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	r.Enqueue(room.MatchResult{MatchID: "late", Players: human})
+	if len(sender.got) != 2 || sender.got[0] != "m-1" || sender.got[1] != "m-2" {
+		t.Fatalf("delivered %v, want [m-1 m-2]", sender.got)
+	}
+}
@@ -0,0 +1,121 @@
+// SKYBATTLE — Local Report Spool
+// Offline hosts have no profile service; reports are written to a directory
+// instead and uploaded by the app once it is back online. Cloud servers spool
+// the reports the profile service could not be reached for and upload them
+// at startup. A spooled report that can't be read or that the profile service
+// rejects is moved to QuarantineDir inside the spool, out of the way of the
+// rest, for someone to look at.
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// QuarantineDir is the spool subdirectory for reports Upload gave up on
+const QuarantineDir = "rejected"
+
+// SpoolSender writes each report to Dir as <match_id>.json
+type SpoolSender struct {
+	Dir string
+}
+
+func NewSpoolSender(dir string) *SpoolSender {
+	return &SpoolSender{Dir: dir}
+}
+
+func (s *SpoolSender) Send(ctx context.Context, rep MatchReport) error {
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return err
+	}
+	body, err := json.Marshal(rep)
+	if err != nil {
+		return err
+	}
+	// Write then rename so an uploader never sees a half-written report
+	path := filepath.Join(s.Dir, rep.MatchID+".json")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, body, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Pending lists spooled report files, oldest match ID first
+func Pending(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".json") {
+			files = append(files, filepath.Join(dir, e.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Upload delivers every spooled report through sender, removing each one
+// that was accepted and quarantining each one that can't be read or was
+// rejected. Any other failure stops it, leaving the rest for the next
+// Upload so order is kept.
+func Upload(ctx context.Context, dir string, sender Sender) (int, error) {
+	files, err := Pending(dir)
+	if err != nil {
+		return 0, err
+	}
+	sent := 0
+	for _, f := range files {
+		body, err := os.ReadFile(f)
+		if err != nil {
+			return sent, err
+		}
+		var rep MatchReport
+		if err := json.Unmarshal(body, &rep); err != nil {
+			if err := quarantine(f, err); err != nil {
+				return sent, err
+			}
+			continue
+		}
+		err = sender.Send(ctx, rep)
+		if errors.Is(err, ErrRejected) {
+			if err := quarantine(f, err); err != nil {
+				return sent, err
+			}
+			continue
+		}
+		if err != nil {
+			return sent, err
+		}
+		if err := os.Remove(f); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// quarantine moves a spooled report Upload gave up on into QuarantineDir
+func quarantine(path string, reason error) error {
+	dir := filepath.Join(filepath.Dir(path), QuarantineDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	if err := os.Rename(path, filepath.Join(dir, filepath.Base(path))); err != nil {
+		return fmt.Errorf("quarantining %s: %w", path, err)
+	}
+	log.Printf("Report %s: quarantined: %v", filepath.Base(path), reason)
+	return nil
+}
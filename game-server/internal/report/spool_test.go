@@ -0,0 +1,119 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/room"
+)
+
+type recordingSender struct {
+	got    []string
+	fail   bool
+	reject string // match ID refused for good
+}
+
+func (r *recordingSender) Send(ctx context.Context, rep MatchReport) error {
+	if r.fail {
+		return errors.New("offline")
+	}
+	if rep.MatchID == r.reject {
+		return fmt.Errorf("%w: bad report", ErrRejected)
+	}
+	r.got = append(r.got, rep.MatchID)
+	return nil
+}
+
+func TestSpoolAndUpload(t *testing.T) {
+	dir := t.TempDir()
+	spool := NewSpoolSender(dir)
+	for _, id := range []string{"m-1", "m-2"} {
+		if err := spool.Send(context.Background(), MatchReport{MatchID: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n, err := Upload(context.Background(), dir, &recordingSender{fail: true}); err == nil || n != 0 {
+		t.Fatalf("failed upload: n=%d err=%v", n, err)
+	}
+	if files, _ := Pending(dir); len(files) != 2 {
+		t.Fatalf("reports lost after failed upload: %v", files)
+	}
+
+	up := &recordingSender{}
+	if n, err := Upload(context.Background(), dir, up); err != nil || n != 2 {
+		t.Fatalf("upload: n=%d err=%v", n, err)
+	}
+	if len(up.got) != 2 || up.got[0] != "m-1" {
+		t.Fatalf("uploaded %v", up.got)
+	}
+	if files, _ := Pending(dir); len(files) != 0 {
+		t.Fatalf("uploaded reports still spooled: %v", files)
+	}
+}
+
+// A report the sender gives up on is spooled, and the next Upload delivers it
+func TestReporterSpoolsFailures(t *testing.T) {
+	dir := t.TempDir()
+	r := NewReporter(&recordingSender{fail: true})
+	r.retryDelay = time.Millisecond
+	r.SpoolFailures(dir)
+	r.Enqueue(room.MatchResult{MatchID: "m-1", Players: []room.PlayerResult{{UserID: "u1"}}})
+	if err := r.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	up := &recordingSender{}
+	if n, err := Upload(context.Background(), dir, up); err != nil || n != 1 || up.got[0] != "m-1" {
+		t.Fatalf("upload: n=%d err=%v got %v", n, err, up.got)
+	}
+}
+
+// An unreadable or rejected report is set aside and the rest still go out
+func TestUploadQuarantinesBadReports(t *testing.T) {
+	dir := t.TempDir()
+	spool := NewSpoolSender(dir)
+	for _, id := range []string{"m-1", "m-2", "m-3"} {
+		if err := spool.Send(context.Background(), MatchReport{MatchID: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "m-0.json"), []byte("{cut off"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	up := &recordingSender{reject: "m-2"}
+	if n, err := Upload(context.Background(), dir, up); err != nil || n != 2 {
+		t.Fatalf("upload: n=%d err=%v", n, err)
+	}
+	if len(up.got) != 2 || up.got[0] != "m-1" || up.got[1] != "m-3" {
+		t.Fatalf("uploaded %v", up.got)
+	}
+	if files, _ := Pending(dir); len(files) != 0 {
+		t.Fatalf("still spooled: %v", files)
+	}
+	if kept, _ := Pending(filepath.Join(dir, QuarantineDir)); len(kept) != 2 {
+		t.Fatalf("quarantined %v, want m-0 and m-2", kept)
+	}
+}
+
+// A rejected report is neither retried nor spooled
+func TestReporterDropsRejected(t *testing.T) {
+	dir := t.TempDir()
+	sender := &recordingSender{reject: "m-1"}
+	r := NewReporter(sender)
+	r.retryDelay = time.Hour // a retry would hang the test
+	r.SpoolFailures(dir)
+	r.Enqueue(room.MatchResult{MatchID: "m-1", Players: []room.PlayerResult{{UserID: "u1"}}})
+	if err := r.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if files, _ := Pending(dir); len(files) != 0 {
+		t.Fatalf("rejected report spooled: %v", files)
+	}
+}
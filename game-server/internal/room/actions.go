@@ -0,0 +1,107 @@
+// SKYBATTLE — Melee and Throwables
+// Actions with their own input bits, usable whatever gun is held. Callers
+// must hold r.mu and the acting player's lock.
+package room
+
+import (
+	"math"
+	"time"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/game"
+)
+
+// melee swipes at every enemy inside the arc in front of p. The MELEE event
+// goes out hit or miss so clients can play the swing.
+func (r *Room) melee(p *game.Player, tick int, now time.Time) {
+	if now.Before(p.MeleeReadyAt) {
+		return
+	}
+	p.MeleeReadyAt = now.Add(time.Duration(game.MeleeCooldownSec * float64(time.Second)))
+
+	var hits []*game.Player
+	for _, target := range r.Players {
+		if target == p || !target.IsAlive || !r.canDamage(p.ID, target) {
+			continue
+		}
+		if inMeleeArc(p.Position, p.AimAngleDeg, target.Position) {
+			hits = append(hits, target)
+		}
+	}
+
+	targetID := -1 // a miss
+	if len(hits) > 0 {
+		targetID = hits[0].ID
+	}
+	r.Events = append(r.Events, game.MatchEvent{
+		Tick: tick, Type: "MELEE",
+		ActorID: p.ID, TargetID: targetID,
+		WeaponID: game.WeaponMelee, OccurredAt: now,
+	})
+	for _, target := range hits {
+		r.hit(p.ID, target, game.WeaponMelee, game.MeleeDamage, tick, now)
+	}
+}
+
+// inMeleeArc reports whether target is within reach and inside the arc
+// centred on aimDeg
+func inMeleeArc(from game.Vec2, aimDeg float32, target game.Vec2) bool {
+	dist := from.Distance(target)
+	if dist > game.MeleeRange {
+		return false
+	}
+	if dist == 0 {
+		return true
+	}
+	angle := math.Atan2(float64(target.Y-from.Y), float64(target.X-from.X)) * 180 / math.Pi
+	diff := math.Mod(angle-float64(aimDeg)+540, 360) - 180
+	return math.Abs(diff) <= game.MeleeArcDeg/2
+}
+
+// throwGrenade launches a grenade along the aim angle if p has one left
+func (r *Room) throwGrenade(p *game.Player, tick int, now time.Time) {
+	if p.Grenades <= 0 || now.Before(p.ThrowReadyAt) {
+		return
+	}
+	p.Grenades--
+	p.ThrowReadyAt = now.Add(time.Duration(game.ThrowCooldownSec * float64(time.Second)))
+	r.spawnShot(p, r.weapons[game.WeaponGrenade], now)
+	r.throwEvent(p, game.WeaponGrenade, tick, now)
+}
+
+// placeMine leaves an armed proximity mine where p stands
+func (r *Room) placeMine(p *game.Player, tick int, now time.Time) {
+	if p.Mines <= 0 || now.Before(p.ThrowReadyAt) {
+		return
+	}
+	p.Mines--
+	p.ThrowReadyAt = now.Add(time.Duration(game.ThrowCooldownSec * float64(time.Second)))
+	r.Projectiles = append(r.Projectiles, &game.Projectile{
+		ID:         len(r.Projectiles) + 1,
+		OwnerID:    p.ID,
+		WeaponID:   game.WeaponProximityMine,
+		Position:   p.Position,
+		SpawnTime:  now,
+		MaxLifeSec: game.MineLifeSec,
+		Active:     true,
+	})
+	r.throwEvent(p, game.WeaponProximityMine, tick, now)
+}
+
+func (r *Room) throwEvent(p *game.Player, weaponID game.WeaponID, tick int, now time.Time) {
+	r.Events = append(r.Events, game.MatchEvent{
+		Tick: tick, Type: "THROW",
+		ActorID: p.ID, TargetID: -1,
+		WeaponID: weaponID, OccurredAt: now,
+	})
+}
+
+// throwableCount returns the counter a throwable pickup refills, nil for guns
+func throwableCount(p *game.Player, id game.WeaponID) *int {
+	switch id {
+	case game.WeaponGrenade:
+		return &p.Grenades
+	case game.WeaponProximityMine:
+		return &p.Mines
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package room
+
+import (
+	"testing"
+	"time"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/game"
+)
+
+func TestMeleeHitsOnlyInsideArc(t *testing.T) {
+	r := NewRoom("FFA", "outpost", 30)
+	attacker, _ := r.AddPlayer("u1", "Alice")
+	front, _ := r.AddPlayer("u2", "Bob")
+	behind, _ := r.AddPlayer("u3", "Carol")
+	attacker.Position = game.Vec2{X: 10, Y: 10}
+	front.Position = game.Vec2{X: 11, Y: 10.5}
+	behind.Position = game.Vec2{X: 9, Y: 10}
+
+	r.HandlePlayerInput(attacker.ID, game.PlayerInput{Melee: true, AimAngle: 0})
+	if front.Health != game.MaxHealth-game.MeleeDamage || behind.Health != game.MaxHealth {
+		t.Fatalf("health front=%d behind=%d", front.Health, behind.Health)
+	}
+	if ev := r.Events[len(r.Events)-1]; ev.Type != "MELEE" || ev.TargetID != front.ID {
+		t.Fatalf("last event = %+v", ev)
+	}
+
+	// Still cooling down: no damage and no second swing event
+	events := len(r.Events)
+	r.HandlePlayerInput(attacker.ID, game.PlayerInput{Melee: true, AimAngle: 0})
+	if front.Health != game.MaxHealth-game.MeleeDamage || len(r.Events) != events {
+		t.Fatal("melee ignored its cooldown")
+	}
+}
+
+// World states carry each event once, so they don't grow over a match
+func TestSnapshotsCarryNewEventsOnly(t *testing.T) {
+	r := NewRoom("FFA", "outpost", 30)
+	attacker, _ := r.AddPlayer("u1", "Alice")
+	r.AddPlayer("u2", "Bob")
+
+	r.HandlePlayerInput(attacker.ID, game.PlayerInput{Melee: true})
+	first := r.snapshot(1)
+	if len(first.Events) != 1 || first.Events[0].Type != "MELEE" {
+		t.Fatalf("first snapshot events = %+v", first.Events)
+	}
+	if next := r.snapshot(2); len(next.Events) != 0 {
+		t.Fatalf("events sent again: %+v", next.Events)
+	}
+	r.End("test")
+	if last := r.snapshot(3); len(last.Events) != 1 || last.Events[0].Type != "MATCH_END" {
+		t.Fatalf("last snapshot events = %+v", last.Events)
+	}
+	if len(first.Events) != 1 || first.Events[0].Type != "MELEE" {
+		t.Fatal("a later event changed an earlier snapshot")
+	}
+}
+
+func TestMeleeArcWrapsAround(t *testing.T) {
+	from := game.Vec2{X: 0, Y: 0}
+	if !inMeleeArc(from, 350, game.Vec2{X: 1, Y: 0.1}) {
+		t.Fatal("target at ~6° not inside arc aimed at 350°")
+	}
+	if inMeleeArc(from, 0, game.Vec2{X: 0, Y: 1}) {
+		t.Fatal("target at 90° inside a 90° arc aimed at 0°")
+	}
+	if inMeleeArc(from, 0, game.Vec2{X: 2, Y: 0}) {
+		t.Fatal("target out of reach was hit")
+	}
+}
+
+func TestGrenadesAreCountedApartFromGuns(t *testing.T) {
+	r := NewRoom("FFA", "outpost", 30)
+	p, _ := r.AddPlayer("u1", "Alice")
+	if p.Grenades != 2 || p.Mines != 3 {
+		t.Fatalf("spawned with %d grenades, %d mines", p.Grenades, p.Mines)
+	}
+
+	for i := 0; i < 3; i++ {
+		p.ThrowReadyAt = time.Time{}
+		r.HandlePlayerInput(p.ID, game.PlayerInput{Throw: true, Firing: true})
+	}
+	grenades := 0
+	for _, proj := range r.Projectiles {
+		if proj.WeaponID == game.WeaponGrenade {
+			grenades++
+		}
+	}
+	if grenades != 2 || p.Grenades != 0 {
+		t.Fatalf("threw %d grenades, %d left", grenades, p.Grenades)
+	}
+	if p.PrimaryAmmo != 29 {
+		t.Fatalf("gun ammo = %d; throwing should not use it", p.PrimaryAmmo)
+	}
+	if ev := r.Events[len(r.Events)-1]; ev.Type != "THROW" || ev.WeaponID != game.WeaponGrenade {
+		t.Fatalf("last event = %+v", ev)
+	}
+}
+
+func TestPlacedMineTriggersOnEnemy(t *testing.T) {
+	r := NewRoom("FFA", "outpost", 30)
+	owner, _ := r.AddPlayer("u1", "Alice")
+	victim, _ := r.AddPlayer("u2", "Bob")
+	owner.Position = game.Vec2{X: 5, Y: 0}
+	victim.Position = game.Vec2{X: 20, Y: 0}
+
+	r.HandlePlayerInput(owner.ID, game.PlayerInput{PlaceMine: true})
+	if owner.Mines != 2 || len(r.Projectiles) != 1 {
+		t.Fatalf("mines left %d, projectiles %d", owner.Mines, len(r.Projectiles))
+	}
+	r.tick(1, 1.0/30)
+	if !r.Projectiles[0].Active {
+		t.Fatal("mine went off with nobody near it")
+	}
+
+	victim.Position = owner.Position
+	r.tick(2, 1.0/30)
+	if victim.Health != game.MaxHealth-90 || r.Projectiles[0].Active {
+		t.Fatalf("victim health %d, mine active %v", victim.Health, r.Projectiles[0].Active)
+	}
+}
@@ -0,0 +1,112 @@
+// SKYBATTLE — Bot Backfill
+// A room with a BotFill target is kept at that size for the whole match:
+// bots take the empty slots when it starts, make way for humans who drop in
+// mid-match and take over from humans who leave. In TDM bots are added and
+// removed so the teams stay even. Bots never count towards ELO or rewards;
+// see MatchResult and report.FromResult.
+package room
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/game"
+)
+
+// fillBots tops the room up to BotFill players before the match starts
+func (r *Room) fillBots() {
+	r.mu.RLock()
+	missing := r.BotFill - len(r.Players)
+	r.mu.RUnlock()
+	if missing > 0 {
+		if err := r.SpawnBots(missing, ""); err != nil {
+			log.Printf("Room %s: bot fill: %v", r.ID, err)
+		}
+	}
+}
+
+// pickTeamLocked chooses a joining player's team. FFA gives everyone their
+// own; TDM puts humans on the side with fewer humans so bots are the ones
+// that get shuffled, and otherwise evens up the headcount. Caller must hold r.mu.
+func (r *Room) pickTeamLocked(human bool) string {
+	if r.GameMode != "TDM" {
+		// FFA or other: everyone is on their own team
+		return fmt.Sprintf("PLAYER_%d", r.NextPlayerID)
+	}
+	players, humans := map[string]int{}, map[string]int{}
+	for _, p := range r.Players {
+		players[p.Team]++
+		if p.UserID != BotUserID {
+			humans[p.Team]++
+		}
+	}
+	if human && humans["RED"] != humans["BLUE"] {
+		if humans["BLUE"] < humans["RED"] {
+			return "BLUE"
+		}
+		return "RED"
+	}
+	if players["BLUE"] < players["RED"] {
+		return "BLUE"
+	}
+	return "RED"
+}
+
+// addBotLocked adds one bot on team. Caller must hold r.mu.
+func (r *Room) addBotLocked(team string, profile game.BotProfile) {
+	r.botSeq++
+	p := r.addPlayerLocked(BotUserID, fmt.Sprintf("Bot_%d", r.botSeq), team)
+	b := game.NewBotController(p, profile)
+	b.Nav = game.NavGraphFor(r.MapID)
+	b.Weapons = r.weapons
+	r.Bots = append(r.Bots, b)
+}
+
+// shedBotLocked removes a bot after a human dropped in on team, if that
+// takes the room over its target: one from the same team when there is
+// one, otherwise from the bigger side. Caller must hold r.mu.
+func (r *Room) shedBotLocked(team string) {
+	if len(r.Players) <= r.BotFill && len(r.Players) <= r.MaxPlayers {
+		return
+	}
+	var victim *game.BotController
+	size := map[string]int{}
+	for _, p := range r.Players {
+		size[p.Team]++
+	}
+	for _, b := range r.Bots {
+		if r.GameMode != "TDM" || b.Player.Team == team {
+			victim = b
+			break
+		}
+		if victim == nil || size[b.Player.Team] > size[victim.Player.Team] {
+			victim = b
+		}
+	}
+	if victim == nil {
+		return
+	}
+	log.Printf("Room %s: %s leaves to make room", r.ID, victim.Player.DisplayName)
+	r.removePlayerLocked(victim.Player.ID)
+}
+
+// backfillLocked replaces a human who left team with a bot while the room
+// is under its target. Caller must hold r.mu.
+func (r *Room) backfillLocked(team string) {
+	if r.BotFill == 0 || len(r.Players) >= r.BotFill || len(r.Players) >= r.MaxPlayers {
+		return
+	}
+	profile, ok := game.BotProfileFor(r.BotDifficulty)
+	if !ok {
+		return
+	}
+	if r.GameMode != "TDM" {
+		team = r.pickTeamLocked(false)
+	}
+	r.addBotLocked(team, profile)
+}
+
+// humanCountLocked counts non-bot players. Caller must hold r.mu.
+func (r *Room) humanCountLocked() int {
+	return len(r.Players) - len(r.Bots)
+}
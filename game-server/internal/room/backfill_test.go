@@ -0,0 +1,112 @@
+package room
+
+import "testing"
+
+func backfilledRoom(gameMode string, fill int) *Room {
+	r := NewRoom(gameMode, "outpost", 30)
+	rules := DefaultRules()
+	rules.BotFill = fill
+	r.ApplyRules(rules)
+	return r
+}
+
+// begin starts the match the way Start does, without running the tick loop
+func begin(r *Room) {
+	r.fillBots()
+	r.mu.Lock()
+	r.State = StateInProgress
+	r.mu.Unlock()
+}
+
+func teamSizes(r *Room) (red, blue, bots int) {
+	for _, p := range r.Players {
+		if p.Team == "RED" {
+			red++
+		} else {
+			blue++
+		}
+	}
+	return red, blue, len(r.Bots)
+}
+
+func TestBackfillKeepsTargetSize(t *testing.T) {
+	r := backfilledRoom("FFA", 4)
+	host, _ := r.AddPlayer("u1", "Alice")
+	begin(r)
+	if len(r.Players) != 4 || len(r.Bots) != 3 {
+		t.Fatalf("at start: %d players, %d bots", len(r.Players), len(r.Bots))
+	}
+
+	// A human dropping in mid-match takes a bot's place
+	if _, err := r.AddPlayer("u2", "Bob"); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Players) != 4 || len(r.Bots) != 2 {
+		t.Fatalf("after drop-in: %d players, %d bots", len(r.Players), len(r.Bots))
+	}
+
+	// ...and a bot takes over when a human leaves
+	r.RemovePlayer(host.ID)
+	if len(r.Players) != 4 || len(r.Bots) != 3 || r.State != StateInProgress {
+		t.Fatalf("after leave: %d players, %d bots, %s", len(r.Players), len(r.Bots), r.State)
+	}
+}
+
+func TestBackfillEndsMatchWithoutHumans(t *testing.T) {
+	r := backfilledRoom("FFA", 4)
+	host, _ := r.AddPlayer("u1", "Alice")
+	begin(r)
+	r.RemovePlayer(host.ID)
+	if r.State != StateFinished {
+		t.Fatalf("bots-only match state = %s", r.State)
+	}
+}
+
+func TestBackfillKeepsTDMTeamsEven(t *testing.T) {
+	r := backfilledRoom("TDM", 6)
+	r.AddPlayer("u1", "Alice")
+	begin(r)
+	if red, blue, bots := teamSizes(r); red != 3 || blue != 3 || bots != 5 {
+		t.Fatalf("at start: red %d, blue %d, bots %d", red, blue, bots)
+	}
+
+	// Each newcomer joins the side with fewer humans and replaces a bot there
+	bob, _ := r.AddPlayer("u2", "Bob")
+	carol, _ := r.AddPlayer("u3", "Carol")
+	if bob.Team != "BLUE" || carol.Team != "RED" {
+		t.Fatalf("teams: bob %s, carol %s", bob.Team, carol.Team)
+	}
+	if red, blue, bots := teamSizes(r); red != 3 || blue != 3 || bots != 3 {
+		t.Fatalf("after drop-ins: red %d, blue %d, bots %d", red, blue, bots)
+	}
+
+	r.RemovePlayer(bob.ID)
+	if red, blue, bots := teamSizes(r); red != 3 || blue != 3 || bots != 4 {
+		t.Fatalf("after leave: red %d, blue %d, bots %d", red, blue, bots)
+	}
+}
+
+func TestWithoutBackfillMidMatchJoinsAreRefused(t *testing.T) {
+	r := backfilledRoom("FFA", 0)
+	r.AddPlayer("u1", "Alice")
+	begin(r)
+	if _, err := r.AddPlayer("u2", "Bob"); err == nil {
+		t.Fatal("open room without bot fill accepted a mid-match join")
+	}
+}
+
+func TestBotKillsAreCountedApart(t *testing.T) {
+	r := backfilledRoom("FFA", 2)
+	human, _ := r.AddPlayer("u1", "Alice")
+	begin(r)
+	bot := r.Bots[0].Player
+	bot.Health = 1
+	shoot(r, human, bot)
+
+	res := r.result(bot.RespawnAt)
+	for _, p := range res.Players {
+		if p.UserID == "u1" && (p.Kills != 1 || p.BotKills != 1) {
+			t.Fatalf("human result = %+v", p)
+		}
+	}
+}
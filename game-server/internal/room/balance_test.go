@@ -0,0 +1,60 @@
+package room
+
+import (
+	"testing"
+	"time"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/game"
+)
+
+func tunedWeapons(tune func(*game.WeaponSpec), id game.WeaponID) game.WeaponTable {
+	t := game.WeaponTable{}
+	for k, v := range game.Weapons {
+		t[k] = v
+	}
+	s := t[id]
+	tune(&s)
+	t[id] = s
+	return t
+}
+
+func TestWeaponReloadOnlyAffectsNewRooms(t *testing.T) {
+	t.Cleanup(func() { game.SetWeapons(game.Weapons) })
+	m := NewManager(5, 30)
+	running, _ := m.CreateRoom("FFA", "outpost")
+
+	game.SetWeapons(tunedWeapons(func(s *game.WeaponSpec) { s.MaxAmmo = 60 }, game.WeaponAssaultRifle))
+	fresh, _ := m.CreateRoom("FFA", "outpost")
+
+	old, _ := running.AddPlayer("u1", "Alice")
+	tuned, _ := fresh.AddPlayer("u2", "Bob")
+	if old.PrimaryAmmo != 30 || tuned.PrimaryAmmo != 60 {
+		t.Fatalf("ammo: running room %d (want 30), new room %d (want 60)", old.PrimaryAmmo, tuned.PrimaryAmmo)
+	}
+}
+
+func TestFusedGrenadeExplodesAfterFuse(t *testing.T) {
+	t.Cleanup(func() { game.SetWeapons(game.Weapons) })
+	game.SetWeapons(tunedWeapons(func(s *game.WeaponSpec) { s.FuseTimeSec = 1 }, game.WeaponGrenade))
+
+	r := NewRoom("FFA", "outpost", 30)
+	thrower, _ := r.AddPlayer("u1", "Alice")
+	target, _ := r.AddPlayer("u2", "Bob")
+	target.Health = 50
+
+	grenade := &game.Projectile{
+		OwnerID: thrower.ID, WeaponID: game.WeaponGrenade,
+		Position: target.Position, SpawnTime: time.Now(), MaxLifeSec: 5, Active: true,
+	}
+	r.Projectiles = append(r.Projectiles, grenade)
+
+	r.tick(1, 1.0/30)
+	if !target.IsAlive || !grenade.Active {
+		t.Fatal("grenade went off on contact before its fuse")
+	}
+	grenade.SpawnTime = time.Now().Add(-2 * time.Second)
+	r.tick(2, 1.0/30)
+	if target.IsAlive || grenade.Active || thrower.Kills != 1 {
+		t.Fatalf("fuse burnt down without a kill: alive=%v active=%v kills=%d", target.IsAlive, grenade.Active, thrower.Kills)
+	}
+}
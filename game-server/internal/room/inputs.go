@@ -0,0 +1,44 @@
+// SKYBATTLE — Room Input Queue
+// Network readers hand inputs to a room through a bounded channel instead
+// of taking the room's lock, and the room applies everything queued at the
+// start of its next tick. A reader never waits on a room: when a room falls
+// behind and its queue fills, further inputs are dropped and counted, and
+// the client's next input (which carries its full state) replaces them.
+package room
+
+import (
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/game"
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/metrics"
+)
+
+// inputQueueSize covers a full room sending 60 Hz input for several 30 TPS ticks
+const inputQueueSize = 256
+
+type queuedInput struct {
+	playerID int
+	input    game.PlayerInput
+}
+
+// QueueInput hands a player's input to the room's tick goroutine. It never
+// blocks; false means the queue was full and the input was dropped.
+func (r *Room) QueueInput(playerID int, input game.PlayerInput) bool {
+	select {
+	case r.inputs <- queuedInput{playerID, input}:
+		return true
+	default:
+		metrics.InputsDropped.With(r.ID).Inc()
+		return false
+	}
+}
+
+// applyQueuedInputs processes the inputs queued since the last tick, in
+// arrival order. Inputs queued while it runs wait for the next tick.
+// Caller must hold r.mu.
+func (r *Room) applyQueuedInputs() {
+	n := len(r.inputs)
+	metrics.InputQueueDepth.With(r.ID).Set(float64(n))
+	for i := 0; i < n; i++ {
+		q := <-r.inputs
+		r.processPlayerInput(q.playerID, q.input)
+	}
+}
@@ -0,0 +1,50 @@
+package room
+
+import (
+	"testing"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/game"
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/metrics"
+)
+
+func TestQueuedInputsWaitForTheTick(t *testing.T) {
+	r, p := armedPlayer(t, game.WeaponAssaultRifle, 0)
+	begin(r)
+
+	r.QueueInput(p.ID, game.PlayerInput{Sequence: 1})
+	r.QueueInput(p.ID, game.PlayerInput{Sequence: 2, Firing: true})
+	if p.LastInputSeq != 0 || p.PrimaryAmmo != 30 {
+		t.Fatal("input applied before the tick")
+	}
+
+	r.tick(1, 1.0/30)
+	if p.LastInputSeq != 2 || p.PrimaryAmmo != 29 {
+		t.Fatalf("after the tick: sequence %d, ammo %d", p.LastInputSeq, p.PrimaryAmmo)
+	}
+	if len(r.inputs) != 0 {
+		t.Fatalf("%d inputs left queued", len(r.inputs))
+	}
+}
+
+func TestFullInputQueueDrops(t *testing.T) {
+	r, p := armedPlayer(t, game.WeaponAssaultRifle, 0)
+	dropped := metrics.InputsDropped.With(r.ID)
+	defer metrics.ForgetRoom(r.ID)
+
+	for i := 0; i < inputQueueSize; i++ {
+		if !r.QueueInput(p.ID, game.PlayerInput{Sequence: uint32(i + 1)}) {
+			t.Fatalf("input %d dropped below capacity", i)
+		}
+	}
+	if r.QueueInput(p.ID, game.PlayerInput{Sequence: 9999}) {
+		t.Fatal("queued past capacity")
+	}
+	if dropped.Value() != 1 {
+		t.Fatalf("dropped = %d", dropped.Value())
+	}
+
+	r.tick(1, 1.0/30)
+	if p.LastInputSeq != inputQueueSize {
+		t.Fatalf("last sequence %d, want %d", p.LastInputSeq, inputQueueSize)
+	}
+}
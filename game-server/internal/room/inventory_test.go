@@ -0,0 +1,139 @@
+package room
+
+import (
+	"testing"
+	"time"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/game"
+)
+
+func armedPlayer(t *testing.T, primary, secondary game.WeaponID) (*Room, *game.Player) {
+	t.Helper()
+	r := NewRoom("FFA", "outpost", 30)
+	p, err := r.AddPlayer("u1", "Alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.SetSlot(game.SlotPrimary, primary, r.weapons[primary].MaxAmmo)
+	if secondary != 0 {
+		p.SetSlot(game.SlotSecondary, secondary, r.weapons[secondary].MaxAmmo)
+	}
+	return r, p
+}
+
+func TestSwitchDelayBlocksFiring(t *testing.T) {
+	r, p := armedPlayer(t, game.WeaponAssaultRifle, game.WeaponRocketLauncher)
+
+	r.HandlePlayerInput(p.ID, game.PlayerInput{Switch: true, Firing: true})
+	if p.ActiveSlot != game.SlotSecondary {
+		t.Fatalf("active slot = %d after switch", p.ActiveSlot)
+	}
+	if len(r.Projectiles) != 0 || p.SecondaryAmmo != 4 {
+		t.Fatal("fired during the switch delay")
+	}
+
+	p.SwitchReadyAt = time.Now().Add(-time.Millisecond)
+	r.HandlePlayerInput(p.ID, game.PlayerInput{Firing: true, WeaponID: uint8(game.WeaponRocketLauncher)})
+	if len(r.Projectiles) != 1 || p.SecondaryAmmo != 3 || p.PrimaryAmmo != 30 {
+		t.Fatalf("projectiles=%d ammo=%d/%d", len(r.Projectiles), p.PrimaryAmmo, p.SecondaryAmmo)
+	}
+}
+
+func TestClientWeaponIDIsOnlyAHint(t *testing.T) {
+	r, p := armedPlayer(t, game.WeaponAssaultRifle, 0)
+
+	// Claiming a weapon the player doesn't carry changes nothing
+	r.HandlePlayerInput(p.ID, game.PlayerInput{Firing: true, WeaponID: uint8(game.WeaponRocketLauncher)})
+	if len(r.Projectiles) != 0 || p.ActiveWeapon() != game.WeaponAssaultRifle || p.PrimaryAmmo != 29 {
+		t.Fatalf("weapon=%d projectiles=%d ammo=%d", p.ActiveWeapon(), len(r.Projectiles), p.PrimaryAmmo)
+	}
+}
+
+func TestDualWieldFiresBothSlots(t *testing.T) {
+	r, p := armedPlayer(t, game.WeaponSMG, game.WeaponDualPistols)
+	r.HandlePlayerInput(p.ID, game.PlayerInput{Firing: true})
+	if p.PrimaryAmmo != 44 || p.SecondaryAmmo != 23 {
+		t.Fatalf("dual-wield ammo = %d/%d, want 44/23", p.PrimaryAmmo, p.SecondaryAmmo)
+	}
+
+	r, p = armedPlayer(t, game.WeaponSMG, game.WeaponShotgun)
+	r.HandlePlayerInput(p.ID, game.PlayerInput{Firing: true})
+	if p.PrimaryAmmo != 44 || p.SecondaryAmmo != 8 {
+		t.Fatalf("single-wield ammo = %d/%d, want 44/8", p.PrimaryAmmo, p.SecondaryAmmo)
+	}
+}
+
+func TestEmptySlotReloads(t *testing.T) {
+	r, p := armedPlayer(t, game.WeaponRocketLauncher, 0)
+	p.PrimaryAmmo = 1
+
+	r.HandlePlayerInput(p.ID, game.PlayerInput{Firing: true})
+	if p.PrimaryAmmo != 0 || p.SlotReloadAt[game.SlotPrimary].IsZero() {
+		t.Fatal("emptying the magazine did not start a reload")
+	}
+	p.SlotFireTime[game.SlotPrimary] = time.Time{}
+	r.HandlePlayerInput(p.ID, game.PlayerInput{Firing: true})
+	if len(r.Projectiles) != 1 {
+		t.Fatal("fired while reloading")
+	}
+
+	p.SlotReloadAt[game.SlotPrimary] = time.Now().Add(-time.Millisecond)
+	r.tick(1, 1.0/30)
+	if p.PrimaryAmmo != 4 {
+		t.Fatalf("ammo after reload = %d, want 4", p.PrimaryAmmo)
+	}
+}
+
+func TestPickupFillsSecondaryThenSwapDropsOldWeapon(t *testing.T) {
+	r, p := armedPlayer(t, game.WeaponAssaultRifle, 0)
+	shotgun := r.Pickups[0] // outpost: shotgun at (5, 14)
+	sniper := r.Pickups[1]  // sniper rifle at (19, 14)
+
+	p.Position = shotgun.Position
+	r.tick(1, 0)
+	if p.SecondaryWeapon != game.WeaponShotgun || shotgun.IsActive {
+		t.Fatalf("secondary = %d, pickup active = %v", p.SecondaryWeapon, shotgun.IsActive)
+	}
+	if ev := r.Events[len(r.Events)-1]; ev.Type != "PICKUP" || ev.TargetID != shotgun.ID {
+		t.Fatalf("last event = %+v", ev)
+	}
+
+	// Both slots full: walking over another weapon leaves it, swapping takes it
+	p.Position = sniper.Position
+	p.PrimaryAmmo = 7
+	r.tick(2, 0)
+	if !sniper.IsActive {
+		t.Fatal("weapon picked up without a swap")
+	}
+	r.HandlePlayerInput(p.ID, game.PlayerInput{Interact: true})
+	if p.PrimaryWeapon != game.WeaponSniperRifle || p.PrimaryAmmo != 5 || sniper.IsActive {
+		t.Fatalf("primary = %d (%d rounds) after swap", p.PrimaryWeapon, p.PrimaryAmmo)
+	}
+	dropped := r.Pickups[len(r.Pickups)-1]
+	if !dropped.Dropped || dropped.WeaponID != game.WeaponAssaultRifle || dropped.Ammo != 7 {
+		t.Fatalf("dropped pickup = %+v", dropped)
+	}
+
+	// Swap back: the rifle keeps its 7 rounds and the dropped pickup is cleared away
+	r.HandlePlayerInput(p.ID, game.PlayerInput{Interact: true})
+	r.tick(3, 0)
+	if p.PrimaryWeapon != game.WeaponAssaultRifle || p.PrimaryAmmo != 7 {
+		t.Fatalf("primary = %d (%d rounds) after swapping back", p.PrimaryWeapon, p.PrimaryAmmo)
+	}
+	for _, pk := range r.Pickups {
+		if pk == dropped {
+			t.Fatal("taken dropped weapon still listed")
+		}
+	}
+}
+
+func TestRespawnResetsLoadout(t *testing.T) {
+	r, p := armedPlayer(t, game.WeaponSMG, game.WeaponShotgun)
+	p.ActiveSlot = game.SlotSecondary
+	p.TakeDamage(p.Health)
+	p.RespawnAt = time.Now().Add(-time.Millisecond)
+	r.tick(1, 0)
+	if !p.IsAlive || p.PrimaryWeapon != game.WeaponAssaultRifle || p.SecondaryWeapon != 0 || p.ActiveSlot != game.SlotPrimary {
+		t.Fatalf("after respawn: alive=%v loadout=%d/%d slot=%d", p.IsAlive, p.PrimaryWeapon, p.SecondaryWeapon, p.ActiveSlot)
+	}
+}
@@ -3,15 +3,20 @@
 package room
 
 import (
+	"errors"
 	"fmt"
 	"log"
-	"math"
 	"math/rand"
+	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/game"
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/metrics"
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/physics"
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/replay"
 )
 
 type RoomState string
@@ -23,6 +28,17 @@ const (
 	StateFinished   RoomState = "FINISHED"
 )
 
+// BotUserID is the placeholder user ID given to server-controlled bots
+const BotUserID = "bot_uid"
+
+var (
+	ErrServerFull      = errors.New("server at max room capacity")
+	ErrDraining        = errors.New("server is draining")
+	ErrRoomFull        = errors.New("room full")
+	ErrMatchInProgress = errors.New("match already in progress")
+	ErrAlreadyInRoom   = errors.New("player already in this room")
+)
+
 type Room struct {
 	mu sync.RWMutex
 
@@ -33,23 +49,55 @@ type Room struct {
 	Players     map[int]*game.Player
 	Pickups     []*game.Pickup
 	Projectiles []*game.Projectile
+	CreatedAt   time.Time
 	StartedAt   time.Time
 	MaxPlayers  int
 	TimeLimitSec int
 	KillLimit    int
+	RespawnDelay time.Duration
+	FriendlyFire bool
+	BotFill      int
+	BotDifficulty string
 	TickRate     int
 	NextPlayerID int
 	SpawnPoints  []game.Vec2
 
 	// Kill feed for match events
-	Events []game.MatchEvent
+	Events     []game.MatchEvent
+	sentEvents int // how many have gone out in snapshots
 
-	broadcastFunc func(roomID string, tick int, players []*game.Player, pickups []*game.Pickup, events []game.MatchEvent)
+	// Balance snapshot taken at creation; reloads only affect new rooms
+	weapons game.WeaponTable
+
+	broadcastFunc func(Snapshot)
 
 	Bots []*game.BotController
 	TeamScores map[string]int
 
-	stopCh chan struct{}
+	currentTick  int
+	nextPickupID int
+	botSeq       int
+	finished     bool
+	onFinish    func(MatchResult)
+	release     func() // removes the room from its manager once finished
+
+	// Matchmaker reservation (nil roster = open room)
+	roster        map[string]bool
+	reservedUntil time.Time
+	rosterArrived bool
+
+	// Replay recording (disabled when replayDir is empty)
+	replayDir      string
+	recorder       *replay.Writer
+	frame          replay.Frame
+	recordedEvents int
+
+	// Inputs from the network, applied by the tick goroutine (see inputs.go)
+	inputs chan queuedInput
+
+	started  bool
+	stopCh   chan struct{}
+	stopOnce sync.Once
 }
 
 // Outpost map — spawn points (from doc 15)
@@ -93,14 +141,20 @@ func NewRoom(gameMode, mapID string, tickRate int) *Room {
 		MapID:        mapID,
 		State:        StateWaiting,
 		Players:      make(map[int]*game.Player),
+		CreatedAt:    time.Now(),
 		TickRate:     tickRate,
-		MaxPlayers:   10,
-		TimeLimitSec: 300, // 5 min default
-		KillLimit:    20,
 		NextPlayerID: 1,
 		TeamScores:   make(map[string]int),
+		inputs:       make(chan queuedInput, inputQueueSize),
 		stopCh:       make(chan struct{}),
+		weapons:      game.CurrentWeapons(),
 	}
+	defaults := DefaultRules()
+	r.MaxPlayers = defaults.MaxPlayers
+	r.TimeLimitSec = defaults.TimeLimitSec
+	r.KillLimit = defaults.KillLimit
+	r.RespawnDelay = time.Duration(defaults.RespawnDelaySec * float64(time.Second))
+	r.BotDifficulty = defaults.BotDifficulty
 
 	// Load map data
 	switch mapID {
@@ -127,41 +181,85 @@ func (r *Room) AddPlayer(userID, displayName string) (*game.Player, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if len(r.Players) >= r.MaxPlayers {
-		return nil, fmt.Errorf("room full")
+	onRoster := r.roster[userID]
+	if r.roster != nil && !onRoster && userID != BotUserID {
+		return nil, ErrNotOnRoster
+	}
+	// One account holds one seat, however many sessions it opens
+	if userID != BotUserID {
+		for _, p := range r.Players {
+			if p.UserID == userID {
+				return nil, ErrAlreadyInRoom
+			}
+		}
+	}
+	// In a backfilled match a human can always take a bot's place
+	dropIn := userID != BotUserID && r.BotFill > 0 && r.State == StateInProgress
+	if len(r.Players) >= r.MaxPlayers && !(dropIn && len(r.Bots) > 0) {
+		return nil, ErrRoomFull
 	}
-	if r.State != StateWaiting {
-		return nil, fmt.Errorf("match already in progress")
+	// Matched players may still arrive after the first one started the match
+	if r.State != StateWaiting && !(onRoster && r.State == StateInProgress) && !dropIn {
+		return nil, ErrMatchInProgress
 	}
 
-	team := "RED"
-	if r.GameMode == "TDM" {
-		if len(r.Players)%2 == 1 {
-			team = "BLUE"
-		}
-	} else {
-		// FFA or other: everyone is on their own team or "NONE"
-		team = fmt.Sprintf("PLAYER_%d", r.NextPlayerID)
+	p := r.addPlayerLocked(userID, displayName, r.pickTeamLocked(userID != BotUserID))
+	if onRoster {
+		r.rosterArrived = true
+	}
+	if dropIn {
+		r.shedBotLocked(p.Team)
 	}
+	return p, nil
+}
 
+// addPlayerLocked places a new player at a spawn point. Caller must hold r.mu.
+func (r *Room) addPlayerLocked(userID, displayName, team string) *game.Player {
 	playerID := r.NextPlayerID
 	r.NextPlayerID++
 	spawn := r.SpawnPoints[playerID%len(r.SpawnPoints)]
 
 	p := game.NewPlayer(playerID, userID, displayName, team)
+	r.resetLoadout(p)
 	p.Position = spawn
 	p.SpawnX = spawn.X
 	p.SpawnY = spawn.Y
 	r.Players[playerID] = p
+	if r.recorder != nil {
+		r.frame.Joined = append(r.frame.Joined, playerInfo(p))
+	}
 
 	log.Printf("Room %s: player %s joined (id=%d)", r.ID, displayName, playerID)
-	return p, nil
+	return p
 }
 
 func (r *Room) RemovePlayer(playerID int) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	p, ok := r.Players[playerID]
+	if !ok {
+		return
+	}
+	r.removePlayerLocked(playerID)
+
+	if r.State != StateInProgress || p.UserID == BotUserID {
+		return
+	}
+	// Bots alone don't keep a match going
+	if r.humanCountLocked() == 0 {
+		r.State = StateFinished
+		return
+	}
+	r.backfillLocked(p.Team)
+}
+
+// removePlayerLocked drops a player, and its bot controller if it was a bot.
+// Caller must hold r.mu.
+func (r *Room) removePlayerLocked(playerID int) {
 	delete(r.Players, playerID)
+	if r.recorder != nil {
+		r.frame.Left = append(r.frame.Left, playerID)
+	}
 	// Also remove from bots if it was a bot
 	for i, b := range r.Bots {
 		if b.Player.ID == playerID {
@@ -169,27 +267,44 @@ func (r *Room) RemovePlayer(playerID int) {
 			break
 		}
 	}
-	if len(r.Players) == 0 && r.State == StateInProgress {
-		r.State = StateFinished
-	}
 }
 
-func (r *Room) SpawnBots(count int) {
-	for i := 0; i < count; i++ {
-		name := fmt.Sprintf("Bot_%d", i+1)
-		p, err := r.AddPlayer("bot_uid", name)
-		if err == nil {
-			r.mu.Lock()
-			r.Bots = append(r.Bots, game.NewBotController(p, 0.5+rand.Float32()*0.5))
-			r.mu.Unlock()
-		}
+// SpawnBots adds up to count bots with the named difficulty profile; ""
+// uses the room's BotDifficulty
+func (r *Room) SpawnBots(count int, difficulty string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if difficulty == "" {
+		difficulty = r.BotDifficulty
+	}
+	profile, ok := game.BotProfileFor(difficulty)
+	if !ok {
+		return fmt.Errorf("unknown bot difficulty %q", difficulty)
 	}
+	for i := 0; i < count && len(r.Players) < r.MaxPlayers; i++ {
+		r.addBotLocked(r.pickTeamLocked(false), profile)
+	}
+	return nil
 }
 
+// Start runs the room's tick loop until Stop. Only the first call starts
+// it, so every join may call it.
 func (r *Room) Start() {
+	r.mu.Lock()
+	if r.started || r.finished {
+		r.mu.Unlock()
+		return
+	}
+	r.started = true
+	r.mu.Unlock()
+
+	r.fillBots()
+
 	r.mu.Lock()
 	r.State = StateInProgress
 	r.StartedAt = time.Now()
+	r.openRecorder()
+	starting := len(r.Players)
 	r.mu.Unlock()
 
 	tickInterval := time.Second / time.Duration(r.TickRate)
@@ -197,20 +312,51 @@ func (r *Room) Start() {
 	defer ticker.Stop()
 
 	currentTick := 0
-	log.Printf("Room %s: match started (%s on %s, %d players)", r.ID, r.GameMode, r.MapID, len(r.Players))
+	log.Printf("Room %s: match started (%s on %s, %d players)", r.ID, r.GameMode, r.MapID, starting)
 
 	nextBroadcast := time.Now()
 	broadcastInterval := time.Second / time.Duration(r.TickRate)
 
+	tickTiming := metrics.TickDuration.With(r.ID)
+	tickLate := metrics.TicksLate.With(r.ID)
+	tickMissed := metrics.TicksMissed.With(r.ID)
+	var lastTick time.Time
+
 	for {
 		select {
 		case <-r.stopCh:
+			r.mu.Lock()
+			r.closeRecorder()
+			r.mu.Unlock()
 			log.Printf("Room %s: stopped", r.ID)
 			return
-		case <-ticker.C:
+		case now := <-ticker.C:
+			// The ticker drops ticks when we fall behind; count the gap
+			if !lastTick.IsZero() {
+				if missed := int(now.Sub(lastTick)/tickInterval) - 1; missed > 0 {
+					tickMissed.Add(missed)
+				}
+			}
+			lastTick = now
+
 			currentTick++
 			deltaTime := float32(tickInterval.Seconds())
+			tickStart := time.Now()
 			r.tick(currentTick, deltaTime)
+			elapsed := time.Since(tickStart)
+			tickTiming.Observe(elapsed.Seconds())
+			if elapsed > tickInterval {
+				tickLate.Inc()
+			}
+
+			// The last state carries MATCH_END; then the room makes way
+			// for the next match
+			if r.IsFinished() {
+				r.BroadcastWorldState(currentTick)
+				log.Printf("Room %s: finished, removing", r.ID)
+				r.releaseSlot()
+				return
+			}
 
 			// Broadcast world state every tick
 			if time.Now().After(nextBroadcast) {
@@ -221,21 +367,21 @@ func (r *Room) Start() {
 	}
 }
 
+// tick advances the match by one step. It holds r.mu throughout, so joins,
+// kicks and admin actions wait for the whole tick.
 func (r *Room) tick(tick int, deltaTime float32) {
 	r.mu.Lock()
-	
+	defer r.mu.Unlock()
+	r.currentTick = tick
+	r.applyQueuedInputs()
+
 	// Process Bot updates first to generate inputs
 	for _, b := range r.Bots {
-		input := b.Update(deltaTime, r.Players)
+		input := b.Update(deltaTime, r.Players, r.Pickups)
 		// Internal call to HandlePlayerInput
 		r.processPlayerInput(b.Player.ID, input)
 	}
 
-	r.mu.Unlock()
-
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
 	now := time.Now()
 	gravity := float32(-20.0) // units/sec^2
 
@@ -247,9 +393,13 @@ func (r *Room) tick(tick int, deltaTime float32) {
 			if now.After(p.RespawnAt) {
 				spawn := r.safeSpawnPoint(p)
 				p.Respawn(spawn.X, spawn.Y)
+				p.Lock()
+				r.resetLoadout(p)
+				p.Unlock()
 			}
 			continue
 		}
+		r.finishReloads(p, now)
 
 		// Apply gravity if not grounded and not flying
 		if !p.IsGrounded && !p.IsFlying {
@@ -281,19 +431,31 @@ func (r *Room) tick(tick int, deltaTime float32) {
 		if !proj.Active {
 			continue
 		}
+		spec := r.weapons[proj.WeaponID]
+		age := float32(now.Sub(proj.SpawnTime).Seconds())
+
+		// Fused explosives (grenades) ignore contact and go off when the fuse burns down
+		if spec.FuseTimeSec > 0 && age >= spec.FuseTimeSec {
+			proj.Active = false
+			r.explode(proj, spec, tick, now)
+			continue
+		}
 		// Age check
-		if time.Since(proj.SpawnTime).Seconds() > float64(proj.MaxLifeSec) {
+		if age > proj.MaxLifeSec {
 			proj.Active = false
 			continue
 		}
 		// Move projectile
+		proj.Velocity.Y -= spec.ProjectileGravity * deltaTime
 		proj.Position.X += proj.Velocity.X * deltaTime
 		proj.Position.Y += proj.Velocity.Y * deltaTime
+		if spec.FuseTimeSec > 0 {
+			continue
+		}
 
 		// Check collisions with players
-		spec := game.Weapons[proj.WeaponID]
 		for _, p := range r.Players {
-			if !p.IsAlive || p.ID == proj.OwnerID {
+			if !p.IsAlive || p.ID == proj.OwnerID || !r.canDamage(proj.OwnerID, p) {
 				continue
 			}
 			dist := proj.Position.Distance(p.Position)
@@ -303,34 +465,7 @@ func (r *Room) tick(tick int, deltaTime float32) {
 			}
 			if dist <= hitRadius {
 				proj.Active = false
-				damage := int(spec.DamagePerShot)
-				p.TakeDamage(damage)
-				if !p.IsAlive {
-					if shooter, ok := r.Players[proj.OwnerID]; ok {
-						shooter.Kills++
-						shooter.DamageDealt += damage
-						
-						// Update Team Score
-						r.TeamScores[shooter.Team]++
-
-						r.Events = append(r.Events, game.MatchEvent{
-							Tick: tick, Type: "KILL",
-							ActorID: proj.OwnerID, TargetID: p.ID,
-							WeaponID: proj.WeaponID, OccurredAt: now,
-						})
-
-						// Check victory conditions
-						if r.GameMode == "TDM" {
-							if r.TeamScores[shooter.Team] >= r.KillLimit {
-								r.State = StateFinished
-							}
-						} else {
-							if shooter.Kills >= r.KillLimit {
-								r.State = StateFinished
-							}
-						}
-					}
-				}
+				r.hit(proj.OwnerID, p, proj.WeaponID, int(spec.DamageAt(age*spec.ProjectileSpeed)), tick, now)
 				break
 			}
 		}
@@ -339,13 +474,76 @@ func (r *Room) tick(tick int, deltaTime float32) {
 	// Check time limit
 	if r.State == StateInProgress && time.Since(r.StartedAt).Seconds() >= float64(r.TimeLimitSec) {
 		r.State = StateFinished
-		r.Events = append(r.Events, game.MatchEvent{Tick: tick, Type: "MATCH_END", OccurredAt: now})
 	}
 
-	// Respawn pickups
-	for _, pk := range r.Pickups {
-		if !pk.IsActive && now.After(pk.RespawnAt) {
-			pk.IsActive = true
+	r.collectPickups(tick, now)
+	r.updatePickups(now)
+
+	if r.State == StateFinished {
+		r.finish()
+	}
+	r.recordFrame(tick)
+	if r.State == StateFinished {
+		r.closeRecorder()
+	}
+}
+
+// canDamage applies the friendly fire rule. Caller must hold r.mu.
+func (r *Room) canDamage(attackerID int, target *game.Player) bool {
+	attacker, ok := r.Players[attackerID]
+	return !ok || r.FriendlyFire || r.GameMode != "TDM" || attacker.Team != target.Team
+}
+
+// explode damages every player in the blast radius. Caller must hold r.mu.
+func (r *Room) explode(proj *game.Projectile, spec game.WeaponSpec, tick int, now time.Time) {
+	for _, p := range r.Players {
+		if !p.IsAlive || p.ID == proj.OwnerID || !r.canDamage(proj.OwnerID, p) {
+			continue
+		}
+		if proj.Position.Distance(p.Position) <= float64(spec.BlastRadius) {
+			r.hit(proj.OwnerID, p, proj.WeaponID, int(spec.DamagePerShot), tick, now)
+		}
+	}
+}
+
+// hit applies damage and, on a kill, scoring, the kill event and the
+// victory check. Caller must hold r.mu.
+func (r *Room) hit(shooterID int, p *game.Player, weaponID game.WeaponID, damage int, tick int, now time.Time) {
+	p.TakeDamage(damage)
+	if p.IsAlive {
+		return
+	}
+	p.Lock()
+	p.RespawnAt = now.Add(r.RespawnDelay)
+	p.Unlock()
+
+	shooter, ok := r.Players[shooterID]
+	if !ok {
+		return
+	}
+	shooter.Kills++
+	if p.UserID == BotUserID {
+		shooter.BotKills++
+	}
+	shooter.DamageDealt += damage
+
+	// Update Team Score
+	r.TeamScores[shooter.Team]++
+
+	r.Events = append(r.Events, game.MatchEvent{
+		Tick: tick, Type: "KILL",
+		ActorID: shooterID, TargetID: p.ID,
+		WeaponID: weaponID, OccurredAt: now,
+	})
+
+	// Check victory conditions
+	if r.GameMode == "TDM" {
+		if r.TeamScores[shooter.Team] >= r.KillLimit {
+			r.State = StateFinished
+		}
+	} else {
+		if shooter.Kills >= r.KillLimit {
+			r.State = StateFinished
 		}
 	}
 }
@@ -374,6 +572,9 @@ func (r *Room) safeSpawnPoint(player *game.Player) game.Vec2 {
 	return bestSpawn
 }
 
+// HandlePlayerInput applies an input at once, for tests and tools that drive
+// a room without its loop. Servers queue inputs with QueueInput so that only
+// the room's goroutine applies them.
 func (r *Room) HandlePlayerInput(playerID int, input game.PlayerInput) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -390,6 +591,8 @@ func (r *Room) processPlayerInput(playerID int, input game.PlayerInput) {
 	p.Lock()
 	defer p.Unlock()
 
+	r.checkInput(p, input)
+
 	// Authoritative movement logic (simplified for Phase 1)
 	// In production, we would use physics.ValidateMove
 	p.Velocity.X = input.Horizontal * game.MaxSpeedX
@@ -406,60 +609,398 @@ func (r *Room) processPlayerInput(playerID int, input game.PlayerInput) {
 	p.AimAngleDeg = input.AimAngle
 	p.LastInputSeq = input.Sequence
 
-	// Weapon fire logic
-	if input.Firing && game.Weapons[game.WeaponID(input.WeaponID)].ID != 0 {
-		// Verify fire rate in production
-		spec := game.Weapons[game.WeaponID(input.WeaponID)]
-		
-		if spec.IsHitscan {
-			// Raycast logic (simplified: check all players in line of sight)
-			// For Phase 1, we'll just log and let clients handle visual hitscan
-			// while server validates damage if client claims a hit via separate packet
-		} else {
-			// Projectile logic: Spawn projectile
-			proj := &game.Projectile{
-				ID:         len(r.Projectiles) + 1,
-				OwnerID:    p.ID,
-				WeaponID:   game.WeaponID(input.WeaponID),
-				Position:   p.Position,
-				Velocity:   game.Vec2{
-					X: float32(math.Cos(float64(p.AimAngleDeg) * math.Pi / 180.0)) * spec.ProjectileSpeed,
-					Y: float32(math.Sin(float64(p.AimAngleDeg) * math.Pi / 180.0)) * spec.ProjectileSpeed,
-				},
-				SpawnTime:  time.Now(),
-				MaxLifeSec: 5.0,
-				Active:     true,
-			}
-			r.Projectiles = append(r.Projectiles, proj)
+	if r.recorder != nil {
+		r.frame.Inputs = append(r.frame.Inputs, replay.InputRecord{PlayerID: playerID, Input: input})
+	}
+
+	// Weapon inventory: the client's weapon ID is only a hint, naming the
+	// other slot's weapon is treated as a switch request
+	now := time.Now()
+	if input.Switch || (input.WeaponID != 0 && game.WeaponID(input.WeaponID) == p.SlotWeapon(p.OtherSlot()) && !r.dualWielding(p)) {
+		r.switchWeapon(p, now)
+	}
+	if input.Interact {
+		r.swapWeapon(p, r.currentTick, now)
+	}
+	if input.Melee {
+		r.melee(p, r.currentTick, now)
+	}
+	if input.Throw {
+		r.throwGrenade(p, r.currentTick, now)
+	}
+	if input.PlaceMine {
+		r.placeMine(p, r.currentTick, now)
+	}
+	if input.Firing {
+		shots, tooFast := r.fire(p, now)
+		if tooFast {
+			r.flagCheat(p, "fire_rate")
+		}
+		for _, spec := range shots {
+			r.spawnShot(p, spec, now)
 		}
 	}
 }
 
-// SetBroadcastFunc registers the network callback for sending state to clients
-func (r *Room) SetBroadcastFunc(f func(roomID string, tick int, players []*game.Player, pickups []*game.Pickup, events []game.MatchEvent)) {
+// checkInput counts input fields that fail server-side validation as
+// anti-cheat events; the input itself is applied unchanged
+func (r *Room) checkInput(p *game.Player, input game.PlayerInput) {
+	if input.Horizontal > 1 || input.Horizontal < -1 || input.Vertical > 1 || input.Vertical < -1 {
+		r.flagCheat(p, "input_range")
+	}
+	if !physics.ValidateAimAngle(input.AimAngle) {
+		r.flagCheat(p, "aim_angle")
+	}
+}
+
+// flagCheat counts a failed validation; bots are trusted and never counted
+func (r *Room) flagCheat(p *game.Player, check string) {
+	if p.UserID != BotUserID {
+		metrics.AntiCheatEvents.With(check).Inc()
+	}
+}
+
+// SetBroadcastFunc registers the network callback for sending state to
+// clients. It is called on the room's goroutine with a snapshot it may keep.
+func (r *Room) SetBroadcastFunc(f func(Snapshot)) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.broadcastFunc = f
 }
 
+// BroadcastWorldState snapshots the world and passes it to the broadcast
+// func, outside the lock
 func (r *Room) BroadcastWorldState(tick int) {
+	r.mu.Lock()
+	send := r.broadcastFunc
+	if send == nil {
+		r.mu.Unlock()
+		return
+	}
+	snap := r.snapshot(tick)
+	r.mu.Unlock()
+	send(snap)
+}
+
+// Stop ends the room's tick goroutine. Safe to call more than once.
+func (r *Room) Stop() {
+	r.stopOnce.Do(func() { close(r.stopCh) })
+}
+
+// End force-finishes the match, e.g. from the admin API or on shutdown.
+// A running tick loop removes the room on its next tick; one that never
+// started is removed here.
+func (r *Room) End(reason string) {
+	r.mu.Lock()
+	if r.finished {
+		r.mu.Unlock()
+		return
+	}
+	r.State = StateFinished
+	r.finish()
+	r.recordFrame(r.currentTick)
+	r.closeRecorder()
+	started := r.started
+	r.mu.Unlock()
+	log.Printf("Room %s: match ended (%s)", r.ID, reason)
+	if !started {
+		r.releaseSlot()
+	}
+}
+
+// releaseSlot removes the room from its manager, freeing its slot
+func (r *Room) releaseSlot() {
+	if r.release != nil {
+		r.release()
+	}
+}
+
+// IsFinished reports whether the room's match is over
+func (r *Room) IsFinished() bool {
 	r.mu.RLock()
-	if r.broadcastFunc == nil {
-		r.mu.RUnlock()
+	defer r.mu.RUnlock()
+	return r.State == StateFinished
+}
+
+// IsActive reports whether a match is being played in this room
+func (r *Room) IsActive() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.State == StateInProgress || r.State == StateCountdown
+}
+
+// ── Match results ─────────────────────────────────────────────────────────────
+
+type MatchResult struct {
+	MatchID     string
+	GameMode    string
+	MapID       string
+	StartedAt   time.Time
+	EndedAt     time.Time
+	WinningTeam string // "" on a draw
+	Players     []PlayerResult
+}
+
+type PlayerResult struct {
+	UserID      string
+	Name        string
+	Team        string
+	IsBot       bool
+	Kills       int
+	BotKills    int // the part of Kills scored on bots, left out of reports
+	Deaths      int
+	DamageDealt int
+	Won         bool
+}
+
+// finish closes out the match exactly once: emits MATCH_END and hands the
+// result to the finish handler. Caller must hold r.mu.
+func (r *Room) finish() {
+	if r.finished {
+		return
+	}
+	r.finished = true
+	now := time.Now()
+	r.Events = append(r.Events, game.MatchEvent{Tick: r.currentTick, Type: "MATCH_END", OccurredAt: now})
+
+	if r.StartedAt.IsZero() || r.onFinish == nil {
 		return
 	}
-	
-	players := make([]*game.Player, 0, len(r.Players))
+	r.onFinish(r.result(now))
+}
+
+// result builds the final scoreboard. Caller must hold r.mu.
+func (r *Room) result(endedAt time.Time) MatchResult {
+	res := MatchResult{
+		MatchID:   r.ID,
+		GameMode:  r.GameMode,
+		MapID:     r.MapID,
+		StartedAt: r.StartedAt,
+		EndedAt:   endedAt,
+	}
+
+	if r.GameMode == "TDM" {
+		switch {
+		case r.TeamScores["RED"] > r.TeamScores["BLUE"]:
+			res.WinningTeam = "RED"
+		case r.TeamScores["BLUE"] > r.TeamScores["RED"]:
+			res.WinningTeam = "BLUE"
+		}
+	} else {
+		best, tied := -1, false
+		for _, p := range r.Players {
+			switch {
+			case p.Kills > best:
+				best, tied = p.Kills, false
+				res.WinningTeam = p.Team
+			case p.Kills == best:
+				tied = true
+			}
+		}
+		if tied {
+			res.WinningTeam = ""
+		}
+	}
+
 	for _, p := range r.Players {
-		players = append(players, p)
+		res.Players = append(res.Players, PlayerResult{
+			UserID:      p.UserID,
+			Name:        p.DisplayName,
+			Team:        p.Team,
+			IsBot:       p.UserID == BotUserID,
+			Kills:       p.Kills,
+			BotKills:    p.BotKills,
+			Deaths:      p.Deaths,
+			DamageDealt: p.DamageDealt,
+			Won:         res.WinningTeam != "" && p.Team == res.WinningTeam,
+		})
 	}
+	sort.Slice(res.Players, func(i, j int) bool { return res.Players[i].Kills > res.Players[j].Kills })
+	return res
+}
 
-	r.mu.RUnlock()
-	r.broadcastFunc(r.ID, tick, players, r.Pickups, r.Events)
+func (r *Room) HasPlayer(playerID int) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.Players[playerID]
+	return ok
 }
 
-func (r *Room) Stop() {
-	close(r.stopCh)
+// SeatOf returns the player an account holds in a match that is still
+// running, so a reconnecting client can take its seat back
+func (r *Room) SeatOf(userID string) (int, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.State == StateFinished || userID == BotUserID {
+		return 0, false
+	}
+	for _, p := range r.Players {
+		if p.UserID == userID {
+			return p.ID, true
+		}
+	}
+	return 0, false
+}
+
+// ── Introspection ─────────────────────────────────────────────────────────────
+
+type RoomInfo struct {
+	ID            string     `json:"id"`
+	GameMode      string     `json:"game_mode"`
+	MapID         string     `json:"map_id"`
+	State         RoomState  `json:"state"`
+	Players       int        `json:"players"`
+	Bots          int        `json:"bots"`
+	MaxPlayers    int        `json:"max_players"`
+	Reserved      bool       `json:"reserved"`
+	CreatedAt     time.Time  `json:"created_at"`
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+	UptimeSeconds float64    `json:"uptime_seconds"`
+}
+
+type PlayerSummary struct {
+	ID       int       `json:"id"`
+	UserID   string    `json:"user_id"`
+	Name     string    `json:"name"`
+	Team     string    `json:"team"`
+	IsBot    bool      `json:"is_bot"`
+	IsAlive  bool      `json:"is_alive"`
+	Health   int       `json:"health"`
+	Kills    int       `json:"kills"`
+	Deaths   int       `json:"deaths"`
+	Position game.Vec2 `json:"position"`
+}
+
+func (r *Room) Info() RoomInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	info := RoomInfo{
+		ID:         r.ID,
+		GameMode:   r.GameMode,
+		MapID:      r.MapID,
+		State:      r.State,
+		Players:    len(r.Players) - len(r.Bots),
+		Bots:       len(r.Bots),
+		MaxPlayers: r.MaxPlayers,
+		Reserved:   r.roster != nil,
+		CreatedAt:  r.CreatedAt,
+	}
+	if !r.StartedAt.IsZero() {
+		started := r.StartedAt
+		info.StartedAt = &started
+		info.UptimeSeconds = time.Since(r.StartedAt).Seconds()
+	}
+	return info
+}
+
+func (r *Room) PlayerSummaries() []PlayerSummary {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := make([]PlayerSummary, 0, len(r.Players))
+	for _, p := range r.Players {
+		list = append(list, PlayerSummary{
+			ID:       p.ID,
+			UserID:   p.UserID,
+			Name:     p.DisplayName,
+			Team:     p.Team,
+			IsBot:    p.UserID == BotUserID,
+			IsAlive:  p.IsAlive,
+			Health:   p.Health,
+			Kills:    p.Kills,
+			Deaths:   p.Deaths,
+			Position: p.Position,
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// ── Replay recording ──────────────────────────────────────────────────────────
+
+func playerInfo(p *game.Player) replay.PlayerInfo {
+	return replay.PlayerInfo{
+		ID:     p.ID,
+		UserID: p.UserID,
+		Name:   p.DisplayName,
+		Team:   p.Team,
+		IsBot:  p.UserID == BotUserID,
+	}
+}
+
+// openRecorder starts the replay file. Caller must hold r.mu.
+func (r *Room) openRecorder() {
+	if r.replayDir == "" {
+		return
+	}
+
+	h := replay.Header{
+		MatchID:      r.ID,
+		GameMode:     r.GameMode,
+		MapID:        r.MapID,
+		TickRate:     r.TickRate,
+		TimeLimitSec: r.TimeLimitSec,
+		KillLimit:    r.KillLimit,
+		StartedAt:    r.StartedAt,
+	}
+	for _, p := range r.Players {
+		h.Players = append(h.Players, playerInfo(p))
+	}
+
+	path := filepath.Join(r.replayDir, r.ID+replay.Extension)
+	w, err := replay.Create(path, h)
+	if err != nil {
+		log.Printf("Room %s: replay disabled: %v", r.ID, err)
+		return
+	}
+	r.recorder = w
+	r.recordedEvents = len(r.Events)
+}
+
+// recordFrame writes this tick's inputs, events and player states. Caller must hold r.mu.
+func (r *Room) recordFrame(tick int) {
+	if r.recorder == nil {
+		return
+	}
+
+	r.frame.Tick = tick
+	r.frame.Events = r.Events[r.recordedEvents:]
+	r.recordedEvents = len(r.Events)
+	r.frame.Players = r.frame.Players[:0]
+	for _, p := range r.Players {
+		r.frame.Players = append(r.frame.Players, replay.PlayerSnapshot{
+			ID:          p.ID,
+			Position:    p.Position,
+			Velocity:    p.Velocity,
+			AimAngleDeg: p.AimAngleDeg,
+			Health:      p.Health,
+			JetpackFuel: p.JetpackFuel,
+			IsFlying:    p.IsFlying,
+			IsAlive:     p.IsAlive,
+		})
+	}
+
+	if err := r.recorder.WriteFrame(&r.frame); err != nil {
+		log.Printf("Room %s: replay write failed, recording stopped: %v", r.ID, err)
+		r.closeRecorder()
+		return
+	}
+
+	r.frame.Joined = r.frame.Joined[:0]
+	r.frame.Left = r.frame.Left[:0]
+	r.frame.Inputs = r.frame.Inputs[:0]
+}
+
+// closeRecorder finalises the replay file. Caller must hold r.mu.
+func (r *Room) closeRecorder() {
+	if r.recorder == nil {
+		return
+	}
+	if err := r.recorder.Close(); err != nil {
+		log.Printf("Room %s: closing replay: %v", r.ID, err)
+	}
+	r.recorder = nil
 }
 
 // Manager manages all active rooms
@@ -468,6 +1009,10 @@ type Manager struct {
 	rooms         map[string]*Room
 	maxRooms      int
 	tickRate      int
+	replayDir     string
+	draining      bool
+	onFinish      func(MatchResult)
+	rules         func(gameMode, mapID string) Rules
 }
 
 func NewManager(maxRooms, tickRate int) *Manager {
@@ -478,13 +1023,34 @@ func NewManager(maxRooms, tickRate int) *Manager {
 	}
 }
 
+// SetReplayDir enables match recording for rooms created afterwards.
+// An empty dir disables recording.
+func (m *Manager) SetReplayDir(dir string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.replayDir = dir
+}
+
 func (m *Manager) CreateRoom(gameMode, mapID string) (*Room, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	return m.createRoomLocked(gameMode, mapID)
+}
+
+func (m *Manager) createRoomLocked(gameMode, mapID string) (*Room, error) {
+	if m.draining {
+		return nil, ErrDraining
+	}
 	if len(m.rooms) >= m.maxRooms {
-		return nil, fmt.Errorf("server at max room capacity")
+		return nil, ErrServerFull
 	}
 	r := NewRoom(gameMode, mapID, m.tickRate)
+	if m.rules != nil {
+		r.ApplyRules(m.rules(gameMode, mapID))
+	}
+	r.replayDir = m.replayDir
+	r.onFinish = m.onFinish
+	r.release = func() { m.RemoveRoom(r.ID) }
 	m.rooms[r.ID] = r
 	return r, nil
 }
@@ -502,6 +1068,7 @@ func (m *Manager) RemoveRoom(id string) {
 	if r, ok := m.rooms[id]; ok {
 		r.Stop()
 		delete(m.rooms, id)
+		metrics.ForgetRoom(id)
 	}
 }
 
@@ -514,3 +1081,89 @@ func (m *Manager) ListRooms() []*Room {
 	}
 	return list
 }
+
+// Drain stops the manager from creating rooms; running rooms are untouched.
+func (m *Manager) Drain() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.draining {
+		log.Printf("Room manager: draining, no new rooms will be created")
+	}
+	m.draining = true
+}
+
+// SetFinishHandler registers f to receive the result of every completed match
+// in rooms created afterwards. f is called with the room lock held and must not block.
+func (m *Manager) SetFinishHandler(f func(MatchResult)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onFinish = f
+}
+
+// ActiveMatches counts rooms with a match still being played
+func (m *Manager) ActiveMatches() int {
+	n := 0
+	for _, r := range m.ListRooms() {
+		if r.IsActive() {
+			n++
+		}
+	}
+	return n
+}
+
+// EndAll force-finishes every active match
+func (m *Manager) EndAll(reason string) {
+	for _, r := range m.ListRooms() {
+		if r.IsActive() {
+			r.End(reason)
+		}
+	}
+}
+
+// StopAll stops every room goroutine and forgets the rooms
+func (m *Manager) StopAll() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, r := range m.rooms {
+		r.Stop()
+		delete(m.rooms, id)
+		metrics.ForgetRoom(id)
+	}
+}
+
+func (m *Manager) IsDraining() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.draining
+}
+
+func (m *Manager) MaxRooms() int {
+	return m.maxRooms
+}
+
+// PlayerCount totals humans and bots across all rooms
+func (m *Manager) PlayerCount() (humans, bots int) {
+	for _, r := range m.ListRooms() {
+		r.mu.RLock()
+		humans += len(r.Players) - len(r.Bots)
+		bots += len(r.Bots)
+		r.mu.RUnlock()
+	}
+	return humans, bots
+}
+
+// CountByState reports how many rooms are in each state, including zeroes.
+func (m *Manager) CountByState() map[RoomState]int {
+	counts := map[RoomState]int{
+		StateWaiting:    0,
+		StateCountdown:  0,
+		StateInProgress: 0,
+		StateFinished:   0,
+	}
+	for _, r := range m.ListRooms() {
+		r.mu.RLock()
+		counts[r.State]++
+		r.mu.RUnlock()
+	}
+	return counts
+}
@@ -0,0 +1,52 @@
+package room
+
+import (
+	"testing"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/game"
+)
+
+func TestNavGraphsCoverSpawnsAndPickups(t *testing.T) {
+	for _, mapID := range MapIDs {
+		g := game.NavGraphFor(mapID)
+		if g == nil {
+			t.Fatalf("%s: no nav graph", mapID)
+		}
+		r := NewRoom("FFA", mapID, 30)
+		for _, spawn := range r.SpawnPoints {
+			if n := g.Nearest(spawn); n.Pos.Distance(spawn) > 1 {
+				t.Errorf("%s: spawn %+v has no waypoint (nearest %+v)", mapID, spawn, n.Pos)
+			}
+		}
+		for _, pk := range r.Pickups {
+			if n := g.Nearest(pk.Position); n.Pos.Distance(pk.Position) > game.PickupRadius {
+				t.Errorf("%s: pickup %d has no waypoint (nearest %+v)", mapID, pk.ID, n.Pos)
+			}
+		}
+	}
+}
+
+// A lone bot runs on the real tick loop, without wall-clock waits, and
+// should patrol onto weapon pickups within a minute of match time.
+func TestBotPatrolReachesPickups(t *testing.T) {
+	for _, mapID := range MapIDs {
+		r := NewRoom("FFA", mapID, 30)
+		r.SpawnBots(1, "normal")
+		bot := r.Bots[0].Player
+
+		picked := map[int]bool{}
+		for tick := 1; tick <= 60*30 && len(picked) < 2; tick++ {
+			r.tick(tick, 1.0/30)
+			for _, ev := range r.Events {
+				if ev.Type == "PICKUP" && ev.ActorID == bot.ID {
+					picked[ev.TargetID] = true
+				}
+			}
+			// Keep the secondary slot free so every weapon pickup counts
+			bot.SetSlot(game.SlotSecondary, 0, 0)
+		}
+		if len(picked) < 2 {
+			t.Errorf("%s: bot collected %d pickups in 60s, ended at %+v", mapID, len(picked), bot.Position)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package room
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/game"
+)
+
+// Joins, inputs, kicks and admin reads from other goroutines against a
+// running room loop, the way the server drives it. Meant for go test -race.
+func TestConcurrentJoinsInputsAndBroadcasts(t *testing.T) {
+	const players, inputs = 8, 40
+
+	m := NewManager(4, 60)
+	users := make([]string, players)
+	for i := range users {
+		users[i] = fmt.Sprintf("u%d", i)
+	}
+	r, _, err := m.Reserve(Reservation{MatchID: "race", GameMode: "FFA", MapID: "outpost", UserIDs: users})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules := DefaultRules()
+	rules.BotFill = 4
+	r.ApplyRules(rules)
+
+	snaps := make(chan Snapshot, 64)
+	r.SetBroadcastFunc(func(s Snapshot) {
+		select {
+		case snaps <- s:
+		default:
+		}
+	})
+	go r.Start()
+	go r.Start() // only one loop runs
+	defer r.Stop()
+
+	var wg sync.WaitGroup
+	for i, uid := range users {
+		wg.Add(1)
+		go func(i int, uid string) {
+			defer wg.Done()
+			p, err := r.AddPlayer(uid, "Racer")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			for seq := uint32(1); seq <= inputs; seq++ {
+				r.QueueInput(p.ID, game.PlayerInput{Sequence: seq, Horizontal: 1, Firing: seq%4 == 0})
+				time.Sleep(time.Millisecond)
+			}
+			if i == 0 {
+				r.RemovePlayer(p.ID) // kicked mid-match
+			}
+		}(i, uid)
+	}
+
+	// The admin API and lobby reading the room meanwhile
+	stop := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				r.Info()
+				r.PlayerSummaries()
+				r.HasPlayer(1)
+				m.ListRooms()
+			}
+		}
+	}()
+
+	// Snapshots are kept past later ticks, as a send queue would, and must
+	// not change under us
+	type kept struct{ snap, copy Snapshot }
+	var held []kept
+	deadline := time.After(3 * time.Second)
+	for done := false; !done; {
+		select {
+		case s := <-snaps:
+			held = append(held, kept{s, Snapshot{
+				RoomID:  s.RoomID,
+				Tick:    s.Tick,
+				Players: slices.Clone(s.Players),
+				Pickups: slices.Clone(s.Pickups),
+				Events:  slices.Clone(s.Events),
+			}})
+			done = allInputsApplied(s, users[1:], inputs)
+		case <-deadline:
+			t.Fatal("inputs never all applied")
+		}
+	}
+	close(stop)
+	wg.Wait()
+
+	for _, k := range held {
+		if !reflect.DeepEqual(k.snap, k.copy) {
+			t.Fatalf("snapshot of tick %d changed after it was handed out", k.snap.Tick)
+		}
+	}
+	if slices.ContainsFunc(r.PlayerSummaries(), func(p PlayerSummary) bool { return p.UserID == users[0] }) {
+		t.Fatal("kicked player still in the room")
+	}
+}
+
+// allInputsApplied reports whether each user's last input shows in s
+func allInputsApplied(s Snapshot, users []string, last uint32) bool {
+	for _, uid := range users {
+		i := slices.IndexFunc(s.Players, func(p game.PlayerState) bool { return p.UserID == uid })
+		if i < 0 || s.Players[i].LastInputSeq != last {
+			return false
+		}
+	}
+	return true
+}
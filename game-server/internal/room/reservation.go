@@ -0,0 +1,121 @@
+// SKYBATTLE — Room Reservations
+// Rooms pre-created by the matchmaker under its match ID, open only to the
+// players it matched, and released if none of them show up in time.
+package room
+
+import (
+	"errors"
+	"log"
+	"time"
+)
+
+var (
+	ErrNotOnRoster   = errors.New("player not on match roster")
+	ErrMatchIDInUse  = errors.New("match id already used by another room")
+	ErrInvalidRoster = errors.New("reservation needs a match id and at least one player")
+	ErrBadMatchID    = errors.New("match id must be 1-64 letters, digits, '-' or '_'")
+)
+
+const DefaultReserveTTL = 60 * time.Second
+
+// maxMatchIDLen fits the matchmaker's UUIDs with room to spare
+const maxMatchIDLen = 64
+
+// ValidMatchID reports whether id is safe to use as a room ID. It ends up in
+// replay file names, metric labels and logs, so only plain characters pass.
+func ValidMatchID(id string) bool {
+	if id == "" || len(id) > maxMatchIDLen {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+type Reservation struct {
+	MatchID  string
+	GameMode string
+	MapID    string
+	UserIDs  []string
+	TTL      time.Duration // how long to wait for the first player
+}
+
+// Reserve pre-creates the room for a matchmaker match. Repeating the same
+// reservation returns the existing room, so the matchmaker may retry safely.
+func (m *Manager) Reserve(res Reservation) (r *Room, created bool, err error) {
+	if res.MatchID == "" || len(res.UserIDs) == 0 {
+		return nil, false, ErrInvalidRoster
+	}
+	if !ValidMatchID(res.MatchID) {
+		return nil, false, ErrBadMatchID
+	}
+	if res.TTL <= 0 {
+		res.TTL = DefaultReserveTTL
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if existing, ok := m.rooms[res.MatchID]; ok {
+		existing.mu.RLock()
+		same := existing.roster != nil && existing.GameMode == res.GameMode && existing.MapID == res.MapID
+		existing.mu.RUnlock()
+		if !same {
+			return nil, false, ErrMatchIDInUse
+		}
+		return existing, false, nil
+	}
+
+	r, err = m.createRoomLocked(res.GameMode, res.MapID)
+	if err != nil {
+		return nil, false, err
+	}
+	delete(m.rooms, r.ID)
+	r.ID = res.MatchID
+	r.roster = make(map[string]bool, len(res.UserIDs))
+	for _, uid := range res.UserIDs {
+		r.roster[uid] = true
+	}
+	if len(r.roster) > r.MaxPlayers {
+		r.MaxPlayers = len(r.roster)
+	}
+	r.reservedUntil = time.Now().Add(res.TTL)
+	m.rooms[r.ID] = r
+
+	log.Printf("Room %s: reserved for %d players (%s on %s, expires in %s)", r.ID, len(r.roster), r.GameMode, r.MapID, res.TTL)
+	return r, true, nil
+}
+
+// IsReserved reports whether the room only admits a matchmaker roster
+func (r *Room) IsReserved() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.roster != nil
+}
+
+// ReservedUntil is the deadline for the first rostered player to arrive
+func (r *Room) ReservedUntil() time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.reservedUntil
+}
+
+// ExpireReservations removes reserved rooms nobody on the roster joined
+// before the deadline. Returns the released match IDs.
+func (m *Manager) ExpireReservations(now time.Time) []string {
+	var expired []string
+	for _, r := range m.ListRooms() {
+		r.mu.RLock()
+		stale := r.roster != nil && !r.rosterArrived && r.State == StateWaiting && now.After(r.reservedUntil)
+		r.mu.RUnlock()
+		if stale {
+			m.RemoveRoom(r.ID)
+			expired = append(expired, r.ID)
+			log.Printf("Room %s: reservation expired, nobody joined", r.ID)
+		}
+	}
+	return expired
+}
@@ -0,0 +1,53 @@
+package room
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReservationExpiresWhenNobodyJoins(t *testing.T) {
+	m := NewManager(5, 30)
+	if _, _, err := m.Reserve(Reservation{MatchID: "empty", GameMode: "FFA", MapID: "outpost", UserIDs: []string{"u1"}, TTL: time.Minute}); err != nil {
+		t.Fatal(err)
+	}
+	joined, _, err := m.Reserve(Reservation{MatchID: "joined", GameMode: "FFA", MapID: "outpost", UserIDs: []string{"u2"}, TTL: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := joined.AddPlayer("u2", "Bob"); err != nil {
+		t.Fatal(err)
+	}
+
+	if expired := m.ExpireReservations(time.Now()); len(expired) != 0 {
+		t.Fatalf("expired before deadline: %v", expired)
+	}
+	expired := m.ExpireReservations(time.Now().Add(2 * time.Minute))
+	if len(expired) != 1 || expired[0] != "empty" {
+		t.Fatalf("expired = %v, want [empty]", expired)
+	}
+	if _, ok := m.GetRoom("joined"); !ok {
+		t.Fatal("room with an arrived player was released")
+	}
+}
+
+func TestRosteredPlayerMayJoinRunningMatch(t *testing.T) {
+	m := NewManager(5, 30)
+	r, _, err := m.Reserve(Reservation{MatchID: "m1", GameMode: "FFA", MapID: "outpost", UserIDs: []string{"u1", "u2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.AddPlayer("u1", "Alice")
+	r.mu.Lock()
+	r.State = StateInProgress
+	r.mu.Unlock()
+
+	if _, err := r.AddPlayer("u2", "Bob"); err != nil {
+		t.Fatalf("late rostered join: %v", err)
+	}
+	if _, err := r.AddPlayer("u3", "Eve"); err != ErrNotOnRoster {
+		t.Fatalf("stranger join: err = %v, want ErrNotOnRoster", err)
+	}
+	if _, err := r.AddPlayer("u1", "Alice"); err != ErrAlreadyInRoom {
+		t.Fatalf("second seat for one account: err = %v, want ErrAlreadyInRoom", err)
+	}
+}
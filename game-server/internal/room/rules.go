@@ -0,0 +1,66 @@
+// SKYBATTLE — Match Rules
+// Per-room settings that vary by game mode and map. The config file resolves
+// them; the manager applies them to each room it creates.
+package room
+
+import (
+	"slices"
+	"time"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/game"
+)
+
+type Rules struct {
+	MaxPlayers      int
+	TimeLimitSec    int
+	KillLimit       int
+	RespawnDelaySec float64
+	FriendlyFire    bool   // TDM only: whether teammates can damage each other
+	BotFill         int    // keep the room at this many players with bots, see backfill.go
+	BotDifficulty   string // profile for fill bots, see game.BotProfiles
+}
+
+var (
+	GameModes = []string{"FFA", "TDM"}
+	MapIDs    = []string{"outpost", "catacombs"}
+)
+
+// ValidGameMode reports whether mode is one rooms can be created with
+func ValidGameMode(mode string) bool {
+	return slices.Contains(GameModes, mode)
+}
+
+// ValidMapID reports whether mapID names a map rooms can be created on
+func ValidMapID(mapID string) bool {
+	return slices.Contains(MapIDs, mapID)
+}
+
+func DefaultRules() Rules {
+	return Rules{
+		MaxPlayers:      10,
+		TimeLimitSec:    300, // 5 min default
+		KillLimit:       20,
+		RespawnDelaySec: game.RespawnDelaySec,
+		BotDifficulty:   game.DefaultBotDifficulty,
+	}
+}
+
+// ApplyRules sets the match rules; only meaningful before the match starts
+func (r *Room) ApplyRules(rules Rules) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.MaxPlayers = rules.MaxPlayers
+	r.TimeLimitSec = rules.TimeLimitSec
+	r.KillLimit = rules.KillLimit
+	r.RespawnDelay = time.Duration(rules.RespawnDelaySec * float64(time.Second))
+	r.FriendlyFire = rules.FriendlyFire
+	r.BotFill = rules.BotFill
+	r.BotDifficulty = rules.BotDifficulty
+}
+
+// SetRules installs the resolver used for rooms created afterwards
+func (m *Manager) SetRules(resolve func(gameMode, mapID string) Rules) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rules = resolve
+}
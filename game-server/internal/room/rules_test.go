@@ -0,0 +1,94 @@
+package room
+
+import (
+	"testing"
+	"time"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/game"
+)
+
+func TestManagerAppliesRules(t *testing.T) {
+	m := NewManager(5, 30)
+	m.SetRules(func(gameMode, mapID string) Rules {
+		r := DefaultRules()
+		if gameMode == "TDM" {
+			r.KillLimit = 40
+			r.MaxPlayers = 4
+			r.BotFill = 3
+			r.BotDifficulty = "hard"
+		}
+		return r
+	})
+
+	ffa, _ := m.CreateRoom("FFA", "outpost")
+	if ffa.KillLimit != 20 || ffa.MaxPlayers != 10 {
+		t.Fatalf("FFA rules = kill %d, max %d", ffa.KillLimit, ffa.MaxPlayers)
+	}
+
+	tdm, _ := m.CreateRoom("TDM", "outpost")
+	if tdm.KillLimit != 40 || tdm.MaxPlayers != 4 {
+		t.Fatalf("TDM rules = kill %d, max %d", tdm.KillLimit, tdm.MaxPlayers)
+	}
+	tdm.AddPlayer("u1", "Alice")
+	tdm.fillBots()
+	if len(tdm.Players) != 3 || len(tdm.Bots) != 2 {
+		t.Fatalf("bot fill: %d players, %d bots, want 3 and 2", len(tdm.Players), len(tdm.Bots))
+	}
+	if tdm.Bots[0].Profile.Name != "hard" {
+		t.Fatalf("fill bots are %q, want the rules' hard", tdm.Bots[0].Profile.Name)
+	}
+}
+
+func TestSpawnBotsDifficulty(t *testing.T) {
+	r := NewRoom("FFA", "outpost", 30)
+	if err := r.SpawnBots(1, "insane"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SpawnBots(1, ""); err != nil {
+		t.Fatal(err)
+	}
+	if r.Bots[0].Profile.Name != "insane" || r.Bots[1].Profile.Name != game.DefaultBotDifficulty {
+		t.Fatalf("profiles = %q, %q", r.Bots[0].Profile.Name, r.Bots[1].Profile.Name)
+	}
+	if err := r.SpawnBots(1, "godlike"); err == nil || len(r.Bots) != 2 {
+		t.Fatalf("unknown difficulty: err=%v, %d bots", err, len(r.Bots))
+	}
+}
+
+// shoot places a point-blank projectile from shooter on target and ticks once
+func shoot(r *Room, shooter, target *game.Player) {
+	r.Projectiles = append(r.Projectiles, &game.Projectile{
+		OwnerID:    shooter.ID,
+		WeaponID:   game.WeaponAssaultRifle,
+		Position:   target.Position,
+		SpawnTime:  time.Now(),
+		MaxLifeSec: 1,
+		Active:     true,
+	})
+	r.tick(1, 1.0/30)
+}
+
+func TestFriendlyFireRule(t *testing.T) {
+	for _, ff := range []bool{false, true} {
+		r := NewRoom("TDM", "outpost", 30)
+		rules := DefaultRules()
+		rules.FriendlyFire = ff
+		rules.RespawnDelaySec = 7
+		r.ApplyRules(rules)
+
+		red, _ := r.AddPlayer("u1", "Red1")
+		r.AddPlayer("u2", "Blue1")
+		mate, _ := r.AddPlayer("u3", "Red2")
+		mate.Health = 1
+
+		shoot(r, red, mate)
+		if mate.IsAlive == ff {
+			t.Fatalf("friendly_fire=%v: teammate alive=%v", ff, mate.IsAlive)
+		}
+		if ff {
+			if wait := time.Until(mate.RespawnAt); wait < 6*time.Second {
+				t.Fatalf("respawn in %s, want the configured 7s", wait)
+			}
+		}
+	}
+}
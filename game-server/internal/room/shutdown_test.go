@@ -0,0 +1,74 @@
+package room
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEndAllThenStopAll(t *testing.T) {
+	m := NewManager(5, 30)
+	var results []MatchResult
+	m.SetFinishHandler(func(res MatchResult) { results = append(results, res) })
+
+	running, _ := m.CreateRoom("FFA", "outpost")
+	waiting, _ := m.CreateRoom("FFA", "outpost")
+	running.AddPlayer("u1", "Alice")
+	stopped := make(chan struct{})
+	go func() {
+		running.Start()
+		close(stopped)
+	}()
+	for !running.IsActive() {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	m.EndAll("server shutdown")
+	m.EndAll("server shutdown") // ending twice reports once
+	if m.ActiveMatches() != 0 || len(results) != 1 || results[0].MatchID != running.ID {
+		t.Fatalf("%d active, results %+v", m.ActiveMatches(), results)
+	}
+	if waiting.State != StateWaiting {
+		t.Fatalf("waiting room went %s", waiting.State)
+	}
+
+	m.StopAll()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("tick loop still running after StopAll")
+	}
+	if n := len(m.ListRooms()); n != 0 {
+		t.Fatalf("%d rooms left", n)
+	}
+}
+
+// A match that runs out of time reports, stops ticking and frees its slot
+func TestFinishedMatchFreesItsSlot(t *testing.T) {
+	m := NewManager(1, 30)
+	results := make(chan MatchResult, 1)
+	m.SetFinishHandler(func(res MatchResult) { results <- res })
+
+	r, _ := m.CreateRoom("FFA", "outpost")
+	r.TimeLimitSec = 0
+	r.AddPlayer("u1", "Alice")
+	stopped := make(chan struct{})
+	go func() {
+		r.Start()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("tick loop still running after the match finished")
+	}
+
+	if res := <-results; res.MatchID != r.ID {
+		t.Fatalf("result = %+v", res)
+	}
+	if _, ok := m.GetRoom(r.ID); ok {
+		t.Fatal("finished room still listed")
+	}
+	if _, err := m.CreateRoom("FFA", "outpost"); err != nil {
+		t.Fatalf("no room for the next match: %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+// SKYBATTLE — Room Snapshots
+// The room's goroutine owns the simulation: players, pickups, projectiles
+// and events change in tick, which applies queued inputs first (see
+// inputs.go) and holds the room's lock from start to end. Joins, kicks,
+// bot spawns and admin actions come from other goroutines and take the
+// same lock, so they land between ticks, never during one; a snapshot may
+// already show a player who joined after the tick it follows.
+//
+// Networking never sees the live state. After a tick the room copies what
+// clients are sent into a Snapshot and hands that over: its slices are
+// built for it or are append-only, so the network can encode it on any
+// goroutine, keep it and share it between sessions without locking.
+//
+// Each snapshot carries only the events since the one before, so a world
+// state stays the same size however long the match runs.
+package room
+
+import (
+	"slices"
+
+	"github.com/siddhantkhandelwal18/skybattle/game-server/internal/game"
+)
+
+// Snapshot is a room's world state at the end of a tick. Nothing in it is
+// modified after it is made.
+type Snapshot struct {
+	RoomID  string
+	Tick    int
+	Players []game.PlayerState // by player ID
+	Pickups []game.Pickup
+	Events  []game.MatchEvent // events since the previous snapshot
+}
+
+// snapshot copies the world state and marks its events sent. Caller must
+// hold r.mu for writing.
+func (r *Room) snapshot(tick int) Snapshot {
+	s := Snapshot{
+		RoomID:  r.ID,
+		Tick:    tick,
+		Players: make([]game.PlayerState, 0, len(r.Players)),
+		Pickups: make([]game.Pickup, len(r.Pickups)),
+		// Events are only ever appended, so a slice capped at their current
+		// length never sees a later change
+		Events: r.Events[r.sentEvents:len(r.Events):len(r.Events)],
+	}
+	r.sentEvents = len(r.Events)
+	for _, p := range r.Players {
+		s.Players = append(s.Players, p.State())
+	}
+	slices.SortFunc(s.Players, func(a, b game.PlayerState) int { return a.ID - b.ID })
+	for i, pk := range r.Pickups {
+		s.Pickups[i] = *pk
+	}
+	return s
+}
@@ -14,6 +14,10 @@ namespace SkyBattle.Core
         [Header("Settings")]
         public string ServerBinaryName = "skybattle-server";
         public bool StartOnAwake = false;
+        public int GamePort = 7001;
+
+        // One JSON line per response from the server's stdin control protocol
+        public event System.Action<string> OnControlResponse;
 
         private Process serverProcess;
         private string internalPath;
@@ -101,11 +105,22 @@ namespace SkyBattle.Core
                 serverProcess.StartInfo.WorkingDirectory = Application.persistentDataPath;
                 serverProcess.StartInfo.UseShellExecute = false;
                 serverProcess.StartInfo.CreateNoWindow = true;
-                
-                // Redirect logs if possible
-                // serverProcess.StartInfo.RedirectStandardOutput = true;
-                
+
+                // Offline profile: no backend, phone-sized limits, reports spooled locally
+                serverProcess.StartInfo.EnvironmentVariables["SERVER_PROFILE"] = "offline";
+                serverProcess.StartInfo.EnvironmentVariables["SERVER_PORT"] = GamePort.ToString();
+                serverProcess.StartInfo.EnvironmentVariables["CONTROL_STDIN"] = "true";
+
+                // stdin/stdout carry control commands; logs stay on stderr
+                serverProcess.StartInfo.RedirectStandardInput = true;
+                serverProcess.StartInfo.RedirectStandardOutput = true;
+                serverProcess.OutputDataReceived += (sender, args) =>
+                {
+                    if (!string.IsNullOrEmpty(args.Data)) OnControlResponse?.Invoke(args.Data);
+                };
+
                 serverProcess.Start();
+                serverProcess.BeginOutputReadLine();
                 Debug.Log("Server process started successfully.");
             }
             catch (System.`Exception` e)
@@ -114,11 +129,23 @@ namespace SkyBattle.Core
             }
         }
 
+        // Sends one control command, e.g. {"cmd":"start_room","map_id":"outpost"}
+        // or {"cmd":"token","user_id":"host"}; the reply arrives via OnControlResponse.
+        public void SendCommand(string json)
+        {
+            if (serverProcess == null || serverProcess.HasExited) return;
+            serverProcess.StandardInput.WriteLine(json);
+            serverProcess.StandardInput.Flush();
+        }
+
         public void StopServer()
         {
             if (serverProcess != null && !serverProcess.HasExited)
             {
-                serverProcess.Kill();
+                // Ask for a clean drain so the match report is spooled
+                SendCommand("{\"cmd\":\"shutdown\"}");
+                if (!serverProcess.WaitForExit(3000))
+                    serverProcess.Kill();
                 serverProcess.Dispose();
                 serverProcess = null;
                 Debug.Log("Server process stopped.");
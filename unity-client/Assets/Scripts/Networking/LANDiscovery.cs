@@ -29,6 +29,9 @@ namespace SkyBattle.Networking
         public float BroadcastInterval = 2.0f;
         public float TimeoutThreshold = 5.0f;
 
+        // Dedicated servers answer this with their LANServerData right away
+        private const string DiscoveryQuery = "SKYBATTLE_DISCOVER";
+
         private UdpClient udpClient;
         private Thread receiveThread;
         private bool isScanning;
@@ -92,7 +95,8 @@ namespace SkyBattle.Networking
                 receiveThread = new Thread(new ThreadStart(ReceivePackets));
                 receiveThread.IsBackground = true;
                 receiveThread.Start();
-                
+
+                SendDiscoveryQuery();
                 InvokeRepeating(nameof(CheckTimeouts), TimeoutThreshold, TimeoutThreshold);
             } catch (Exception e) {
                 Debug.LogError($"Failed to start scanning: {e.Message}");
@@ -129,6 +133,19 @@ namespace SkyBattle.Networking
             }
         }
 
+        private void SendDiscoveryQuery()
+        {
+            if (udpClient == null) return;
+
+            try {
+                udpClient.EnableBroadcast = true;
+                byte[] data = Encoding.UTF8.GetBytes(DiscoveryQuery);
+                udpClient.Send(data, data.Length, new IPEndPoint(IPAddress.Broadcast, DiscoveryPort));
+            } catch (Exception e) {
+                Debug.LogWarning($"Discovery query error: {e.Message}");
+            }
+        }
+
         private void ReceivePackets()
         {
             IPEndPoint remoteEP = new IPEndPoint(IPAddress.Any, DiscoveryPort);
@@ -138,6 +155,8 @@ namespace SkyBattle.Networking
                 try {
                     byte[] data = udpClient.Receive(ref remoteEP);
                     string json = Encoding.UTF8.GetString(data);
+                    if (json == DiscoveryQuery) continue; // our own query echoed back
+
                     LANServerData server = JsonUtility.FromJson<LANServerData>(json);
 
                     if (server != null)
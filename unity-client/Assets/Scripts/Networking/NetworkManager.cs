@@ -14,12 +14,23 @@ namespace SkyBattle.Networking
 
         private UdpClient udpClient;
         private IPEndPoint serverEndPoint;
+        private string pendingToken; // sent once the server's challenge arrives
+        private byte[] pendingCookie;
+        private X25519KeyPair handshakeKey;
+        private SessionCrypto session; // seals everything after the auth ack
 
         public event Action<WorldStatePacket> OnWorldStateReceived;
         public event Action<AuthAckPacket> OnAuthAckReceived;
         public event Action<MatchInitPacket> OnMatchInitReceived;
         public event Action<LobbyStatePacket> OnLobbyStateReceived;
         public event Action OnConnectionFailed;
+        public event Action<string> OnUpdateRequired;
+        public event Action<JoinRejectPacket> OnJoinRejected;
+        public event Action<KickPacket> OnKicked;
+        public event Action<ShutdownPacket> OnServerShutdown;
+        public event Action<ErrorPacket> OnServerError;
+
+        public Capabilities NegotiatedCaps { get; private set; }
 
         private void Awake()
         {
@@ -76,6 +87,20 @@ namespace SkyBattle.Networking
         {
             if (data.Length < 1) return;
 
+            if ((ServerPacketType)data[0] == ServerPacketType.ServerSecure)
+            {
+                data = session?.Open(data);
+                if (data == null || data.Length < 1) return; // forged, replayed or no key
+            }
+            else if (session != null && (ServerPacketType)data[0] != ServerPacketType.Challenge
+                && (ServerPacketType)data[0] != ServerPacketType.AuthAck
+                && (ServerPacketType)data[0] != ServerPacketType.Error)
+            {
+                // The server seals everything once the session is keyed; an
+                // error comes in the clear if it has forgotten the session
+                return;
+            }
+
             byte type = data[0];
             byte[] payload = new byte[data.Length - 1];
             Array.Copy(data, 1, payload, 0, payload.Length);
@@ -86,8 +111,21 @@ namespace SkyBattle.Networking
             
             switch ((ServerPacketType)type)
             {
+                case ServerPacketType.Challenge:
+                    OnChallenge(JsonUtility.FromJson<ChallengePacket>(System.Text.Encoding.UTF8.GetString(payload)));
+                    break;
                 case ServerPacketType.AuthAck:
                     var ack = JsonUtility.FromJson<AuthAckPacket>(System.Text.Encoding.UTF8.GetString(payload));
+                    if (ack.ok && pendingToken != null)
+                    {
+                        if (!StartSession(ack)) return;
+                        pendingToken = null;
+                    }
+                    NegotiatedCaps = ack.ok ? (Capabilities)ack.caps : Capabilities.None;
+                    if (!ack.ok && ack.reason == ProtocolInfo.ReasonClientOutdated)
+                    {
+                        OnUpdateRequired?.Invoke(ack.msg);
+                    }
                     OnAuthAckReceived?.Invoke(ack);
                     break;
                 case ServerPacketType.MatchInit:
@@ -102,6 +140,73 @@ namespace SkyBattle.Networking
                     var lobby = JsonUtility.FromJson<LobbyStatePacket>(System.Text.Encoding.UTF8.GetString(payload));
                     OnLobbyStateReceived?.Invoke(lobby);
                     break;
+                case ServerPacketType.JoinReject:
+                    var reject = JsonUtility.FromJson<JoinRejectPacket>(System.Text.Encoding.UTF8.GetString(payload));
+                    Debug.LogWarning($"Join rejected ({reject.reason}): {reject.msg}");
+                    OnJoinRejected?.Invoke(reject);
+                    break;
+                case ServerPacketType.Kick:
+                    var kick = JsonUtility.FromJson<KickPacket>(System.Text.Encoding.UTF8.GetString(payload));
+                    Debug.LogWarning($"Kicked ({kick.reason}): {kick.msg}");
+                    OnKicked?.Invoke(kick);
+                    break;
+                case ServerPacketType.Shutdown:
+                    var shutdown = JsonUtility.FromJson<ShutdownPacket>(System.Text.Encoding.UTF8.GetString(payload));
+                    OnServerShutdown?.Invoke(shutdown);
+                    break;
+                case ServerPacketType.Error:
+                    var error = JsonUtility.FromJson<ErrorPacket>(System.Text.Encoding.UTF8.GetString(payload));
+                    if (error.reason == ProtocolInfo.ReasonBadCookie && pendingToken != null)
+                    {
+                        Authenticate(pendingToken); // cookie expired, start over
+                    }
+                    Debug.LogWarning($"Server error for {(PacketType)error.pkt} ({error.reason}): {error.msg}");
+                    OnServerError?.Invoke(error);
+                    break;
+            }
+        }
+
+        // Starts the handshake: Connect, then Auth echoing the server's cookie
+        public void Authenticate(string token)
+        {
+            pendingToken = token;
+            session = null;
+            SendPacket(PacketType.Connect, new ConnectPacket
+            {
+                ver = ProtocolInfo.Version,
+                pad = new byte[48] // the server never answers with more than it was sent
+            });
+        }
+
+        private void OnChallenge(ChallengePacket challenge)
+        {
+            if (pendingToken == null) return;
+            pendingCookie = challenge.cookie;
+            handshakeKey = new X25519KeyPair();
+            SendPacket(PacketType.Auth, new AuthPacket
+            {
+                token = pendingToken,
+                ver = ProtocolInfo.Version,
+                caps = (uint)ProtocolInfo.Supported,
+                cookie = challenge.cookie,
+                pub = handshakeKey.Public
+            });
+        }
+
+        // Derives the session keys from the ack; false if the server's key
+        // is unusable, in which case the handshake starts over
+        private bool StartSession(AuthAckPacket ack)
+        {
+            try
+            {
+                session = SessionCrypto.Client(handshakeKey, ack.pub, pendingCookie, pendingToken);
+                return true;
+            }
+            catch (System.Security.Cryptography.CryptographicException e)
+            {
+                Debug.LogError($"Key exchange failed: {e.Message}");
+                Authenticate(pendingToken);
+                return false;
             }
         }
 
@@ -114,12 +219,17 @@ namespace SkyBattle.Networking
             byte[] data = new byte[payload.Length + 1];
             data[0] = (byte)type;
             Array.Copy(payload, 0, data, 1, payload.Length);
+            if (session != null && type != PacketType.Connect && type != PacketType.Auth)
+            {
+                data = session.Seal(data);
+            }
 
             udpClient.SendAsync(data, data.Length, serverEndPoint);
         }
 
         public void Disconnect()
         {
+            session = null;
             udpClient?.Close();
             udpClient = null;
             Debug.Log("Disconnected from Game Server.");
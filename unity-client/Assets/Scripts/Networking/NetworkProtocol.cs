@@ -1,8 +1,46 @@
+// Code generated by protogen from protocol.json. DO NOT EDIT.
 using System;
 using UnityEngine;
 
 namespace SkyBattle.Networking
 {
+    // ── Protocol Version ───────────────────────────────────────────────────────
+
+    public static class ProtocolInfo
+    {
+        public const ushort Version = 4;
+        public const Capabilities Supported = Capabilities.Inventory | Capabilities.Actions;
+
+        public const string ReasonAuthFailed = "AUTH_FAILED";             // the token was rejected
+        public const string ReasonTokenExpired = "TOKEN_EXPIRED";         // the token has expired; refresh it and authenticate again
+        public const string ReasonClientOutdated = "CLIENT_OUTDATED";     // the client must update to keep playing
+        public const string ReasonServerOutdated = "SERVER_OUTDATED";     // the client is newer than the server
+        public const string ReasonBadCookie = "BAD_COOKIE";               // the challenge cookie is missing, expired or for another address; Connect again
+        public const string ReasonKeyExchange = "KEY_EXCHANGE_FAILED";    // the auth packet had no usable X25519 public key
+        public const string ReasonRoomNotFound = "ROOM_NOT_FOUND";        // no room has the requested match id
+        public const string ReasonNoOpenRoom = "NO_OPEN_ROOM";            // every room is reserved for a matched game
+        public const string ReasonRoomFull = "ROOM_FULL";                 // the room has no free slot
+        public const string ReasonMatchInProgress = "MATCH_IN_PROGRESS";  // the match started without you
+        public const string ReasonNotOnRoster = "NOT_ON_ROSTER";          // the match is reserved for other players
+        public const string ReasonAlreadyInMatch = "ALREADY_IN_MATCH";    // the account already holds a seat in the match
+        public const string ReasonDraining = "SERVER_DRAINING";           // the server takes no new players before shutting down
+        public const string ReasonJoinFailed = "JOIN_FAILED";             // any other join failure
+        public const string ReasonKicked = "KICKED";                      // removed by an admin
+        public const string ReasonShutdown = "SERVER_SHUTDOWN";           // the server is going away
+        public const string ReasonMalformedPacket = "MALFORMED_PACKET";   // the packet body did not decode
+        public const string ReasonNotAuthenticated = "NOT_AUTHENTICATED"; // send Auth first, or again after a kick
+        public const string ReasonNotInMatch = "NOT_IN_MATCH";            // input before a successful join
+        public const string ReasonMatchEnded = "MATCH_ENDED";             // the room the session was in is gone
+    }
+
+    [Flags]
+    public enum Capabilities : uint
+    {
+        None = 0,
+        Inventory = 1 << 0, // weapon slots, switching and dropped weapon pickups
+        Actions = 1 << 1,   // melee, grenades and mines, and their events
+    }
+
     // ── Client to Server Packet Types ──────────────────────────────────────────
 
     public enum PacketType : byte
@@ -11,19 +49,51 @@ namespace SkyBattle.Networking
         Input = 2,
         Ping = 3,
         RequestJoin = 4,
-        LobbyReady = 5
+        LobbyReady = 5,
+        Connect = 6,
+        Secure = 7,
     }
 
+    // ── Server to Client Packet Types ──────────────────────────────────────────
+
+    public enum ServerPacketType : byte
+    {
+        WorldState = 10,
+        AuthAck = 11,
+        MatchInit = 12,
+        Pong = 13,
+        LobbyState = 14,
+        JoinReject = 15,
+        Kick = 16,
+        Shutdown = 17,
+        Error = 18,
+        Challenge = 19,
+        ServerSecure = 20,
+    }
+
+    // ── Packet Bodies ──────────────────────────────────────────────────────────
+
     [Serializable]
     public struct AuthPacket
     {
         public string token;
+        public ushort ver;    // ProtocolVersion the client was built against; 0 from clients older than versioning
+        public uint caps;     // Capabilities the client supports
+        public byte[] cookie; // echoed from ChallengePacket
+        public byte[] pub;    // client's X25519 public key for the session
+    }
+
+    [Serializable]
+    public struct ConnectPacket
+    {
+        public ushort ver;
+        public byte[] pad; // zeros; the challenge is only sent if it is no larger than this packet, 64 bytes is enough
     }
 
     [Serializable]
     public struct JoinPacket
     {
-        public string mid; // MatchID
+        public string mid;
     }
 
     [Serializable]
@@ -35,18 +105,12 @@ namespace SkyBattle.Networking
         public float aim;
         public bool fly;
         public bool fire;
-        public byte wpn;
-    }
-
-    // ── Server to Client Packet Types ──────────────────────────────────────────
-
-    public enum ServerPacketType : byte
-    {
-        WorldState = 10,
-        AuthAck = 11,
-        MatchInit = 12,
-        Pong = 13,
-        LobbyState = 14
+        public byte wpn;  // active weapon as the client sees it; the server owns the inventory
+        public bool sw;   // switch primary/secondary
+        public bool use;  // swap the active weapon for the one on the ground
+        public bool mel;  // melee, independent of the held gun
+        public bool thr;  // throw a grenade
+        public bool mine; // drop a proximity mine
     }
 
     [Serializable]
@@ -55,43 +119,7 @@ namespace SkyBattle.Networking
         public int tick;
         public PlayerState[] players;
         public PickupState[] pickups;
-        public MatchEvent[] events;
-    }
-
-    [Serializable]
-    public struct PlayerState
-    {
-        public int id;
-        public string name;
-        public Vector2 pos;
-        public Vector2 vel;
-        public float aim;
-        public int hp;
-        public float fuel;
-        public bool fly;
-        public bool grnd;
-        public uint seq;
-        public bool alive;
-    }
-
-    [Serializable]
-    public struct PickupState
-    {
-        public int id;
-        public string type;
-        public int wpnId;
-        public Vector2 pos;
-        public bool active;
-    }
-
-    [Serializable]
-    public struct MatchEvent
-    {
-        public int tick;
-        public string type;
-        public int actor;
-        public int target;
-        public int wpn;
+        public MatchEvent[] events; // events since the previous world state
     }
 
     [Serializable]
@@ -100,6 +128,11 @@ namespace SkyBattle.Networking
         public bool ok;
         public int id;
         public string msg;
+        public string reason; // machine-readable rejection reason, e.g. CLIENT_OUTDATED
+        public ushort ver;    // version the session speaks, or the server's own on rejection
+        public ushort minVer; // oldest version the server still accepts
+        public uint caps;     // capabilities negotiated for the session
+        public byte[] pub;    // server's X25519 public key for the session
     }
 
     [Serializable]
@@ -126,6 +159,92 @@ namespace SkyBattle.Networking
         public int id;
         public string name;
         public bool ready;
-        public int team;
+        public int team; // 0=RED, 1=BLUE
+    }
+
+    [Serializable]
+    public struct JoinRejectPacket
+    {
+        public string mid; // the match asked for, empty for any open room
+        public string reason;
+        public string msg;
+    }
+
+    [Serializable]
+    public struct KickPacket
+    {
+        public string reason;
+        public string msg;
+    }
+
+    [Serializable]
+    public struct ShutdownPacket
+    {
+        public string reason;
+        public string msg;
+        public int grace; // how long running matches may still play on
+    }
+
+    [Serializable]
+    public struct ErrorPacket
+    {
+        public byte pkt; // type of the client packet that failed
+        public string reason;
+        public string msg;
+    }
+
+    [Serializable]
+    public struct ChallengePacket
+    {
+        public byte[] cookie; // echo in AuthPacket within 30 seconds
+    }
+
+    [Serializable]
+    public struct PlayerState
+    {
+        public int id;
+        public string uid;
+        public string name;
+        public string team; // "RED", "BLUE", or a per-player team in FFA
+        public Vector2 pos;
+        public Vector2 vel;
+        public float aim;
+        public int hp;
+        public int mhp;
+        public float fuel;
+        public float mfuel;
+        public bool grnd;
+        public bool fly;
+        public int wpn1;
+        public int wpn2;    // 0 = empty
+        public int ammo1;
+        public int ammo2;
+        public int slot;    // active slot: 0 = primary, 1 = secondary
+        public int gren;    // grenades left
+        public int mines;   // proximity mines left
+        public uint seq;
+        public bool alive;
+    }
+
+    [Serializable]
+    public struct PickupState
+    {
+        public int id;
+        public string type;
+        public int wpnId;
+        public Vector2 pos;
+        public bool active;
+        public int ammo;
+        public bool dropped; // left behind by a swap, disappears when taken
+    }
+
+    [Serializable]
+    public struct MatchEvent
+    {
+        public int tick;
+        public string type; // KILL, PICKUP, MELEE, THROW or MATCH_END
+        public int actor;
+        public int target;
+        public int wpn;
     }
 }
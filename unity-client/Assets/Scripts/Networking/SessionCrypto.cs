@@ -0,0 +1,366 @@
+using System;
+using System.Numerics;
+using System.Security.Cryptography;
+using System.Text;
+
+namespace SkyBattle.Networking
+{
+    // Session encryption, the client half of game-server/internal/network/crypto.go.
+    // Auth carries our X25519 public key and AuthAck the server's; both sides
+    // derive one AES-256-GCM key per direction and wrap every later packet as
+    //   [type][counter, 8 bytes big-endian][ciphertext][tag, 16 bytes]
+    // The counter is the nonce and, with the type byte, the additional data.
+    public class SessionCrypto
+    {
+        public const int Overhead = HeaderSize + TagSize;
+        private const int HeaderSize = 1 + 8;
+        private const int TagSize = 16;
+        private const int WindowSize = 64;
+
+        private readonly ManagedAesGcm send;
+        private readonly ManagedAesGcm recv;
+        private ulong sent;      // last counter sealed
+        private ulong maxSeen;   // highest counter opened
+        private ulong seen;      // bit i set: counter maxSeen-i has been opened
+
+        private SessionCrypto(byte[] sendKey, byte[] recvKey)
+        {
+            send = new ManagedAesGcm(sendKey);
+            recv = new ManagedAesGcm(recvKey);
+        }
+
+        // Derives the session from our key pair and the server's AuthAck.
+        // Throws CryptographicException if the server's key is unusable.
+        public static SessionCrypto Client(X25519KeyPair ours, byte[] serverPub, byte[] cookie, string token)
+        {
+            byte[] shared = X25519.ScalarMult(ours.Private, serverPub);
+            bool zero = true;
+            foreach (byte b in shared) zero &= b == 0;
+            if (zero) throw new CryptographicException("invalid X25519 public key");
+
+            byte[] salt;
+            using (var sha = SHA256.Create())
+            {
+                byte[] label = Encoding.ASCII.GetBytes("skybattle-session-v1");
+                byte[] tok = Encoding.UTF8.GetBytes(token);
+                sha.TransformBlock(label, 0, label.Length, null, 0);
+                sha.TransformBlock(ours.Public, 0, ours.Public.Length, null, 0);
+                sha.TransformBlock(serverPub, 0, serverPub.Length, null, 0);
+                sha.TransformBlock(cookie, 0, cookie.Length, null, 0);
+                sha.TransformFinalBlock(tok, 0, tok.Length);
+                salt = sha.Hash;
+            }
+
+            byte[] prk;
+            using (var hmac = new HMACSHA256(salt)) prk = hmac.ComputeHash(shared);
+            return new SessionCrypto(Expand(prk, "c2s"), Expand(prk, "s2c"));
+        }
+
+        private static byte[] Expand(byte[] prk, string info)
+        {
+            byte[] label = Encoding.ASCII.GetBytes(info);
+            byte[] input = new byte[label.Length + 1];
+            Array.Copy(label, input, label.Length);
+            input[label.Length] = 1;
+            using (var hmac = new HMACSHA256(prk)) return hmac.ComputeHash(input);
+        }
+
+        // Wraps a plaintext packet (type byte and body) for the server
+        public byte[] Seal(byte[] packet)
+        {
+            ulong n = ++sent;
+            byte[] data = new byte[packet.Length + Overhead];
+            data[0] = (byte)PacketType.Secure;
+            WriteCounter(data, n);
+            byte[] header = new byte[HeaderSize];
+            Array.Copy(data, header, HeaderSize);
+
+            byte[] cipher = new byte[packet.Length];
+            byte[] tag = new byte[TagSize];
+            send.Encrypt(Nonce(n), packet, cipher, tag, header);
+            Array.Copy(cipher, 0, data, HeaderSize, cipher.Length);
+            Array.Copy(tag, 0, data, HeaderSize + cipher.Length, TagSize);
+            return data;
+        }
+
+        // Unwraps a ServerSecure packet, or returns null if it is forged,
+        // corrupt or a replay
+        public byte[] Open(byte[] data)
+        {
+            if (data.Length < Overhead) return null;
+            ulong n = 0;
+            for (int i = 1; i < HeaderSize; i++) n = n << 8 | data[i];
+            if (!Fresh(n)) return null;
+
+            byte[] header = new byte[HeaderSize];
+            Array.Copy(data, header, HeaderSize);
+            int len = data.Length - Overhead;
+            byte[] cipher = new byte[len];
+            byte[] tag = new byte[TagSize];
+            Array.Copy(data, HeaderSize, cipher, 0, len);
+            Array.Copy(data, HeaderSize + len, tag, 0, TagSize);
+            byte[] packet = new byte[len];
+            try
+            {
+                recv.Decrypt(Nonce(n), cipher, tag, packet, header);
+            }
+            catch (CryptographicException)
+            {
+                return null;
+            }
+            Mark(n);
+            return packet;
+        }
+
+        private bool Fresh(ulong n)
+        {
+            if (n == 0) return false;
+            if (n > maxSeen) return true;
+            if (maxSeen - n >= WindowSize) return false;
+            return (seen & (1UL << (int)(maxSeen - n))) == 0;
+        }
+
+        private void Mark(ulong n)
+        {
+            if (n > maxSeen)
+            {
+                ulong shift = n - maxSeen;
+                seen = shift >= WindowSize ? 0 : seen << (int)shift;
+                maxSeen = n;
+            }
+            seen |= 1UL << (int)(maxSeen - n);
+        }
+
+        private static void WriteCounter(byte[] data, ulong n)
+        {
+            for (int i = 8; i >= 1; i--, n >>= 8) data[i] = (byte)n;
+        }
+
+        private static byte[] Nonce(ulong n)
+        {
+            byte[] nonce = new byte[12];
+            for (int i = 11; i >= 4; i--, n >>= 8) nonce[i] = (byte)n;
+            return nonce;
+        }
+    }
+
+    // AES-GCM (NIST SP 800-38D) with 12-byte nonces and 16-byte tags, in the
+    // shape of System.Security.Cryptography.AesGcm. That class is missing
+    // from Unity's Mono and IL2CPP players and throws on the platforms that
+    // do ship it without OS support, so GCM is built here on plain AES,
+    // which every Unity player has.
+    public sealed class ManagedAesGcm
+    {
+        private const int BlockSize = 16;
+        private readonly ICryptoTransform aes;
+        private readonly ulong hHi, hLo; // hash subkey, E(K, 0)
+
+        public ManagedAesGcm(byte[] key)
+        {
+            var alg = Aes.Create();
+            alg.Mode = CipherMode.ECB;
+            alg.Padding = PaddingMode.None;
+            alg.Key = key;
+            aes = alg.CreateEncryptor();
+
+            byte[] h = new byte[BlockSize];
+            aes.TransformBlock(h, 0, BlockSize, h, 0);
+            hHi = ReadUInt64(h, 0);
+            hLo = ReadUInt64(h, 8);
+        }
+
+        public void Encrypt(byte[] nonce, byte[] plaintext, byte[] ciphertext, byte[] tag, byte[] associatedData)
+        {
+            Check(nonce, plaintext, ciphertext, tag);
+            Ctr(nonce, plaintext, ciphertext);
+            ComputeTag(nonce, associatedData, ciphertext, tag);
+        }
+
+        // Throws CryptographicException, leaving plaintext untouched, if the
+        // tag does not match
+        public void Decrypt(byte[] nonce, byte[] ciphertext, byte[] tag, byte[] plaintext, byte[] associatedData)
+        {
+            Check(nonce, ciphertext, plaintext, tag);
+            byte[] want = new byte[BlockSize];
+            ComputeTag(nonce, associatedData, ciphertext, want);
+            int diff = 0;
+            for (int i = 0; i < BlockSize; i++) diff |= want[i] ^ tag[i];
+            if (diff != 0) throw new CryptographicException("the authentication tag does not match");
+            Ctr(nonce, ciphertext, plaintext);
+        }
+
+        private static void Check(byte[] nonce, byte[] input, byte[] output, byte[] tag)
+        {
+            if (nonce.Length != 12 || tag.Length != BlockSize || output.Length != input.Length)
+                throw new ArgumentException("AES-GCM takes a 12-byte nonce, a 16-byte tag and an output as long as its input");
+        }
+
+        // Counter mode from block 2; block 1 masks the tag
+        private void Ctr(byte[] nonce, byte[] input, byte[] output)
+        {
+            byte[] counter = CounterBlock(nonce, 2);
+            byte[] stream = new byte[BlockSize];
+            for (int off = 0; off < input.Length; off += BlockSize)
+            {
+                aes.TransformBlock(counter, 0, BlockSize, stream, 0);
+                int n = Math.Min(BlockSize, input.Length - off);
+                for (int i = 0; i < n; i++) output[off + i] = (byte)(input[off + i] ^ stream[i]);
+                for (int i = BlockSize - 1; i >= 12 && ++counter[i] == 0; i--) { }
+            }
+        }
+
+        private void ComputeTag(byte[] nonce, byte[] aad, byte[] ciphertext, byte[] tag)
+        {
+            aad = aad ?? new byte[0];
+            ulong yHi = 0, yLo = 0;
+            Absorb(aad, ref yHi, ref yLo);
+            Absorb(ciphertext, ref yHi, ref yLo);
+            yHi ^= (ulong)aad.Length * 8;
+            yLo ^= (ulong)ciphertext.Length * 8;
+            Multiply(ref yHi, ref yLo);
+
+            byte[] mask = new byte[BlockSize];
+            aes.TransformBlock(CounterBlock(nonce, 1), 0, BlockSize, mask, 0);
+            WriteUInt64(tag, 0, yHi ^ ReadUInt64(mask, 0));
+            WriteUInt64(tag, 8, yLo ^ ReadUInt64(mask, 8));
+        }
+
+        // GHASH over data, zero-padded to whole blocks
+        private void Absorb(byte[] data, ref ulong yHi, ref ulong yLo)
+        {
+            byte[] block = new byte[BlockSize];
+            for (int off = 0; off < data.Length; off += BlockSize)
+            {
+                int n = Math.Min(BlockSize, data.Length - off);
+                Array.Clear(block, 0, BlockSize);
+                Array.Copy(data, off, block, 0, n);
+                yHi ^= ReadUInt64(block, 0);
+                yLo ^= ReadUInt64(block, 8);
+                Multiply(ref yHi, ref yLo);
+            }
+        }
+
+        // y = y * H in GF(2^128), bit by bit without data-dependent branches
+        private void Multiply(ref ulong yHi, ref ulong yLo)
+        {
+            ulong zHi = 0, zLo = 0, vHi = hHi, vLo = hLo;
+            for (int i = 0; i < 128; i++)
+            {
+                ulong bit = i < 64 ? yHi >> (63 - i) : yLo >> (127 - i);
+                ulong take = 0UL - (bit & 1);
+                zHi ^= vHi & take;
+                zLo ^= vLo & take;
+                ulong carry = 0UL - (vLo & 1);
+                vLo = vLo >> 1 | vHi << 63;
+                vHi = vHi >> 1 ^ (0xE100000000000000UL & carry);
+            }
+            yHi = zHi;
+            yLo = zLo;
+        }
+
+        private static byte[] CounterBlock(byte[] nonce, uint counter)
+        {
+            byte[] block = new byte[BlockSize];
+            Array.Copy(nonce, block, 12);
+            for (int i = 15; i >= 12; i--, counter >>= 8) block[i] = (byte)counter;
+            return block;
+        }
+
+        private static ulong ReadUInt64(byte[] b, int off)
+        {
+            ulong v = 0;
+            for (int i = 0; i < 8; i++) v = v << 8 | b[off + i];
+            return v;
+        }
+
+        private static void WriteUInt64(byte[] b, int off, ulong v)
+        {
+            for (int i = 7; i >= 0; i--, v >>= 8) b[off + i] = (byte)v;
+        }
+    }
+
+    public class X25519KeyPair
+    {
+        public readonly byte[] Private = new byte[32];
+        public readonly byte[] Public;
+
+        public X25519KeyPair()
+        {
+            using (var rng = RandomNumberGenerator.Create()) rng.GetBytes(Private);
+            byte[] basePoint = new byte[32];
+            basePoint[0] = 9;
+            Public = X25519.ScalarMult(Private, basePoint);
+        }
+    }
+
+    // RFC 7748 X25519. Unity has no built-in Curve25519; this runs once per
+    // handshake, so BigInteger arithmetic is fast enough.
+    public static class X25519
+    {
+        private static readonly BigInteger P = BigInteger.Pow(2, 255) - 19;
+        private static readonly BigInteger A24 = 121665;
+
+        public static byte[] ScalarMult(byte[] scalar, byte[] u)
+        {
+            if (scalar.Length != 32 || u == null || u.Length != 32)
+                throw new CryptographicException("invalid X25519 public key");
+
+            byte[] k = (byte[])scalar.Clone();
+            k[0] &= 248;
+            k[31] &= 127;
+            k[31] |= 64;
+            byte[] uBytes = (byte[])u.Clone();
+            uBytes[31] &= 127;
+
+            BigInteger x1 = Mod(Decode(uBytes)), x2 = 1, z2 = 0, x3 = x1, z3 = 1;
+            int swap = 0;
+            for (int t = 254; t >= 0; t--)
+            {
+                int bit = (k[t >> 3] >> (t & 7)) & 1;
+                swap ^= bit;
+                if (swap == 1) { Swap(ref x2, ref x3); Swap(ref z2, ref z3); }
+                swap = bit;
+
+                BigInteger a = Mod(x2 + z2), aa = Mod(a * a);
+                BigInteger b = Mod(x2 - z2), bb = Mod(b * b);
+                BigInteger e = Mod(aa - bb);
+                BigInteger c = Mod(x3 + z3), d = Mod(x3 - z3);
+                BigInteger da = Mod(d * a), cb = Mod(c * b);
+                x3 = Mod((da + cb) * (da + cb));
+                z3 = Mod(x1 * Mod((da - cb) * (da - cb)));
+                x2 = Mod(aa * bb);
+                z2 = Mod(e * (aa + A24 * e));
+            }
+            if (swap == 1) { Swap(ref x2, ref x3); Swap(ref z2, ref z3); }
+
+            return Encode(Mod(x2 * BigInteger.ModPow(z2, P - 2, P)));
+        }
+
+        private static BigInteger Mod(BigInteger x)
+        {
+            x %= P;
+            return x.Sign < 0 ? x + P : x;
+        }
+
+        private static void Swap(ref BigInteger a, ref BigInteger b)
+        {
+            BigInteger t = a; a = b; b = t;
+        }
+
+        // Little-endian, unsigned
+        private static BigInteger Decode(byte[] b)
+        {
+            byte[] le = new byte[33];
+            Array.Copy(b, le, 32);
+            return new BigInteger(le);
+        }
+
+        private static byte[] Encode(BigInteger x)
+        {
+            byte[] le = x.ToByteArray();
+            byte[] out32 = new byte[32];
+            Array.Copy(le, out32, Math.Min(le.Length, 32));
+            return out32;
+        }
+    }
+}